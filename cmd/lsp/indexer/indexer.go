@@ -3,30 +3,64 @@ package indexer
 import (
 	"context"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/tliron/commonlog"
+	"golang.org/x/exp/maps"
 
 	"github.com/nevalang/neva/internal/builder"
 	"github.com/nevalang/neva/internal/compiler"
 	"github.com/nevalang/neva/internal/compiler/analyzer"
 	"github.com/nevalang/neva/internal/compiler/parser"
 	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
 )
 
 type Indexer struct {
 	fe       compiler.Frontend
+	builder  builder.Builder
+	parser   parser.Parser
 	analyzer analyzer.Analyzer
 	logger   commonlog.Logger
 }
 
+// Index is what indexer knows about the nevalang module in the workspace.
+type Index struct {
+	// Parsed is a build as it's written in the source code.
+	// Unlike analyzed one it's available even if there are semantic errors
+	// and its type expressions are not replaced with resolved ones.
+	Parsed src.Build
+	// Analyzed is a result of semantic analysis, nil if analysis failed.
+	Analyzed *src.Build
+	// ModulePaths maps every module of the build to its location on disk.
+	ModulePaths map[core.ModuleRef]string
+
+	manifestErrs compiler.Errors                   // manifests only change with full scan
+	parseErrs    map[core.Location]*compiler.Error // files that failed to parse keep their previous version
+	packages     map[packageRef]packageAnalysis    // analysis of every package of the parsed build
+}
+
+type packageRef struct {
+	modRef  core.ModuleRef
+	pkgName string
+}
+
+type packageAnalysis struct {
+	pkg  src.Package
+	errs compiler.Errors // can contain only warnings
+}
+
+// FullScan reads and indexes the module of the workspace from disk.
+// Returned errors are all the problems found in the module and its dependencies, including warnings.
 func (i Indexer) FullScan(
 	ctx context.Context,
 	workspacePath string,
-) (src.Build, bool, *compiler.Error) {
+) (Index, bool, compiler.Errors) {
 	feResult, err := i.fe.Process(ctx, workspacePath)
 	if err != nil {
-		return src.Build{}, false, err
+		return Index{}, false, compiler.Errors{err}
 	}
 
 	if isParentPath(workspacePath, feResult.Path) {
@@ -34,17 +68,214 @@ func (i Indexer) FullScan(
 			"nevalang module found but not part of workspace",
 			"path", feResult.Path, "workspacePath", workspacePath,
 		)
-		return src.Build{}, false, nil
+		return Index{}, false, nil
 	}
 
 	i.logger.Debug("nevalang module found in workspace", "path", feResult.Path)
 
-	aBuild, err := i.analyzer.AnalyzeBuild(feResult.ParsedBuild)
-	if err != nil {
-		return src.Build{}, true, err.Unwrap() // use only deepest compiler error for now
+	entryMod := feResult.ParsedBuild.Modules[feResult.ParsedBuild.EntryModRef]
+	modPaths := make(map[core.ModuleRef]string, len(feResult.ParsedBuild.Modules))
+	for modRef := range feResult.ParsedBuild.Modules {
+		if modRef == feResult.ParsedBuild.EntryModRef {
+			modPaths[modRef] = feResult.Path
+			continue
+		}
+		modPaths[modRef] = i.builder.DepPath(feResult.Path, entryMod.Manifest.Replace, modRef)
+	}
+
+	index := Index{
+		Parsed:      feResult.ParsedBuild,
+		ModulePaths: modPaths,
+		parseErrs:   map[core.Location]*compiler.Error{},
+		packages:    map[packageRef]packageAnalysis{},
+	}
+
+	for modRef := range index.Parsed.Modules {
+		if err := i.analyzer.AnalyzeModuleManifest(modRef, index.Parsed); err != nil {
+			index.manifestErrs = append(index.manifestErrs, err)
+		}
+	}
+
+	affected := map[packageRef]struct{}{}
+	for modRef, mod := range index.Parsed.Modules {
+		for pkgName := range mod.Packages {
+			affected[packageRef{modRef, pkgName}] = struct{}{}
+		}
+	}
+
+	return index, true, i.analyze(&index, affected)
+}
+
+// Update returns copy of the index where given files are re-parsed from given contents.
+// Files are identified by their paths on disk, and they don't have to exist there yet.
+// Only packages that are affected by the change are re-analyzed:
+// packages of the changed files and packages that import them, directly or not.
+func (i Indexer) Update(index Index, files map[string][]byte) (Index, compiler.Errors) {
+	modules := maps.Clone(index.Parsed.Modules)
+	parseErrs := maps.Clone(index.parseErrs)
+	changed := map[packageRef]struct{}{}
+
+	for path, content := range files {
+		loc, ok := index.Location(path)
+		if !ok {
+			continue
+		}
+
+		parsed, err := i.parser.ParseFiles(loc.ModRef, loc.Package, map[string][]byte{
+			loc.Filename: content,
+		})
+		if err != nil {
+			parseErrs[loc] = err
+			continue
+		}
+		delete(parseErrs, loc)
+
+		// packages are shared with the previous index so they must be copied before modification
+		mod := modules[loc.ModRef]
+		packages := maps.Clone(mod.Packages)
+		pkg := maps.Clone(packages[loc.Package])
+		if pkg == nil {
+			pkg = src.Package{}
+		}
+		pkg[loc.Filename] = parsed[loc.Filename]
+		packages[loc.Package] = pkg
+		mod.Packages = packages
+		modules[loc.ModRef] = mod
+
+		changed[packageRef{loc.ModRef, loc.Package}] = struct{}{}
+	}
+
+	index.Parsed = src.Build{
+		EntryModRef: index.Parsed.EntryModRef,
+		Modules:     modules,
+	}
+	index.parseErrs = parseErrs
+	index.packages = maps.Clone(index.packages)
+
+	return index, i.analyze(&index, affectedPackages(index.Parsed, changed))
+}
+
+// Location returns location of the file with the given path on disk.
+// File is not required to be part of the build.
+func (index Index) Location(path string) (core.Location, bool) {
+	var (
+		result     core.Location
+		modPathLen int
+	)
+
+	// modules can be nested so the deepest one is the right one
+	for modRef, modPath := range index.ModulePaths {
+		rel, err := filepath.Rel(modPath, path)
+		if err != nil || strings.HasPrefix(rel, "..") || len(modPath) < modPathLen {
+			continue
+		}
+		result = core.Location{
+			ModRef:   modRef,
+			Package:  filepath.ToSlash(filepath.Dir(rel)),
+			Filename: strings.TrimSuffix(filepath.Base(rel), ".neva"),
+		}
+		modPathLen = len(modPath)
+	}
+
+	return result, modPathLen != 0
+}
+
+// analyze re-analyzes given packages and builds analyzed build if there are no errors.
+// It returns problems of the whole index: manifests, files that failed to parse and all the packages.
+// Analysis problems of packages with unparsable files are not reported because they are outdated.
+func (i Indexer) analyze(index *Index, affected map[packageRef]struct{}) compiler.Errors {
+	for ref := range affected {
+		pkg, errs := i.analyzer.AnalyzePackage(ref.modRef, ref.pkgName, index.Parsed)
+		index.packages[ref] = packageAnalysis{pkg: pkg, errs: errs}
+	}
+
+	errs := slices.Clone(index.manifestErrs)
+
+	unparsed := make(map[packageRef]struct{}, len(index.parseErrs))
+	locs := maps.Keys(index.parseErrs)
+	sort.Slice(locs, func(i, j int) bool {
+		return locs[i].String() < locs[j].String()
+	})
+	for _, loc := range locs {
+		errs = append(errs, index.parseErrs[loc])
+		unparsed[packageRef{loc.ModRef, loc.Package}] = struct{}{}
+	}
+
+	refs := maps.Keys(index.packages)
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].modRef != refs[j].modRef {
+			return refs[i].modRef.String() < refs[j].modRef.String()
+		}
+		return refs[i].pkgName < refs[j].pkgName
+	})
+
+	modules := make(map[core.ModuleRef]src.Module, len(index.Parsed.Modules))
+	for _, ref := range refs {
+		analysis := index.packages[ref]
+		if _, ok := unparsed[ref]; !ok {
+			errs = append(errs, analysis.errs...)
+		}
+		mod, ok := modules[ref.modRef]
+		if !ok {
+			mod = src.Module{
+				Manifest: index.Parsed.Modules[ref.modRef].Manifest,
+				Packages: map[string]src.Package{},
+			}
+		}
+		mod.Packages[ref.pkgName] = analysis.pkg
+		modules[ref.modRef] = mod
+	}
+
+	if errs.HasErrors() {
+		index.Analyzed = nil
+		return errs
+	}
+
+	index.Analyzed = &src.Build{
+		EntryModRef: index.Parsed.EntryModRef,
+		Modules:     modules,
+	}
+
+	return errs
+}
+
+// affectedPackages returns changed packages and all the packages that depend on them.
+// Every package implicitly depends on the builtin package of its std module.
+func affectedPackages(build src.Build, changed map[packageRef]struct{}) map[packageRef]struct{} {
+	dependants := map[packageRef][]packageRef{}
+	for modRef, mod := range build.Modules {
+		for pkgName, pkg := range mod.Packages {
+			ref := packageRef{modRef, pkgName}
+			builtin := packageRef{mod.Manifest.Deps["std"], "builtin"}
+			if modRef.Path == "std" {
+				builtin.modRef = modRef
+			}
+			dependants[builtin] = append(dependants[builtin], ref)
+			for _, file := range pkg {
+				for _, imp := range file.Imports {
+					dep := packageRef{modRef, imp.Package}
+					if imp.Module != "@" {
+						dep.modRef = mod.Manifest.Deps[imp.Module]
+					}
+					dependants[dep] = append(dependants[dep], ref)
+				}
+			}
+		}
+	}
+
+	result := make(map[packageRef]struct{}, len(changed))
+	queue := maps.Keys(changed)
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		if _, ok := result[ref]; ok {
+			continue
+		}
+		result[ref] = struct{}{}
+		queue = append(queue, dependants[ref]...)
 	}
 
-	return aBuild, true, nil
+	return result
 }
 
 func isParentPath(parent, child string) bool {
@@ -70,6 +301,8 @@ func New(
 ) Indexer {
 	return Indexer{
 		fe:       compiler.NewFrontend(builder, parser),
+		builder:  builder,
+		parser:   parser,
 		analyzer: analyzer,
 		logger:   logger,
 	}
@@ -0,0 +1,315 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/commonlog"
+
+	"github.com/nevalang/neva/internal/builder"
+	"github.com/nevalang/neva/internal/compiler/analyzer"
+	"github.com/nevalang/neva/internal/compiler/parser"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+	"github.com/nevalang/neva/pkg"
+)
+
+const (
+	mainFile = `import { @:greet }
+
+def Main(start any) (stop any) {
+	hello greet.Hello
+	---
+	:start -> hello -> :stop
+}
+`
+	greetFile = `pub def Hello(data any) (res any) {
+	:data -> :res
+}
+`
+)
+
+var entryModRef = core.ModuleRef{Path: "@"}
+
+// scan returns indexer and index of the module where main package uses entity of greet package.
+func scan(t *testing.T) (Indexer, Index, string) {
+	t.Helper()
+
+	// builder writes stdlib to the home directory
+	t.Setenv("HOME", t.TempDir())
+
+	modPath := t.TempDir()
+	files := map[string]string{
+		"neva.yml":         "neva: " + pkg.Version,
+		"main/main.neva":   mainFile,
+		"greet/greet.neva": greetFile,
+	}
+	for name, content := range files {
+		path := filepath.Join(modPath, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	p := parser.New()
+	terminator := ts.Terminator{}
+	checker := ts.MustNewSubtypeChecker(terminator)
+	resolver := ts.MustNewResolver(ts.Validator{}, checker, terminator)
+	idx := New(builder.MustNew(p), p, analyzer.MustNew(resolver), commonlog.GetLogger("test"))
+
+	index, found, errs := idx.FullScan(context.Background(), modPath)
+	require.True(t, found)
+	require.False(t, errs.HasErrors(), errs.Error())
+	require.NotNil(t, index.Analyzed)
+
+	return idx, index, modPath
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("dependants_are_reanalyzed", func(t *testing.T) {
+		idx, index, modPath := scan(t)
+
+		updated, errs := idx.Update(index, map[string][]byte{
+			filepath.Join(modPath, "greet", "greet.neva"): []byte(`pub def Greet(data any) (res any) {
+	:data -> :res
+}
+`),
+		})
+
+		require.True(t, errs.HasErrors())
+		require.Contains(t, errs.Error(), "main/main.neva")
+		require.Nil(t, updated.Analyzed)
+
+		// changes are reverted
+		updated, errs = idx.Update(updated, map[string][]byte{
+			filepath.Join(modPath, "greet", "greet.neva"): []byte(greetFile),
+		})
+		require.False(t, errs.HasErrors(), errs.Error())
+		require.NotNil(t, updated.Analyzed)
+	})
+
+	t.Run("syntax_error_keeps_last_good_parse", func(t *testing.T) {
+		idx, index, modPath := scan(t)
+
+		updated, errs := idx.Update(index, map[string][]byte{
+			filepath.Join(modPath, "greet", "greet.neva"): []byte(`pub def Hello(`),
+		})
+
+		require.Len(t, errs, 1)
+		require.Nil(t, updated.Analyzed)
+		greet := updated.Parsed.Modules[entryModRef].Packages["greet"]
+		require.Equal(t, index.Parsed.Modules[entryModRef].Packages["greet"], greet)
+		_, ok := greet["greet"].Entities["Hello"]
+		require.True(t, ok)
+
+		// the error is gone when file is fixed
+		updated, errs = idx.Update(updated, map[string][]byte{
+			filepath.Join(modPath, "greet", "greet.neva"): []byte(greetFile),
+		})
+		require.Empty(t, errs)
+		require.NotNil(t, updated.Analyzed)
+	})
+
+	t.Run("new_file_in_existing_package", func(t *testing.T) {
+		idx, index, modPath := scan(t)
+
+		updated, errs := idx.Update(index, map[string][]byte{
+			filepath.Join(modPath, "greet", "bye.neva"): []byte(`pub def Bye(data any) (res any) {
+	:data -> :res
+}
+`),
+			filepath.Join(modPath, "main", "main.neva"): []byte(`import { @:greet }
+
+def Main(start any) (stop any) {
+	bye greet.Bye
+	---
+	:start -> bye -> :stop
+}
+`),
+		})
+
+		require.Empty(t, errs)
+		require.NotNil(t, updated.Analyzed)
+		greet := updated.Parsed.Modules[entryModRef].Packages["greet"]
+		require.Len(t, greet, 2)
+		_, ok := greet["bye"].Entities["Bye"]
+		require.True(t, ok)
+	})
+
+	t.Run("previous_index_is_not_mutated", func(t *testing.T) {
+		idx, index, modPath := scan(t)
+
+		parsedGreet := index.Parsed.Modules[entryModRef].Packages["greet"]
+		analyzed := index.Analyzed
+		packages := len(index.packages)
+
+		_, errs := idx.Update(index, map[string][]byte{
+			filepath.Join(modPath, "greet", "bye.neva"): []byte(`pub def Bye(`),
+			filepath.Join(modPath, "greet", "greet.neva"): []byte(`pub def Greet(data any) (res any) {
+	:data -> :res
+}
+`),
+		})
+		require.True(t, errs.HasErrors())
+
+		require.Len(t, parsedGreet, 1)
+		_, ok := parsedGreet["greet"].Entities["Hello"]
+		require.True(t, ok)
+		require.Equal(t, parsedGreet, index.Parsed.Modules[entryModRef].Packages["greet"])
+		require.Same(t, analyzed, index.Analyzed)
+		require.Empty(t, index.parseErrs)
+		require.Len(t, index.packages, packages)
+		require.Empty(t, index.packages[packageRef{entryModRef, "main"}].errs)
+	})
+
+	t.Run("file_outside_of_modules_is_ignored", func(t *testing.T) {
+		idx, index, _ := scan(t)
+
+		updated, errs := idx.Update(index, map[string][]byte{
+			filepath.Join(t.TempDir(), "foo", "foo.neva"): []byte(`pub def Foo(`),
+		})
+		require.Empty(t, errs)
+		require.Equal(t, index.Parsed, updated.Parsed)
+	})
+}
+
+func TestAffectedPackages(t *testing.T) {
+	stdModRef := core.ModuleRef{Path: "std", Version: pkg.Version}
+	depModRef := core.ModuleRef{Path: "github.com/nevalang/x", Version: "0.0.1"}
+
+	imports := func(imports ...src.Import) src.Package {
+		file := src.File{Imports: map[string]src.Import{}}
+		for _, imp := range imports {
+			file.Imports[imp.Package] = imp
+		}
+		return src.Package{"file": file}
+	}
+
+	build := src.Build{
+		EntryModRef: entryModRef,
+		Modules: map[core.ModuleRef]src.Module{
+			entryModRef: {
+				Manifest: src.ModuleManifest{Deps: map[string]core.ModuleRef{"std": stdModRef, "x": depModRef}},
+				Packages: map[string]src.Package{
+					"main":  imports(src.Import{Module: "@", Package: "greet"}),
+					"greet": imports(src.Import{Module: "x", Package: "strings"}),
+					"other": imports(src.Import{Module: "std", Package: "fmt"}),
+				},
+			},
+			depModRef: {
+				Manifest: src.ModuleManifest{Deps: map[string]core.ModuleRef{"std": stdModRef}},
+				Packages: map[string]src.Package{
+					"strings": imports(),
+				},
+			},
+			stdModRef: {
+				Packages: map[string]src.Package{
+					"builtin": imports(),
+					"fmt":     imports(),
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		changed  []packageRef
+		expected []packageRef
+	}{
+		{
+			name:     "package_without_dependants",
+			changed:  []packageRef{{entryModRef, "main"}},
+			expected: []packageRef{{entryModRef, "main"}},
+		},
+		{
+			name:     "dependants_of_the_same_module",
+			changed:  []packageRef{{entryModRef, "greet"}},
+			expected: []packageRef{{entryModRef, "greet"}, {entryModRef, "main"}},
+		},
+		{
+			name:    "transitive_dependants_of_other_module",
+			changed: []packageRef{{depModRef, "strings"}},
+			expected: []packageRef{
+				{depModRef, "strings"},
+				{entryModRef, "greet"},
+				{entryModRef, "main"},
+			},
+		},
+		{
+			name:    "builtin",
+			changed: []packageRef{{stdModRef, "builtin"}},
+			expected: []packageRef{
+				{stdModRef, "builtin"},
+				{stdModRef, "fmt"},
+				{depModRef, "strings"},
+				{entryModRef, "main"},
+				{entryModRef, "greet"},
+				{entryModRef, "other"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := map[packageRef]struct{}{}
+			for _, ref := range tt.changed {
+				changed[ref] = struct{}{}
+			}
+			expected := map[packageRef]struct{}{}
+			for _, ref := range tt.expected {
+				expected[ref] = struct{}{}
+			}
+			require.Equal(t, expected, affectedPackages(build, changed))
+		})
+	}
+}
+
+func TestIndexLocation(t *testing.T) {
+	depModRef := core.ModuleRef{Path: "github.com/nevalang/x", Version: "0.0.1"}
+	index := Index{
+		ModulePaths: map[core.ModuleRef]string{
+			entryModRef: filepath.FromSlash("/ws"),
+			depModRef:   filepath.FromSlash("/ws/deps/x"),
+		},
+	}
+
+	tests := []struct {
+		path     string
+		expected core.Location
+		ok       bool
+	}{
+		{
+			path:     "/ws/main/main.neva",
+			expected: core.Location{ModRef: entryModRef, Package: "main", Filename: "main"},
+			ok:       true,
+		},
+		{
+			path:     "/ws/foo/bar/baz.neva",
+			expected: core.Location{ModRef: entryModRef, Package: "foo/bar", Filename: "baz"},
+			ok:       true,
+		},
+		{
+			path:     "/ws/deps/x/strings/strings.neva",
+			expected: core.Location{ModRef: depModRef, Package: "strings", Filename: "strings"},
+			ok:       true,
+		},
+		{
+			path: "/other/main/main.neva",
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			// map of modules is iterated in random order
+			for range 10 {
+				loc, ok := index.Location(filepath.FromSlash(tt.path))
+				require.Equal(t, tt.ok, ok)
+				require.Equal(t, tt.expected, loc)
+			}
+		})
+	}
+}
@@ -38,7 +38,7 @@ func main() {
 
 	indexer := indexer.New(builder, p, analyzer.MustNew(resolver), logger)
 
-	handler := lspServer.BuildHandler(logger, serverName, indexer)
+	handler := lspServer.BuildHandler(logger, serverName, indexer, resolver)
 
 	srv := server.NewServer(
 		handler,
@@ -0,0 +1,741 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/nevalang/neva/internal/compiler"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+// TextDocumentCodeAction returns quick fixes for the analyzer errors reported in the document.
+func (s *Server) TextDocumentCodeAction(
+	glspCtx *glsp.Context,
+	params *protocol.CodeActionParams,
+) (any, error) {
+	actions := []protocol.CodeAction{}
+
+	index := s.currentIndex()
+	if index == nil {
+		return actions, nil
+	}
+
+	loc, ok := fileLocation(*index, uriToPath(params.TextDocument.URI))
+	if !ok {
+		return actions, nil
+	}
+
+	lines, ok := s.sourceCache(*index).fileLines(loc)
+	if !ok {
+		return actions, nil
+	}
+
+	f := fixer{
+		build: index.Parsed,
+		scope: src.NewScope(index.Parsed, loc),
+		loc:   loc,
+		file:  index.Parsed.Modules[loc.ModRef].Packages[loc.Package][loc.Filename],
+		lines: lines,
+	}
+
+	kind := protocol.CodeActionKindQuickFix
+	for _, diagnostic := range params.Context.Diagnostics {
+		fixes := f.quickFixes(diagnostic)
+		for _, fix := range fixes {
+			action := protocol.CodeAction{
+				Title:       fix.title,
+				Kind:        &kind,
+				Diagnostics: []protocol.Diagnostic{diagnostic},
+				Edit: &protocol.WorkspaceEdit{
+					Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+						params.TextDocument.URI: fix.edits,
+					},
+				},
+			}
+			if len(fixes) == 1 {
+				preferred := true
+				action.IsPreferred = &preferred
+			}
+			actions = append(actions, action)
+		}
+	}
+
+	return actions, nil
+}
+
+type quickFix struct {
+	title string
+	edits []protocol.TextEdit
+}
+
+// fixer creates quick fixes for the file at given location.
+// Positions of the source code abstractions are used to find the place of the problem
+// and the text of the file is used to make edits that look like they were written by hand.
+type fixer struct {
+	build src.Build
+	scope src.Scope
+	loc   core.Location
+	file  src.File
+	lines []string
+}
+
+func (f fixer) quickFixes(diagnostic protocol.Diagnostic) []quickFix {
+	pos := core.Position{
+		Line:   int(diagnostic.Range.Start.Line) + 1,
+		Column: int(diagnostic.Range.Start.Character),
+	}
+
+	comp, ok := f.componentAt(pos)
+	if !ok {
+		return nil
+	}
+
+	// all the problems that have quick fixes are reported at the definition of the node
+	name, node, ok := nodeAt(comp, pos)
+	if !ok {
+		return nil
+	}
+
+	switch diagnosticCode(diagnostic) {
+	case compiler.ErrorCodeImportNotFound:
+		return f.addImport(node.EntityRef.Pkg)
+	case compiler.ErrorCodeEntityNotFound:
+		if node.EntityRef.Pkg != "" {
+			return nil
+		}
+		return f.createComponent(comp, name, node.EntityRef.Name)
+	case compiler.ErrorCodeUnusedNode:
+		return f.removeNode(comp, name)
+	case compiler.ErrorCodeUnusedOutports:
+		iface, ok := f.nodeInterface(comp, name)
+		if !ok {
+			return nil
+		}
+		ports := make([]string, 0, len(iface.IO.Out))
+		for port := range iface.IO.Out {
+			ports = append(ports, port)
+		}
+		sort.Strings(ports)
+		return f.connectToDel(comp, name, ports)
+	case compiler.ErrorCodeUnhandledError:
+		return f.connectToDel(comp, name, []string{"err"})
+	case compiler.ErrorCodeTypeArgsCount:
+		return f.addTypeArgs(comp, name, len(node.TypeArgs))
+	}
+
+	return nil
+}
+
+func diagnosticCode(diagnostic protocol.Diagnostic) compiler.ErrorCode {
+	if diagnostic.Code == nil {
+		return ""
+	}
+	code, _ := diagnostic.Code.Value.(string)
+	return compiler.ErrorCode(code)
+}
+
+// decodeCodeActionParams decodes params of the code action request.
+// Codes of the diagnostics are decoded separately because protocol package loses them.
+func decodeCodeActionParams(data []byte) (protocol.CodeActionParams, error) {
+	var params protocol.CodeActionParams
+	if err := json.Unmarshal(data, &params); err != nil {
+		return protocol.CodeActionParams{}, err
+	}
+
+	var codes struct {
+		Context struct {
+			Diagnostics []struct {
+				Code any `json:"code"`
+			} `json:"diagnostics"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(data, &codes); err != nil {
+		return protocol.CodeActionParams{}, err
+	}
+
+	for i, diagnostic := range codes.Context.Diagnostics {
+		if diagnostic.Code != nil && i < len(params.Context.Diagnostics) {
+			params.Context.Diagnostics[i].Code = &protocol.IntegerOrString{Value: diagnostic.Code}
+		}
+	}
+
+	return params, nil
+}
+
+// addImport suggests packages that can be imported under the given name.
+// If there are references like `name.Entity` in the file, only packages that export all of them are suggested.
+func (f fixer) addImport(name string) []quickFix {
+	entities := map[string]struct{}{}
+	for _, entity := range f.file.Entities {
+		if entity.Kind != src.ComponentEntity {
+			continue
+		}
+		for _, node := range entity.Component.Nodes {
+			if node.EntityRef.Pkg == name {
+				entities[node.EntityRef.Name] = struct{}{}
+			}
+		}
+	}
+
+	var paths []string
+	for modRef, mod := range f.build.Modules {
+		var prefix string
+		switch {
+		case modRef == f.loc.ModRef:
+			prefix = "@:"
+		case modRef.Path == "std":
+			prefix = ""
+		default:
+			alias, ok := f.depAlias(modRef)
+			if !ok {
+				continue
+			}
+			prefix = alias + ":"
+		}
+
+		for pkgName, pkg := range mod.Packages {
+			if modRef == f.loc.ModRef && pkgName == f.loc.Package {
+				continue
+			}
+			parts := strings.Split(pkgName, "/")
+			if parts[len(parts)-1] != name || !exportsAll(pkg, entities) {
+				continue
+			}
+			paths = append(paths, prefix+pkgName)
+		}
+	}
+	sort.Strings(paths)
+
+	fixes := make([]quickFix, 0, len(paths))
+	for _, path := range paths {
+		fixes = append(fixes, quickFix{
+			title: "Add import " + path,
+			edits: []protocol.TextEdit{f.importEdit(path)},
+		})
+	}
+
+	return fixes
+}
+
+// depAlias returns name under which the module is a dependency of the current module.
+func (f fixer) depAlias(modRef core.ModuleRef) (string, bool) {
+	for alias, dep := range f.build.Modules[f.loc.ModRef].Manifest.Deps {
+		if dep == modRef {
+			return alias, true
+		}
+	}
+	return "", false
+}
+
+func exportsAll(pkg src.Package, entities map[string]struct{}) bool {
+	for name := range entities {
+		entity, _, ok := pkg.Entity(name)
+		if !ok || !entity.IsPublic {
+			return false
+		}
+	}
+	return true
+}
+
+// importEdit adds path to the first import block of the file or creates one at the top of the file.
+func (f fixer) importEdit(path string) protocol.TextEdit {
+	for i, line := range f.lines {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "import")
+		if rest = strings.TrimSpace(rest); !ok || rest != "" && !strings.HasPrefix(rest, "{") {
+			continue
+		}
+
+		for j := i; j < len(f.lines); j++ {
+			closing := strings.IndexByte(f.lines[j], '}')
+			if closing == -1 {
+				continue
+			}
+
+			before := strings.TrimRight(f.lines[j][:closing], " \t")
+			switch {
+			case strings.HasSuffix(before, "{"):
+				// `import {}`
+				return insertEdit(j, len(before), closing, " "+path+" ")
+			case strings.TrimSpace(before) != "":
+				// `import { fmt }`
+				return insertEdit(j, len(before), len(before), ", "+path)
+			}
+
+			// path is written on its own line before the closing bracket
+			indent := "\t"
+			if j-1 > i {
+				indent = leadingWhitespace(f.lines[j-1])
+			}
+			return insertEdit(j, 0, 0, indent+path+"\n")
+		}
+	}
+
+	return insertEdit(0, 0, 0, "import { "+path+" }\n\n")
+}
+
+// removeNode removes definition of the node, and the `---` separator if it was the only node.
+func (f fixer) removeNode(comp src.Component, name string) []quickFix {
+	node, ok := comp.Nodes[name]
+	if !ok || node.Meta.Start.Line < 1 || node.Meta.Stop.Line > len(f.lines) {
+		return nil
+	}
+
+	startLine, stopLine := node.Meta.Start.Line-1, node.Meta.Stop.Line-1
+	prefix := f.lines[startLine][:node.Meta.Start.Column]
+	end := tokenEnd(f.lines[stopLine], node.Meta.Stop.Column)
+	suffix := f.lines[stopLine][end:]
+
+	var edit protocol.TextEdit
+	switch {
+	case strings.TrimSpace(prefix) == "" && strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(suffix), ",")) == "":
+		// node is the only thing on its lines
+		stop := stopLine + 1
+		if len(comp.Nodes) == 1 {
+			for i := stop; i < len(f.lines); i++ {
+				if strings.TrimSpace(f.lines[i]) == "---" {
+					stop = i + 1
+					break
+				}
+			}
+		}
+		edit = protocol.TextEdit{Range: lineRange(startLine, 0, stop, 0)}
+	case strings.HasPrefix(strings.TrimSpace(suffix), ","):
+		// `node Node, other Other`
+		edit = protocol.TextEdit{Range: lineRange(startLine, node.Meta.Start.Column, stopLine, end+commaEnd(suffix))}
+	case strings.HasSuffix(strings.TrimRight(prefix, " \t"), ","):
+		// `other Other, node Node`
+		comma := strings.LastIndexByte(prefix, ',')
+		edit = protocol.TextEdit{Range: lineRange(startLine, comma, stopLine, end)}
+	default:
+		return nil
+	}
+
+	return []quickFix{{
+		title: "Remove unused node " + name,
+		edits: []protocol.TextEdit{edit},
+	}}
+}
+
+// commaEnd returns length of the leading comma and whitespace around it.
+func commaEnd(s string) int {
+	trimmed := strings.TrimLeft(s, " \t")
+	trimmed = strings.TrimPrefix(trimmed, ",")
+	trimmed = strings.TrimLeft(trimmed, " \t")
+	return len(s) - len(trimmed)
+}
+
+// connectToDel suggests to send messages from each of the node's outports to `Del` that discards them.
+// Node with `Del` component is added if there's no such node yet.
+func (f fixer) connectToDel(comp src.Component, name string, ports []string) []quickFix {
+	closing, ok := f.closingBracketLine(comp)
+	if !ok {
+		return nil
+	}
+
+	var nodeEdit *protocol.TextEdit
+
+	del, ok := delNode(comp)
+	if !ok {
+		del = uniqueName("del", func(name string) bool {
+			_, ok := comp.Nodes[name]
+			return ok
+		})
+		edit, ok := f.addNodeEdit(comp, del+" Del")
+		if !ok {
+			return nil
+		}
+		nodeEdit = &edit
+	}
+
+	indent := "\t"
+	if len(comp.Net) > 0 {
+		last := comp.Net[len(comp.Net)-1].Meta.Start.Line
+		if last >= 1 && last <= len(f.lines) {
+			indent = leadingWhitespace(f.lines[last-1])
+		}
+	}
+
+	fixes := make([]quickFix, 0, len(ports))
+	for _, port := range ports {
+		edits := []protocol.TextEdit{}
+		if nodeEdit != nil {
+			edits = append(edits, *nodeEdit)
+		}
+		edits = append(edits, insertEdit(closing, 0, 0, fmt.Sprintf("%v%v:%v -> %v\n", indent, name, port, del)))
+
+		fixes = append(fixes, quickFix{
+			title: fmt.Sprintf("Connect %v:%v to Del", name, port),
+			edits: edits,
+		})
+	}
+
+	return fixes
+}
+
+// delNode returns name of the node that is instantiated with `Del` component.
+func delNode(comp src.Component) (string, bool) {
+	names := make([]string, 0, len(comp.Nodes))
+	for name, node := range comp.Nodes {
+		if node.EntityRef.Name == "Del" && (node.EntityRef.Pkg == "" || node.EntityRef.Pkg == "builtin") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	sort.Strings(names)
+	return names[0], true
+}
+
+// addNodeEdit adds node definition after the last node of the component.
+// If component has no nodes, they are added before the network.
+func (f fixer) addNodeEdit(comp src.Component, def string) (protocol.TextEdit, bool) {
+	var last *src.Node
+	for _, node := range comp.Nodes {
+		if last == nil || node.Meta.Stop.Line > last.Meta.Stop.Line {
+			last = &node
+		}
+	}
+
+	if last != nil {
+		if last.Meta.Start.Line < 1 || last.Meta.Stop.Line > len(f.lines) {
+			return protocol.TextEdit{}, false
+		}
+		indent := leadingWhitespace(f.lines[last.Meta.Start.Line-1])
+		return insertEdit(last.Meta.Stop.Line, 0, 0, indent+def+"\n"), true
+	}
+
+	if len(comp.Net) == 0 {
+		return protocol.TextEdit{}, false
+	}
+	first := comp.Net[0].Meta.Start.Line
+	if first < 1 || first > len(f.lines) {
+		return protocol.TextEdit{}, false
+	}
+	indent := leadingWhitespace(f.lines[first-1])
+
+	return insertEdit(first-1, 0, 0, indent+def+"\n"+indent+"---\n"), true
+}
+
+// addTypeArgs adds constraints of the missing type parameters as type arguments of the node.
+func (f fixer) addTypeArgs(comp src.Component, name string, got int) []quickFix {
+	iface, ok := f.nodeInterface(comp, name)
+	if !ok || got >= len(iface.TypeParams.Params) {
+		return nil
+	}
+
+	args := make([]string, 0, len(iface.TypeParams.Params)-got)
+	for _, param := range iface.TypeParams.Params[got:] {
+		if param.Constr.Inst == nil && param.Constr.Lit == nil {
+			args = append(args, "any")
+			continue
+		}
+		args = append(args, formatTypeExpr(param.Constr, ""))
+	}
+
+	node := comp.Nodes[name]
+	line, col, ok := f.identEnd(node.Meta, node.EntityRef.Name)
+	if !ok {
+		return nil
+	}
+
+	var edit protocol.TextEdit
+	if got == 0 {
+		edit = insertEdit(line, col, col, "<"+strings.Join(args, ", ")+">")
+	} else {
+		closeLine, closeCol, ok := f.closingAngleBracket(line, col)
+		if !ok {
+			return nil
+		}
+		edit = insertEdit(closeLine, closeCol, closeCol, ", "+strings.Join(args, ", "))
+	}
+
+	title := "Add missing type argument"
+	if len(args) > 1 {
+		title += "s"
+	}
+
+	return []quickFix{{
+		title: title,
+		edits: []protocol.TextEdit{edit},
+	}}
+}
+
+// identEnd returns 0-based position right after the identifier written between start and stop of the meta.
+func (f fixer) identEnd(meta core.Meta, ident string) (int, int, bool) {
+	for line := meta.Start.Line; line >= 1 && line <= meta.Stop.Line && line <= len(f.lines); line++ {
+		from := 0
+		if line == meta.Start.Line {
+			from = meta.Start.Column
+		}
+		if col, ok := indexIdent(f.lines[line-1], ident, from); ok {
+			return line - 1, col + len(ident), true
+		}
+	}
+	return 0, 0, false
+}
+
+// closingAngleBracket returns position of `>` that closes type arguments starting at given position.
+func (f fixer) closingAngleBracket(line, col int) (int, int, bool) {
+	depth := 0
+	for ; line < len(f.lines); line++ {
+		for ; col < len(f.lines[line]); col++ {
+			switch f.lines[line][col] {
+			case '<':
+				depth++
+			case '>':
+				depth--
+				if depth == 0 {
+					return line, col, true
+				}
+			}
+		}
+		col = 0
+	}
+	return 0, 0, false
+}
+
+// createComponent adds component with ports that the node uses in the network to the end of the file.
+func (f fixer) createComponent(comp src.Component, nodeName, name string) []quickFix {
+	if _, _, ok := f.build.Modules[f.loc.ModRef].Packages[f.loc.Package].Entity(name); ok {
+		return nil
+	}
+
+	inports, outports := nodePorts(comp, nodeName)
+	if len(inports) == 0 {
+		inports = []string{"data any"}
+	}
+	if len(outports) == 0 {
+		outports = []string{"res any"}
+	}
+
+	def := fmt.Sprintf(
+		"def %v(%v) (%v) {\n\t// TODO: implement\n}\n",
+		name,
+		strings.Join(inports, ", "),
+		strings.Join(outports, ", "),
+	)
+
+	last := len(f.lines) - 1
+	var edit protocol.TextEdit
+	if f.lines[last] == "" {
+		edit = insertEdit(last, 0, 0, "\n"+def)
+	} else {
+		edit = insertEdit(last, len(f.lines[last]), len(f.lines[last]), "\n\n"+strings.TrimSuffix(def, "\n"))
+	}
+
+	return []quickFix{{
+		title: "Create component " + name,
+		edits: []protocol.TextEdit{edit},
+	}}
+}
+
+// nodePorts returns definitions of the ports that are used by the node in the network, in the order of usage.
+// Ports used without name are named `data` and `res`.
+func nodePorts(comp src.Component, nodeName string) ([]string, []string) {
+	var (
+		inports, outports []string
+		seen              = map[string]struct{}{}
+	)
+
+	add := func(addr *src.PortAddr, isSender bool) {
+		if addr == nil || addr.Node != nodeName {
+			return
+		}
+		port := addr.Port
+		switch {
+		case port != "":
+		case isSender:
+			port = "res"
+		default:
+			port = "data"
+		}
+
+		def := port + " any"
+		if addr.Idx != nil {
+			def = "[" + port + "] any"
+		}
+
+		key := fmt.Sprint(isSender, port)
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+
+		if isSender {
+			outports = append(outports, def)
+		} else {
+			inports = append(inports, def)
+		}
+	}
+
+	var (
+		addConnection func(conn src.Connection)
+		addNormal     func(conn src.NormalConnection, isChained bool)
+		addSender     func(sender src.ConnectionSender)
+		addReceivers  func(receivers []src.ConnectionReceiver)
+	)
+	addConnection = func(conn src.Connection) {
+		if conn.ArrayBypass != nil {
+			add(&conn.ArrayBypass.SenderOutport, true)
+			add(&conn.ArrayBypass.ReceiverInport, false)
+		}
+		if conn.Normal != nil {
+			addNormal(*conn.Normal, false)
+		}
+	}
+	addNormal = func(conn src.NormalConnection, isChained bool) {
+		for _, sender := range conn.Senders {
+			// senders of chained connection are receivers of the connection it's chained to
+			if isChained && sender.PortAddr != nil {
+				add(sender.PortAddr, false)
+				continue
+			}
+			addSender(sender)
+		}
+		addReceivers(conn.Receivers)
+	}
+	addSender = func(sender src.ConnectionSender) {
+		switch {
+		case sender.PortAddr != nil:
+			add(sender.PortAddr, true)
+		case sender.Unary != nil:
+			addSender(sender.Unary.Operand)
+		case sender.Binary != nil:
+			addSender(sender.Binary.Left)
+			addSender(sender.Binary.Right)
+		case sender.Ternary != nil:
+			addSender(sender.Ternary.Condition)
+			addSender(sender.Ternary.Left)
+			addSender(sender.Ternary.Right)
+		}
+	}
+	addReceivers = func(receivers []src.ConnectionReceiver) {
+		for _, receiver := range receivers {
+			switch {
+			case receiver.PortAddr != nil:
+				add(receiver.PortAddr, false)
+			case receiver.DeferredConnection != nil:
+				addConnection(*receiver.DeferredConnection)
+			case receiver.ChainedConnection != nil && receiver.ChainedConnection.Normal != nil:
+				addNormal(*receiver.ChainedConnection.Normal, true)
+			case receiver.Switch != nil:
+				for _, switchCase := range receiver.Switch.Cases {
+					addNormal(switchCase, false)
+				}
+				addReceivers(receiver.Switch.Default)
+			}
+		}
+	}
+
+	for _, conn := range comp.Net {
+		addConnection(conn)
+	}
+
+	return inports, outports
+}
+
+// componentAt returns component which definition contains given position.
+func (f fixer) componentAt(pos core.Position) (src.Component, bool) {
+	for _, entity := range f.file.Entities {
+		if entity.Kind != src.ComponentEntity {
+			continue
+		}
+		meta := entity.Component.Meta
+		if meta.Start.Line <= pos.Line && pos.Line <= meta.Stop.Line {
+			return entity.Component, true
+		}
+	}
+	return src.Component{}, false
+}
+
+// nodeAt returns node which definition starts at given position.
+func nodeAt(comp src.Component, pos core.Position) (string, src.Node, bool) {
+	for name, node := range comp.Nodes {
+		if node.Meta.Start == pos {
+			return name, node, true
+		}
+	}
+	return "", src.Node{}, false
+}
+
+// nodeInterface returns interface of the component or interface that node is instantiated with.
+func (f fixer) nodeInterface(comp src.Component, name string) (src.Interface, bool) {
+	node, ok := comp.Nodes[name]
+	if !ok {
+		return src.Interface{}, false
+	}
+	entity, _, err := f.scope.Entity(node.EntityRef)
+	if err != nil {
+		return src.Interface{}, false
+	}
+	switch entity.Kind {
+	case src.ComponentEntity:
+		return entity.Component.Interface, true
+	case src.InterfaceEntity:
+		return entity.Interface, true
+	}
+	return src.Interface{}, false
+}
+
+// closingBracketLine returns 0-based line of the `}` that closes body of the component.
+func (f fixer) closingBracketLine(comp src.Component) (int, bool) {
+	for i := min(comp.Meta.Stop.Line, len(f.lines)) - 1; i >= comp.Meta.Start.Line; i-- {
+		if strings.TrimSpace(f.lines[i]) == "}" {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// tokenEnd returns index right after the token that starts at given index.
+func tokenEnd(line string, start int) int {
+	if start >= len(line) {
+		return len(line)
+	}
+	if !isIdentChar(line[start]) {
+		return start + 1
+	}
+	end := start
+	for end < len(line) && isIdentChar(line[end]) {
+		end++
+	}
+	return end
+}
+
+func uniqueName(name string, taken func(string) bool) string {
+	if !taken(name) {
+		return name
+	}
+	for i := 2; ; i++ {
+		if candidate := name + strconv.Itoa(i); !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// insertEdit replaces text between given columns of the 0-based line.
+func insertEdit(line, start, end int, text string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range:   lineRange(line, start, line, end),
+		NewText: text,
+	}
+}
+
+func lineRange(startLine, startCol, endLine, endCol int) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: uint32(startLine), Character: uint32(startCol)},
+		End:   protocol.Position{Line: uint32(endLine), Character: uint32(endCol)},
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/nevalang/neva/internal/compiler"
+)
+
+func TestTextDocumentCodeAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     compiler.ErrorCode
+		source   string
+		title    string
+		expected string
+	}{
+		{
+			name: "add_import",
+			code: compiler.ErrorCodeImportNotFound,
+			source: `def Main(start any) (stop any) {
+	print fmt.Print
+	---
+	:start -> print -> :stop
+}
+`,
+			title: "Add import fmt",
+			expected: `import { fmt }
+
+def Main(start any) (stop any) {
+	print fmt.Print
+	---
+	:start -> print -> :stop
+}
+`,
+		},
+		{
+			name: "create_component",
+			code: compiler.ErrorCodeEntityNotFound,
+			source: `def Main(start any) (stop any) {
+	foo Foo
+	---
+	:start -> foo -> :stop
+}
+`,
+			title: "Create component Foo",
+			expected: `def Main(start any) (stop any) {
+	foo Foo
+	---
+	:start -> foo -> :stop
+}
+
+def Foo(data any) (res any) {
+	// TODO: implement
+}
+`,
+		},
+		{
+			name: "remove_node",
+			code: compiler.ErrorCodeUnusedNode,
+			source: `def Main(start any) (stop any) {
+	del Del
+	---
+	:start -> :stop
+}
+`,
+			title: "Remove unused node del",
+			expected: `def Main(start any) (stop any) {
+	:start -> :stop
+}
+`,
+		},
+		{
+			name: "connect_unused_outport_to_del",
+			code: compiler.ErrorCodeUnusedOutports,
+			source: `import { fmt }
+
+def Main(start any) (stop any) {
+	print fmt.Print
+	---
+	:start -> [print, :stop]
+}
+`,
+			title: "Connect print:res to Del",
+			expected: `import { fmt }
+
+def Main(start any) (stop any) {
+	print fmt.Print
+	del Del
+	---
+	:start -> [print, :stop]
+	print:res -> del
+}
+`,
+		},
+		{
+			name: "connect_unhandled_error_to_del",
+			code: compiler.ErrorCodeUnhandledError,
+			source: `import { fmt }
+
+def Main(start any) (stop any) {
+	println fmt.Println
+	---
+	:start -> println
+	println:res -> :stop
+}
+`,
+			title: "Connect println:err to Del",
+			expected: `import { fmt }
+
+def Main(start any) (stop any) {
+	println fmt.Println
+	del Del
+	---
+	:start -> println
+	println:res -> :stop
+	println:err -> del
+}
+`,
+		},
+		{
+			name: "add_type_args",
+			code: compiler.ErrorCodeTypeArgsCount,
+			source: `def Main(start any) (stop any) {
+	pass Pass<any>
+	---
+	:start -> pass -> :stop
+}
+
+def Pass<T, R int>(data T) (res T) {
+	:data -> :res
+}
+`,
+			title: "Add missing type argument",
+			expected: `def Main(start any) (stop any) {
+	pass Pass<any, int>
+	---
+	:start -> pass -> :stop
+}
+
+def Pass<T, R int>(data T) (res T) {
+	:data -> :res
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, modPath, errs := newTestServer(t, map[string]string{
+				"main/main.neva": tt.source,
+			})
+			uri := pathToURI(filepath.Join(modPath, "main", "main.neva"))
+
+			var diagnostics []protocol.Diagnostic
+			for _, err := range errs {
+				diagnostic := s.createDiagnostic(*err.Unwrap())
+				if diagnosticCode(diagnostic) == tt.code {
+					diagnostics = append(diagnostics, diagnostic)
+				}
+			}
+			require.Len(t, diagnostics, 1, errs.Error())
+
+			resp, err := s.TextDocumentCodeAction(nil, &protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Context:      protocol.CodeActionContext{Diagnostics: diagnostics},
+			})
+			require.NoError(t, err)
+
+			actions := resp.([]protocol.CodeAction)
+			require.Len(t, actions, 1)
+			require.Equal(t, tt.title, actions[0].Title)
+			require.Equal(t, tt.expected, applyEdits(tt.source, actions[0].Edit.Changes[uri]))
+		})
+	}
+}
+
+func TestDecodeCodeActionParams(t *testing.T) {
+	diagnostic := protocol.Diagnostic{
+		Message: "Unused node found: del",
+		Code:    &protocol.IntegerOrString{Value: string(compiler.ErrorCodeUnusedNode)},
+	}
+	data, err := json.Marshal(protocol.CodeActionParams{
+		Context: protocol.CodeActionContext{Diagnostics: []protocol.Diagnostic{diagnostic, {}}},
+	})
+	require.NoError(t, err)
+
+	params, err := decodeCodeActionParams(data)
+	require.NoError(t, err)
+	require.Len(t, params.Context.Diagnostics, 2)
+	require.Equal(t, compiler.ErrorCodeUnusedNode, diagnosticCode(params.Context.Diagnostics[0]))
+	require.Nil(t, params.Context.Diagnostics[1].Code)
+}
@@ -0,0 +1,416 @@
+package server
+
+import (
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/nevalang/neva/internal/compiler"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+)
+
+// completionContext describes where completion was requested.
+// It's computed from the text of the document because the index doesn't know about unsaved changes.
+type completionContext struct {
+	inImports  bool
+	component  string // name of the component which body contains the cursor
+	inNetwork  bool   // cursor is after `---` or inside component without nodes section
+	inTypeArgs bool   // cursor is inside angle brackets of the line, e.g. `List<Poi`
+	prefix     string // what's written right before the cursor, e.g. `fmt.Pr`, `println:` or `$`
+}
+
+// getCompletionContext scans the text before the cursor, skipping comments and strings,
+// to find out in which block the cursor is.
+func getCompletionContext(text string, offset int) completionContext {
+	var (
+		ctx         completionContext
+		depth       int // curly braces
+		parenDepth  int
+		keyword     string // last top-level keyword
+		defName     string
+		expectsName bool
+	)
+
+	before := text[:offset]
+	for i := 0; i < len(before); i++ {
+		c := before[i]
+		switch {
+		case c == '/' && i+1 < len(before) && before[i+1] == '/':
+			for i < len(before) && before[i] != '\n' {
+				i++
+			}
+		case c == '\'' || c == '"':
+			i = skipString(before, i)
+		case c == '(':
+			parenDepth++
+		case c == ')':
+			parenDepth--
+		case c == '{' && parenDepth == 0:
+			if depth == 0 {
+				switch keyword {
+				case "import":
+					ctx.inImports = true
+				case "def":
+					ctx.component = defName
+					ctx.inNetwork = false
+				}
+			}
+			depth++
+		case c == '}' && parenDepth == 0:
+			depth--
+			if depth == 0 {
+				ctx.inImports = false
+				ctx.component = ""
+			}
+		case c == '-' && depth == 1 && ctx.component != "" && strings.HasPrefix(before[i:], "---"):
+			ctx.inNetwork = true
+			i += 2
+		case isIdentChar(c):
+			start := i
+			for i+1 < len(before) && isIdentChar(before[i+1]) {
+				i++
+			}
+			if depth != 0 || parenDepth != 0 {
+				continue
+			}
+			word := before[start : i+1]
+			switch word {
+			case "import", "type", "const", "interface", "def":
+				keyword = word
+				expectsName = word == "def"
+			default:
+				if expectsName {
+					defName = word
+					expectsName = false
+				}
+			}
+		}
+	}
+
+	// component without nodes section has only network
+	if ctx.component != "" && !ctx.inNetwork && !hasNodesSection(text[offset:]) {
+		ctx.inNetwork = true
+	}
+
+	start := offset
+	for start > 0 && (isIdentChar(text[start-1]) || strings.IndexByte(".:$", text[start-1]) != -1) {
+		start--
+	}
+	ctx.prefix = text[start:offset]
+
+	lineStart := strings.LastIndexByte(text[:start], '\n') + 1
+	line := text[lineStart:start]
+	ctx.inTypeArgs = strings.Count(line, "<") > strings.Count(line, ">")
+
+	return ctx
+}
+
+// skipString returns index of the closing quote of the string literal that starts at given index.
+func skipString(text string, i int) int {
+	quote := text[i]
+	for i++; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case quote, '\n':
+			return i
+		}
+	}
+	return i
+}
+
+// hasNodesSection reports whether `---` is met before the end of the component's body that contains the text.
+func hasNodesSection(after string) bool {
+	depth := 1
+	for i := 0; i < len(after); i++ {
+		switch c := after[i]; {
+		case c == '/' && i+1 < len(after) && after[i+1] == '/':
+			for i < len(after) && after[i] != '\n' {
+				i++
+			}
+		case c == '\'' || c == '"':
+			i = skipString(after, i)
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return false
+			}
+		case c == '-' && depth == 1 && strings.HasPrefix(after[i:], "---"):
+			return true
+		}
+	}
+	return false
+}
+
+// completer produces completion items for the single file of the build.
+type completer struct {
+	server *Server
+	build  src.Build
+	loc    core.Location
+	file   src.File
+	scope  src.Scope
+}
+
+// imports returns packages that can be imported: std packages and packages of the current module.
+func (c completer) imports() []protocol.CompletionItem {
+	items := []protocol.CompletionItem{}
+
+	stdModRef := c.build.Modules[c.build.EntryModRef].Manifest.Deps["std"]
+	for pkgName := range c.build.Modules[stdModRef].Packages {
+		if pkgName == "builtin" { // imported implicitly
+			continue
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:  pkgName,
+			Kind:   compiler.Pointer(protocol.CompletionItemKindModule),
+			Detail: compiler.Pointer("std"),
+		})
+	}
+
+	for pkgName := range c.build.Modules[c.loc.ModRef].Packages {
+		if pkgName == c.loc.Package {
+			continue
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:  "@:" + pkgName,
+			Kind:   compiler.Pointer(protocol.CompletionItemKindModule),
+			Detail: compiler.Pointer("local package"),
+		})
+	}
+
+	return items
+}
+
+// entities returns entities available in the scope.
+// Prefix like `fmt.` means only public entities of the imported package are needed.
+// Empty kinds means entities of any kind.
+func (c completer) entities(prefix string, kinds ...src.EntityKind) []protocol.CompletionItem {
+	items := []protocol.CompletionItem{}
+
+	if alias, _, ok := strings.Cut(prefix, "."); ok {
+		imp, ok := c.file.Imports[alias]
+		if !ok {
+			return items
+		}
+		mod, ok := c.importedModule(imp)
+		if !ok {
+			return items
+		}
+		for result := range mod.Packages[imp.Package].Entities() {
+			if result.Entity.IsPublic {
+				items = appendEntityItem(items, result.EntityName, result.Entity, imp.Package, kinds)
+			}
+		}
+		return items
+	}
+
+	for result := range c.build.Modules[c.loc.ModRef].Packages[c.loc.Package].Entities() {
+		items = appendEntityItem(items, result.EntityName, result.Entity, c.loc.Package, kinds)
+	}
+
+	stdModRef := c.build.Modules[c.build.EntryModRef].Manifest.Deps["std"]
+	for result := range c.build.Modules[stdModRef].Packages["builtin"].Entities() {
+		if result.Entity.IsPublic {
+			items = appendEntityItem(items, result.EntityName, result.Entity, "builtin", kinds)
+		}
+	}
+
+	for alias, imp := range c.file.Imports {
+		items = append(items, protocol.CompletionItem{
+			Label:  alias,
+			Kind:   compiler.Pointer(protocol.CompletionItemKindModule),
+			Detail: compiler.Pointer(imp.Package),
+		})
+	}
+
+	return items
+}
+
+func (c completer) importedModule(imp src.Import) (src.Module, bool) {
+	modRef := c.loc.ModRef
+	if imp.Module != "@" {
+		modRef = c.build.Modules[c.loc.ModRef].Manifest.Deps[imp.Module]
+	}
+	mod, ok := c.build.Modules[modRef]
+	return mod, ok
+}
+
+func appendEntityItem(
+	items []protocol.CompletionItem,
+	name string,
+	entity src.Entity,
+	pkgName string,
+	kinds []src.EntityKind,
+) []protocol.CompletionItem {
+	if len(kinds) != 0 && !containsKind(kinds, entity.Kind) {
+		return items
+	}
+
+	var kind protocol.CompletionItemKind
+	switch entity.Kind {
+	case src.ComponentEntity:
+		kind = protocol.CompletionItemKindFunction
+	case src.InterfaceEntity:
+		kind = protocol.CompletionItemKindInterface
+	case src.TypeEntity:
+		kind = protocol.CompletionItemKindStruct
+	case src.ConstEntity:
+		kind = protocol.CompletionItemKindConstant
+	}
+
+	return append(items, protocol.CompletionItem{
+		Label:  name,
+		Kind:   &kind,
+		Detail: compiler.Pointer(pkgName + "." + name),
+	})
+}
+
+func containsKind(kinds []src.EntityKind, kind src.EntityKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// network returns completions for the network of the component.
+func (c completer) network(comp src.Component, prefix string) []protocol.CompletionItem {
+	// constant senders like `$foo` or `$pkg.foo`
+	if rest, ok := strings.CutPrefix(prefix, "$"); ok {
+		return c.entities(rest, src.ConstEntity)
+	}
+
+	nodeName, rest, isPortAddr := strings.Cut(prefix, ":")
+	if !isPortAddr {
+		return c.portAddrs(comp)
+	}
+
+	// struct selectors like `node:port.field.`
+	if portName, selectors, ok := strings.Cut(rest, "."); ok {
+		return c.structFields(comp, nodeName, portName, strings.Split(selectors, "."))
+	}
+
+	// ports of the specific node or component itself
+	items := []protocol.CompletionItem{}
+	if nodeName == "" {
+		items = appendPortItems(items, comp.Interface.IO.In, "")
+		items = appendPortItems(items, comp.Interface.IO.Out, "")
+		return items
+	}
+
+	node, ok := comp.Nodes[nodeName]
+	if !ok {
+		return items
+	}
+	iface, ok := nodeInterface(c.scope, node)
+	if !ok {
+		return items
+	}
+	items = appendPortItems(items, iface.IO.In, "")
+	items = appendPortItems(items, iface.IO.Out, "")
+
+	return items
+}
+
+// portAddrs returns `node:port` addresses of all nodes of the component and `:port` addresses of the component.
+func (c completer) portAddrs(comp src.Component) []protocol.CompletionItem {
+	items := []protocol.CompletionItem{}
+
+	items = appendPortItems(items, comp.Interface.IO.In, ":")
+	items = appendPortItems(items, comp.Interface.IO.Out, ":")
+
+	for nodeName, iface := range nodesInterfaces(c.scope, comp) {
+		items = appendPortItems(items, iface.IO.In, nodeName+":")
+		items = appendPortItems(items, iface.IO.Out, nodeName+":")
+	}
+
+	return items
+}
+
+func appendPortItems(items []protocol.CompletionItem, ports map[string]src.Port, prefix string) []protocol.CompletionItem {
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		port := ports[name]
+		items = append(items, protocol.CompletionItem{
+			Label:  prefix + name,
+			Kind:   compiler.Pointer(protocol.CompletionItemKindProperty),
+			Detail: compiler.Pointer(port.TypeExpr.String()),
+		})
+	}
+
+	return items
+}
+
+// structFields returns fields of the struct that is selected from the port,
+// e.g. for `node:port.a.` it returns fields of the `a` field of the port's type.
+// Outports of the nodes and inports of the component are senders so they are the only ports that can have selectors.
+func (c completer) structFields(
+	comp src.Component,
+	nodeName string,
+	portName string,
+	selectors []string,
+) []protocol.CompletionItem {
+	items := []protocol.CompletionItem{}
+	frame := comp.Interface.TypeParams.ToFrame()
+
+	var typeExpr ts.Expr
+	if nodeName == "" {
+		port, ok := comp.Interface.IO.In[portName]
+		if !ok {
+			return items
+		}
+		resolved, err := c.server.resolver.ResolveExprWithFrame(port.TypeExpr, frame, c.scope)
+		if err != nil {
+			return items
+		}
+		typeExpr = resolved
+	} else {
+		node, ok := comp.Nodes[nodeName]
+		if !ok {
+			return items
+		}
+		_, resolved, ok := c.server.resolveNodePort(c.scope, frame, node, portName, false)
+		if !ok {
+			return items
+		}
+		typeExpr = resolved
+	}
+
+	// last selector is being typed right now
+	for _, selector := range selectors[:len(selectors)-1] {
+		if typeExpr.Lit == nil || typeExpr.Lit.Struct == nil {
+			return items
+		}
+		field, ok := typeExpr.Lit.Struct[selector]
+		if !ok {
+			return items
+		}
+		typeExpr = field
+	}
+
+	if typeExpr.Lit == nil {
+		return items
+	}
+	for name, field := range typeExpr.Lit.Struct {
+		items = append(items, protocol.CompletionItem{
+			Label:  name,
+			Kind:   compiler.Pointer(protocol.CompletionItemKindField),
+			Detail: compiler.Pointer(field.String()),
+		})
+	}
+
+	return items
+}
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// cutCursor returns text without the `|` marker and offset of the marker.
+func cutCursor(t *testing.T, text string) (string, int) {
+	t.Helper()
+	offset := strings.Index(text, "|")
+	require.NotEqual(t, -1, offset)
+	return text[:offset] + text[offset+1:], offset
+}
+
+func TestGetCompletionContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string // `|` marks the cursor
+		expected completionContext
+	}{
+		{
+			name:     "top_level",
+			text:     "def Main(start any) (stop any) {\n\t:start -> :stop\n}\n|",
+			expected: completionContext{},
+		},
+		{
+			name:     "import_block",
+			text:     "import {\n\tfmt\n\t|\n}\n",
+			expected: completionContext{inImports: true},
+		},
+		{
+			name:     "after_import_block",
+			text:     "import { fmt }\n\n|",
+			expected: completionContext{},
+		},
+		{
+			name:     "nodes_section",
+			text:     "def Main(start any) (stop any) {\n\tp fmt.Pr|\n\t---\n\t:start -> p -> :stop\n}\n",
+			expected: completionContext{component: "Main", prefix: "fmt.Pr"},
+		},
+		{
+			name:     "network",
+			text:     "def Main(start any) (stop any) {\n\tp Print\n\t---\n\t:start -> p:|\n}\n",
+			expected: completionContext{component: "Main", inNetwork: true, prefix: "p:"},
+		},
+		{
+			name:     "component_without_nodes_section",
+			text:     "def Main(start any) (stop any) {\n\t:start -> |\n}\n",
+			expected: completionContext{component: "Main", inNetwork: true},
+		},
+		{
+			name:     "struct_selector",
+			text:     "def Main(start any) (stop any) {\n\t:start -> p:res.a.|\n}\n",
+			expected: completionContext{component: "Main", inNetwork: true, prefix: "p:res.a."},
+		},
+		{
+			name:     "const_sender",
+			text:     "def Main(start any) (stop any) {\n\t:start -> { $gr| }\n}\n",
+			expected: completionContext{component: "Main", inNetwork: true, prefix: "$gr"},
+		},
+		{
+			name:     "braces_in_comments",
+			text:     "// def Foo() () {\ndef Main(start any) (stop any) {\n\t// }\n\tp |\n\t---\n\t// ---\n}\n",
+			expected: completionContext{component: "Main"},
+		},
+		{
+			name:     "braces_in_strings",
+			text:     "const s string = '{'\ndef Main(start any) (stop any) {\n\t:start -> { '}' -> p }\n\t:start -> \"{\" -> |\n}\n",
+			expected: completionContext{component: "Main", inNetwork: true},
+		},
+		{
+			name:     "dashes_in_strings_of_the_rest_of_component",
+			text:     "def Main(start any) (stop any) {\n\t|\n\t:start -> { '---' -> p }\n}\n",
+			expected: completionContext{component: "Main", inNetwork: true},
+		},
+		{
+			name:     "type_args",
+			text:     "def Main(start any) (stop any) {\n\tp Pass<list<Us|\n\t---\n}\n",
+			expected: completionContext{component: "Main", inTypeArgs: true, prefix: "Us"},
+		},
+		{
+			name:     "closed_type_args",
+			text:     "def Main(start any) (stop any) {\n\tp Pass<list<User>>, |\n\t---\n}\n",
+			expected: completionContext{component: "Main"},
+		},
+		{
+			name:     "generic_component_signature",
+			text:     "def Pass<T>(data T) (res T) {\n\t:data -> |\n}\n",
+			expected: completionContext{component: "Pass", inNetwork: true},
+		},
+		{
+			name:     "second_component",
+			text:     "def Main(start any) (stop any) {\n\tp Pass\n\t---\n\t:start -> p -> :stop\n}\n\ndef Pass(data any) (res any) {\n\t:data -> |\n}\n",
+			expected: completionContext{component: "Pass", inNetwork: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, offset := cutCursor(t, tt.text)
+			require.Equal(t, tt.expected, getCompletionContext(text, offset))
+		})
+	}
+}
+
+func TestTextDocumentCompletion(t *testing.T) {
+	source := `import { fmt }
+
+type User struct {
+	name string
+	address struct {
+		city string
+	}
+}
+
+def Main(start any) (stop any) {
+	println fmt.Println<User>
+	---
+	:start -> println
+	println:res -> :stop
+}
+`
+
+	tests := []struct {
+		name     string
+		old      string // part of the source that is replaced by new
+		new      string // `|` marks the cursor
+		expected []string
+	}{
+		{
+			name:     "imported_package_entities",
+			old:      "\t---",
+			new:      "\tprint fmt.|\n\t---",
+			expected: []string{"Print", "Printf", "Println", "Scanln"},
+		},
+		{
+			name:     "node_ports",
+			old:      "\tprintln:res -> :stop",
+			new:      "\tprintln:|",
+			expected: []string{"data", "err", "res"},
+		},
+		{
+			name:     "struct_fields",
+			old:      "\tprintln:res -> :stop",
+			new:      "\tprintln:res.|",
+			expected: []string{"address", "name"},
+		},
+		{
+			name:     "nested_struct_fields",
+			old:      "\tprintln:res -> :stop",
+			new:      "\tprintln:res.address.|",
+			expected: []string{"city"},
+		},
+		{
+			name: "import_block",
+			old:  "import { fmt }",
+			new:  "import {\n\tfmt\n\t|\n}",
+			expected: []string{
+				"@:greet", "errors", "fmt", "http", "image", "io", "lists", "os",
+				"regexp", "strconv", "streams", "strings", "sync", "time",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, modPath, _ := newTestServer(t, map[string]string{
+				"main/main.neva":   source,
+				"greet/greet.neva": "pub def Hello(data any) (res any) {\n\t:data -> :res\n}\n",
+			})
+			uri := pathToURI(filepath.Join(modPath, "main", "main.neva"))
+
+			// completion is requested for unsaved text
+			require.Contains(t, source, tt.old)
+			marked := strings.Replace(source, tt.old, tt.new, 1)
+			s.documents[uri], _ = cutCursor(t, marked)
+
+			resp, err := s.TextDocumentCompletion(nil, &protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+					Position:     positionOf(t, marked, "|"),
+				},
+			})
+			require.NoError(t, err)
+
+			labels := []string{}
+			for _, item := range resp.([]protocol.CompletionItem) {
+				labels = append(labels, item.Label)
+			}
+			sort.Strings(labels)
+			require.Equal(t, tt.expected, labels)
+		})
+	}
+}
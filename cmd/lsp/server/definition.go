@@ -0,0 +1,170 @@
+package server
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/nevalang/neva/cmd/lsp/indexer"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+func (s *Server) TextDocumentDefinition(
+	glspCtx *glsp.Context,
+	params *protocol.DefinitionParams,
+) (any, error) {
+	index := s.currentIndex()
+	if index == nil {
+		return nil, nil
+	}
+
+	sources := s.sourceCache(*index)
+
+	occ, _, ok := occurrenceAt(*index, sources, params.TextDocument.URI, params.Position)
+	if !ok {
+		return nil, nil
+	}
+
+	loc, ok := definitionLocation(index.Parsed, sources, occ.target)
+	if !ok {
+		return nil, nil
+	}
+
+	return loc, nil
+}
+
+func (s *Server) currentIndex() *indexer.Index {
+	s.indexMutex.Lock()
+	defer s.indexMutex.Unlock()
+	return s.index
+}
+
+// occurrenceAt returns occurrence under the cursor and location of the file it's in.
+func occurrenceAt(
+	index indexer.Index,
+	sources *sourceCache,
+	uri string,
+	pos protocol.Position,
+) (occurrence, core.Location, bool) {
+	loc, ok := fileLocation(index, uriToPath(uri))
+	if !ok {
+		return occurrence{}, core.Location{}, false
+	}
+
+	cursor := core.Position{
+		Line:   int(pos.Line) + 1,
+		Column: int(pos.Character),
+	}
+
+	var (
+		found   occurrence
+		isFound bool
+	)
+	for _, occ := range fileOccurrences(index.Parsed, loc, sources) {
+		if !occ.contains(cursor) {
+			continue
+		}
+		// occurrences can touch each other, e.g. in `node:port`, so the narrowest one wins
+		if !isFound || occ.length < found.length {
+			found = occ
+			isFound = true
+		}
+	}
+
+	return found, loc, isFound
+}
+
+// definitionLocation returns location where target is defined.
+func definitionLocation(build src.Build, sources *sourceCache, t target) (protocol.Location, bool) {
+	pkg, ok := build.Modules[t.modRef].Packages[t.pkg]
+	if !ok {
+		return protocol.Location{}, false
+	}
+
+	if t.kind == packageTarget {
+		// package has no single definition so we jump to its first file
+		fileNames := make([]string, 0, len(pkg))
+		for fileName := range pkg {
+			fileNames = append(fileNames, fileName)
+		}
+		if len(fileNames) == 0 {
+			return protocol.Location{}, false
+		}
+		sort.Strings(fileNames)
+		return sources.location(
+			core.Location{ModRef: t.modRef, Package: t.pkg, Filename: fileNames[0]},
+			core.Position{Line: 1},
+			0,
+		)
+	}
+
+	entity, fileName, ok := pkg.Entity(t.entity)
+	if !ok {
+		return protocol.Location{}, false
+	}
+
+	loc := core.Location{ModRef: t.modRef, Package: t.pkg, Filename: fileName}
+	for _, occ := range fileOccurrences(build, loc, sources) {
+		if occ.isDef && occ.target == t {
+			return sources.location(loc, occ.start, occ.length)
+		}
+	}
+
+	// nodes without explicit name are defined by their entity reference
+	if t.kind == nodeTarget {
+		if node, ok := entity.Component.Nodes[t.node]; ok {
+			return sources.location(loc, node.Meta.Start, len(node.EntityRef.Meta.Text))
+		}
+	}
+
+	return protocol.Location{}, false
+}
+
+// fileLocation returns location of the file of the build by its path on disk.
+func fileLocation(index indexer.Index, path string) (core.Location, bool) {
+	loc, ok := index.Location(path)
+	if !ok {
+		return core.Location{}, false
+	}
+	_, ok = index.Parsed.Modules[loc.ModRef].Packages[loc.Package][loc.Filename]
+	return loc, ok
+}
+
+// location returns LSP location of the single-line range in the file.
+func (s *sourceCache) location(loc core.Location, start core.Position, length int) (protocol.Location, bool) {
+	path, ok := s.path(loc)
+	if !ok {
+		return protocol.Location{}, false
+	}
+	return protocol.Location{
+		URI: pathToURI(path),
+		Range: protocol.Range{
+			Start: protocol.Position{
+				Line:      uint32(start.Line - 1),
+				Character: uint32(start.Column),
+			},
+			End: protocol.Position{
+				Line:      uint32(start.Line - 1),
+				Character: uint32(start.Column + length),
+			},
+		},
+	}, true
+}
+
+// uriToPath turns `file://` URI into path, other URIs are treated as paths.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestTextDocumentDefinition(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		cursor     string // cursor is at the start of the first occurrence
+		expectFile string
+		expectAt   string
+		expectLen  int
+	}{
+		{
+			name:       "entity_of_imported_package",
+			file:       "main/main.neva",
+			cursor:     "Hello",
+			expectFile: "greet/greet.neva",
+			expectAt:   "Hello",
+			expectLen:  len("Hello"),
+		},
+		{
+			name:       "imported_package",
+			file:       "main/main.neva",
+			cursor:     "greet.Hello",
+			expectFile: "greet/greet.neva",
+			expectAt:   "pub",
+			expectLen:  0,
+		},
+		{
+			name:       "node",
+			file:       "main/main.neva",
+			cursor:     "hello -> :stop",
+			expectFile: "main/main.neva",
+			expectAt:   "hello greet",
+			expectLen:  len("hello"),
+		},
+		{
+			name:       "entity_of_same_package",
+			file:       "greet/greet.neva",
+			cursor:     "Pass\n",
+			expectFile: "greet/greet.neva",
+			expectAt:   "Pass(",
+			expectLen:  len("Pass"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := navigationFiles()
+			s, modPath, errs := newTestServer(t, navigationFiles())
+			require.False(t, errs.HasErrors(), errs.Error())
+
+			resp, err := s.TextDocumentDefinition(nil, &protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: pathToURI(filepath.Join(modPath, tt.file))},
+					Position:     positionOf(t, files[tt.file], tt.cursor),
+				},
+			})
+			require.NoError(t, err)
+
+			start := positionOf(t, files[tt.expectFile], tt.expectAt)
+			end := start
+			end.Character += uint32(tt.expectLen)
+			require.Equal(t, protocol.Location{
+				URI:   pathToURI(filepath.Join(modPath, tt.expectFile)),
+				Range: protocol.Range{Start: start, End: end},
+			}, resp)
+		})
+	}
+}
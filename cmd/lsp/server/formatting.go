@@ -0,0 +1,55 @@
+package server
+
+import (
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/nevalang/neva/internal/compiler/formatter"
+)
+
+// TextDocumentFormatting formats the whole document the same way `neva fmt` does.
+// Documents with syntax errors are left as they are, the errors are reported as diagnostics.
+func (s *Server) TextDocumentFormatting(
+	glspCtx *glsp.Context,
+	params *protocol.DocumentFormattingParams,
+) ([]protocol.TextEdit, error) {
+	s.documentsMutex.Lock()
+	text, ok := s.documents[params.TextDocument.URI]
+	s.documentsMutex.Unlock()
+
+	if !ok {
+		bb, err := os.ReadFile(uriToPath(params.TextDocument.URI))
+		if err != nil {
+			return nil, err
+		}
+		text = string(bb)
+	}
+
+	formatted, err := formatter.Format([]byte(text))
+	if err != nil {
+		return nil, nil
+	}
+
+	if string(formatted) == text {
+		return []protocol.TextEdit{}, nil
+	}
+
+	// positions are measured in UTF-16 code units
+	lines := strings.Split(text, "\n")
+	lastLine := lines[len(lines)-1]
+
+	return []protocol.TextEdit{{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 0, Character: 0},
+			End: protocol.Position{
+				Line:      protocol.UInteger(len(lines) - 1),
+				Character: protocol.UInteger(len(utf16.Encode([]rune(lastLine)))),
+			},
+		},
+		NewText: string(formatted),
+	}}, nil
+}
@@ -8,8 +8,22 @@ import (
 func (s *Server) Initialize(glspCtx *glsp.Context, params *protocol.InitializeParams) (any, error) {
 	s.workspacePath = *params.RootPath
 
+	capabilities := s.handler.CreateServerCapabilities()
+	// glsp doesn't advertise prepare rename so it's done manually
+	prepareProvider := true
+	capabilities.RenameProvider = protocol.RenameOptions{PrepareProvider: &prepareProvider}
+	// glsp doesn't know the legend of semantic tokens
+	capabilities.SemanticTokensProvider.(*protocol.SemanticTokensOptions).Legend = semanticTokensLegend
+
+	if workspace := params.Capabilities.Workspace; workspace != nil &&
+		workspace.SemanticTokens != nil &&
+		workspace.SemanticTokens.RefreshSupport != nil &&
+		*workspace.SemanticTokens.RefreshSupport {
+		s.refreshSemanticTokens = glspCtx.Call
+	}
+
 	return protocol.InitializeResult{
-		Capabilities: s.handler.CreateServerCapabilities(),
+		Capabilities: capabilities,
 		ServerInfo: &protocol.InitializeResultServerInfo{
 			Name:    s.name,
 			Version: &s.version,
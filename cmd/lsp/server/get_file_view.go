@@ -1,6 +1,11 @@
 package server
 
 import (
+	"errors"
+	"fmt"
+
+	"github.com/tliron/glsp"
+
 	src "github.com/nevalang/neva/internal/compiler/sourcecode"
 )
 
@@ -25,3 +30,58 @@ type GetFileViewResponce struct {
 type Extra struct {
 	NodesPorts map[string]map[string]src.Interface `json:"nodesPorts"` // flows -> nodes -> interface
 }
+
+func (s *Server) GetFileView(glspCtx *glsp.Context, req GetFileViewRequest) (GetFileViewResponce, error) {
+	index := s.currentIndex()
+	if index == nil {
+		return GetFileViewResponce{}, errors.New("workspace is not indexed yet")
+	}
+
+	loc, ok := fileLocation(*index, req.Document.URI.FSPath)
+	if !ok {
+		return GetFileViewResponce{}, fmt.Errorf("file not found in workspace: %v", req.Document.URI.FSPath)
+	}
+
+	file := index.Parsed.Modules[loc.ModRef].Packages[loc.Package][loc.Filename]
+	scope := src.NewScope(index.Parsed, loc)
+
+	nodesPorts := map[string]map[string]src.Interface{}
+	for name, entity := range file.Entities {
+		if entity.Kind == src.ComponentEntity {
+			nodesPorts[name] = nodesInterfaces(scope, entity.Component)
+		}
+	}
+
+	return GetFileViewResponce{
+		File: file,
+		Extra: Extra{
+			NodesPorts: nodesPorts,
+		},
+	}, nil
+}
+
+// nodesInterfaces returns interfaces of the component's nodes.
+// Nodes which entities can't be found are skipped.
+func nodesInterfaces(scope src.Scope, comp src.Component) map[string]src.Interface {
+	result := make(map[string]src.Interface, len(comp.Nodes))
+	for name, node := range comp.Nodes {
+		if iface, ok := nodeInterface(scope, node); ok {
+			result[name] = iface
+		}
+	}
+	return result
+}
+
+func nodeInterface(scope src.Scope, node src.Node) (src.Interface, bool) {
+	entity, _, err := scope.Entity(node.EntityRef)
+	if err != nil {
+		return src.Interface{}, false
+	}
+	switch entity.Kind {
+	case src.InterfaceEntity:
+		return entity.Interface, true
+	case src.ComponentEntity:
+		return entity.Component.Interface, true
+	}
+	return src.Interface{}, false
+}
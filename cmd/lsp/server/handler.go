@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/nevalang/neva/cmd/lsp/indexer"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
 	"github.com/nevalang/neva/pkg"
 	"github.com/tliron/commonlog"
 	"github.com/tliron/glsp"
@@ -15,7 +16,8 @@ import (
 type Handler struct {
 	*protocol.Handler
 
-	GetFileView func(glspCtx *glsp.Context, params GetFileViewRequest) (GetFileViewResponce, error)
+	GetFileView   func(glspCtx *glsp.Context, params GetFileViewRequest) (GetFileViewResponce, error)
+	PrepareRename func(glspCtx *glsp.Context, params protocol.PrepareRenameParams) (*protocol.RangeWithPlaceholder, error)
 }
 
 func (h Handler) Handle(glspCtx *glsp.Context) (response any, validMethod bool, validParams bool, err error) {
@@ -37,11 +39,44 @@ func (h Handler) Handle(glspCtx *glsp.Context) (response any, validMethod bool,
 		return resp, true, true, nil
 	}
 
+	if glspCtx.Method == protocol.MethodTextDocumentPrepareRename {
+		var params protocol.PrepareRenameParams
+		if err := json.Unmarshal(glspCtx.Params, &params); err != nil {
+			return nil, true, false, err
+		}
+
+		resp, err := h.PrepareRename(glspCtx, params)
+		if err != nil {
+			return nil, true, true, err
+		}
+
+		return resp, true, true, nil
+	}
+
+	if glspCtx.Method == protocol.MethodTextDocumentCodeAction {
+		params, err := decodeCodeActionParams(glspCtx.Params)
+		if err != nil {
+			return nil, true, false, err
+		}
+
+		resp, err := h.TextDocumentCodeAction(glspCtx, &params)
+		if err != nil {
+			return nil, true, true, err
+		}
+
+		return resp, true, true, nil
+	}
+
 	return h.Handler.Handle(glspCtx)
 }
 
 //nolint:lll,funlen
-func BuildHandler(logger commonlog.Logger, serverName string, indexer indexer.Indexer) *Handler {
+func BuildHandler(
+	logger commonlog.Logger,
+	serverName string,
+	indexer indexer.Indexer,
+	resolver ts.Resolver,
+) *Handler {
 	h := &Handler{
 		Handler: &protocol.Handler{},
 	}
@@ -52,14 +87,23 @@ func BuildHandler(logger commonlog.Logger, serverName string, indexer indexer.In
 		name:            serverName,
 		version:         pkg.Version,
 		indexer:         indexer,
+		resolver:        resolver,
 		indexMutex:      &sync.Mutex{},
 		index:           nil,
 		problemsMutex:   &sync.Mutex{},
 		problemFiles:    make(map[string]struct{}),
 		activeFile:      "",
 		activeFileMutex: &sync.Mutex{},
+		documentsMutex:  &sync.Mutex{},
+		documents:       make(map[string]string),
+		unsaved:         make(map[string]struct{}),
+		pending:         make(map[string]struct{}),
+		indexingMutex:   &sync.Mutex{},
 	}
 
+	h.GetFileView = s.GetFileView
+	h.PrepareRename = s.TextDocumentPrepareRename
+
 	// Basic
 	h.CancelRequest = func(_ *glsp.Context, params *protocol.CancelParams) error {
 		return nil
@@ -92,9 +136,7 @@ func BuildHandler(logger commonlog.Logger, serverName string, indexer indexer.In
 	h.WorkspaceDidChangeWatchedFiles = func(context *glsp.Context, params *protocol.DidChangeWatchedFilesParams) error {
 		return nil
 	}
-	h.WorkspaceSymbol = func(context *glsp.Context, params *protocol.WorkspaceSymbolParams) ([]protocol.SymbolInformation, error) {
-		return nil, nil
-	}
+	h.WorkspaceSymbol = s.WorkspaceSymbol
 	h.WorkspaceExecuteCommand = func(context *glsp.Context, params *protocol.ExecuteCommandParams) (any, error) {
 		return nil, nil
 	}
@@ -116,13 +158,9 @@ func BuildHandler(logger commonlog.Logger, serverName string, indexer indexer.In
 	h.WorkspaceDidDeleteFiles = func(context *glsp.Context, params *protocol.DeleteFilesParams) error {
 		return nil
 	}
-	h.WorkspaceSemanticTokensRefresh = func(context *glsp.Context) error {
-		return nil
-	}
+	h.WorkspaceSemanticTokensRefresh = nil // sent by the server, see Server.refreshSemanticTokens
 
-	h.TextDocumentDidOpen = func(glpsCtx *glsp.Context, params *protocol.DidOpenTextDocumentParams) error {
-		return nil
-	}
+	h.TextDocumentDidOpen = s.TextDocumentDidOpen
 	h.TextDocumentDidChange = s.TextDocumentDidChange
 	h.TextDocumentWillSave = func(context *glsp.Context, params *protocol.WillSaveTextDocumentParams) error {
 		return nil
@@ -131,22 +169,20 @@ func BuildHandler(logger commonlog.Logger, serverName string, indexer indexer.In
 		return nil, nil
 	}
 	h.TextDocumentDidSave = s.TextDocumentDidSave
-	h.TextDocumentDidClose = func(context *glsp.Context, params *protocol.DidCloseTextDocumentParams) error {
-		return nil
-	}
+	h.TextDocumentDidClose = s.TextDocumentDidClose
 
 	h.TextDocumentCompletion = s.TextDocumentCompletion
 	h.CompletionItemResolve = nil
-	h.TextDocumentHover = nil
+	h.TextDocumentHover = s.TextDocumentHover
 	h.TextDocumentSignatureHelp = nil
 	h.TextDocumentDeclaration = nil
-	h.TextDocumentDefinition = nil
+	h.TextDocumentDefinition = s.TextDocumentDefinition
 	h.TextDocumentTypeDefinition = nil
 	h.TextDocumentImplementation = nil
-	h.TextDocumentReferences = nil
+	h.TextDocumentReferences = s.TextDocumentReferences
 	h.TextDocumentDocumentHighlight = nil
-	h.TextDocumentDocumentSymbol = nil
-	h.TextDocumentCodeAction = nil
+	h.TextDocumentDocumentSymbol = s.TextDocumentDocumentSymbol
+	h.TextDocumentCodeAction = s.TextDocumentCodeAction
 	h.CodeActionResolve = nil
 	h.TextDocumentCodeLens = nil
 	h.CodeLensResolve = nil
@@ -154,19 +190,19 @@ func BuildHandler(logger commonlog.Logger, serverName string, indexer indexer.In
 	h.DocumentLinkResolve = nil
 	h.TextDocumentColor = nil
 	h.TextDocumentColorPresentation = nil
-	h.TextDocumentFormatting = nil
+	h.TextDocumentFormatting = s.TextDocumentFormatting
 	h.TextDocumentRangeFormatting = nil
 	h.TextDocumentOnTypeFormatting = nil
-	h.TextDocumentRename = nil
-	h.TextDocumentPrepareRename = nil
+	h.TextDocumentRename = s.TextDocumentRename
+	h.TextDocumentPrepareRename = nil // handled by Handler.PrepareRename
 	h.TextDocumentFoldingRange = nil
 	h.TextDocumentSelectionRange = nil
 	h.TextDocumentPrepareCallHierarchy = nil
 	h.CallHierarchyIncomingCalls = nil
 	h.CallHierarchyOutgoingCalls = nil
-	h.TextDocumentSemanticTokensFull = nil
+	h.TextDocumentSemanticTokensFull = s.TextDocumentSemanticTokensFull
 	h.TextDocumentSemanticTokensFullDelta = nil
-	h.TextDocumentSemanticTokensRange = nil
+	h.TextDocumentSemanticTokensRange = s.TextDocumentSemanticTokensRange
 	h.TextDocumentLinkedEditingRange = nil
 	h.TextDocumentMoniker = nil
 
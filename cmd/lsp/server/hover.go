@@ -0,0 +1,458 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+)
+
+func (s *Server) TextDocumentHover(
+	glspCtx *glsp.Context,
+	params *protocol.HoverParams,
+) (*protocol.Hover, error) {
+	index := s.currentIndex()
+	if index == nil {
+		return nil, nil
+	}
+
+	sources := s.sourceCache(*index)
+
+	occ, loc, ok := occurrenceAt(*index, sources, params.TextDocument.URI, params.Position)
+	if !ok {
+		return nil, nil
+	}
+
+	h := hoverer{
+		build:   index.Parsed,
+		sources: sources,
+		loc:     loc,
+	}
+
+	var code, doc string
+	switch occ.target.kind {
+	case entityTarget:
+		code, doc, ok = h.entity(occ.target)
+	case packageTarget:
+		code, ok = fmt.Sprintf("package %v // %v", occ.target.pkg, occ.target.modRef), true
+	case nodeTarget:
+		code, doc, ok = h.node(occ.target)
+	case portTarget:
+		code, ok = h.port(occ)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	value := "```neva\n" + code + "\n```"
+	if doc != "" {
+		value += "\n\n" + doc
+	}
+
+	line := protocol.UInteger(occ.start.Line - 1)
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{
+			Kind:  protocol.MarkupKindMarkdown,
+			Value: value,
+		},
+		Range: &protocol.Range{
+			Start: protocol.Position{Line: line, Character: protocol.UInteger(occ.start.Column)},
+			End:   protocol.Position{Line: line, Character: protocol.UInteger(occ.start.Column + occ.length)},
+		},
+	}, nil
+}
+
+// hoverer renders hover content for targets referenced from the file at given location.
+type hoverer struct {
+	build   src.Build
+	sources *sourceCache
+	loc     core.Location
+}
+
+// entity returns entity definition and its doc comment.
+func (h hoverer) entity(t target) (string, string, bool) {
+	entity, loc, ok := h.lookupEntity(t)
+	if !ok {
+		return "", "", false
+	}
+	return formatEntity(t.entity, entity), h.sources.docComment(loc, entity.Meta().Start.Line), true
+}
+
+// node returns interface of the node with node's type arguments substituted.
+// Doc comment is the one of the node's entity.
+func (h hoverer) node(t target) (string, string, bool) {
+	comp, compLoc, ok := h.lookupEntity(t)
+	if !ok {
+		return "", "", false
+	}
+
+	node, ok := comp.Component.Nodes[t.node]
+	if !ok {
+		return "", "", false
+	}
+
+	nodeEntity, nodeLoc, err := src.NewScope(h.build, compLoc).Entity(node.EntityRef)
+	if err != nil {
+		return "", "", false
+	}
+
+	iface := nodeEntity.Interface
+	if nodeEntity.Kind == src.ComponentEntity {
+		iface = nodeEntity.Component.Interface
+	}
+
+	args := typeParamsArgs(iface.TypeParams.Params, node.TypeArgs)
+	io := formatIO(iface.IO, func(_ string, port src.Port, _ bool) string {
+		return formatTypeExpr(substituteTypeParams(port.TypeExpr, args), "")
+	})
+
+	code := fmt.Sprintf(
+		"%v %v%v\n%v",
+		t.node,
+		node.EntityRef,
+		formatTypeArgs(node.TypeArgs, ""),
+		io,
+	)
+
+	return code, h.sources.docComment(nodeLoc, nodeEntity.Meta().Start.Line), true
+}
+
+// port returns type of the port.
+// Ports of the nodes have type arguments of the node substituted,
+// ports of the component itself are shown as they are defined.
+func (h hoverer) port(occ occurrence) (string, bool) {
+	t := occ.target
+
+	direction := "inport"
+	if t.outport {
+		direction = "outport"
+	}
+
+	if occ.node == "" {
+		entity, _, ok := h.lookupEntity(t)
+		if !ok {
+			return "", false
+		}
+		iface := entity.Interface
+		if entity.Kind == src.ComponentEntity {
+			iface = entity.Component.Interface
+		}
+		ports := iface.IO.In
+		if t.outport {
+			ports = iface.IO.Out
+		}
+		port, ok := ports[t.port]
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf(
+			"%v %v:%v %v",
+			direction,
+			t.entity,
+			formatPortName(t.port, port),
+			formatTypeExpr(port.TypeExpr, ""),
+		), true
+	}
+
+	owner, ok := h.build.Modules[h.loc.ModRef].Packages[h.loc.Package][h.loc.Filename].Entities[occ.owner]
+	if !ok {
+		return "", false
+	}
+
+	node, ok := owner.Component.Nodes[occ.node]
+	if !ok {
+		return "", false
+	}
+
+	nodeEntity, _, err := src.NewScope(h.build, h.loc).Entity(node.EntityRef)
+	if err != nil {
+		return "", false
+	}
+
+	iface := nodeEntity.Interface
+	if nodeEntity.Kind == src.ComponentEntity {
+		iface = nodeEntity.Component.Interface
+	}
+
+	ports := iface.IO.In
+	if t.outport {
+		ports = iface.IO.Out
+	}
+
+	port, ok := ports[t.port]
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf(
+		"%v %v:%v %v",
+		direction,
+		occ.node,
+		formatPortName(t.port, port),
+		formatTypeExpr(substituteTypeParams(port.TypeExpr, typeParamsArgs(iface.TypeParams.Params, node.TypeArgs)), ""),
+	), true
+}
+
+func (h hoverer) lookupEntity(t target) (src.Entity, core.Location, bool) {
+	return lookupTargetEntity(h.build, t)
+}
+
+// docComment returns text of the comments written right above the given line.
+// Compiler directives between comments and definition are skipped.
+func (s *sourceCache) docComment(loc core.Location, line int) string {
+	lines, ok := s.fileLines(loc)
+	if !ok {
+		return ""
+	}
+
+	var comments []string
+	for i := line - 2; i >= 0 && i < len(lines); i-- {
+		text := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+		comment, ok := strings.CutPrefix(text, "//")
+		if !ok {
+			break
+		}
+		comments = append(comments, strings.TrimSpace(comment))
+	}
+
+	for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+		comments[i], comments[j] = comments[j], comments[i]
+	}
+
+	return strings.Join(comments, "\n")
+}
+
+func formatEntity(name string, entity src.Entity) string {
+	switch entity.Kind {
+	case src.TypeEntity:
+		s := "type " + name + formatTypeParams(entity.Type.Params)
+		if entity.Type.BodyExpr != nil {
+			s += " " + formatTypeExpr(*entity.Type.BodyExpr, "")
+		}
+		return s
+	case src.ConstEntity:
+		return fmt.Sprintf(
+			"const %v %v = %v",
+			name,
+			formatTypeExpr(entity.Const.TypeExpr, ""),
+			formatConstValue(entity.Const.Value),
+		)
+	case src.InterfaceEntity:
+		return "interface " + name + formatInterface(entity.Interface)
+	case src.ComponentEntity:
+		return "def " + name + formatInterface(entity.Component.Interface)
+	}
+	return name
+}
+
+func formatInterface(iface src.Interface) string {
+	return formatTypeParams(iface.TypeParams.Params) + formatIO(iface.IO, func(_ string, port src.Port, _ bool) string {
+		return formatTypeExpr(port.TypeExpr, "")
+	})
+}
+
+// typeParamsArgs maps type parameters to given type arguments.
+// Parameters without arguments are mapped to their constraints.
+func typeParamsArgs(params []ts.Param, args []ts.Expr) map[string]ts.Expr {
+	result := make(map[string]ts.Expr, len(params))
+	for i, param := range params {
+		if i < len(args) {
+			result[param.Name] = args[i]
+			continue
+		}
+		result[param.Name] = param.Constr
+	}
+	return result
+}
+
+// substituteTypeParams replaces references to type parameters with given type arguments.
+// Unlike resolution it keeps other references as they are written so named types are not expanded.
+func substituteTypeParams(expr ts.Expr, args map[string]ts.Expr) ts.Expr {
+	if expr.Inst != nil {
+		if arg, ok := args[expr.Inst.Ref.Name]; ok && expr.Inst.Ref.Pkg == "" && len(expr.Inst.Args) == 0 {
+			return arg
+		}
+		inst := *expr.Inst
+		inst.Args = make([]ts.Expr, 0, len(expr.Inst.Args))
+		for _, arg := range expr.Inst.Args {
+			inst.Args = append(inst.Args, substituteTypeParams(arg, args))
+		}
+		expr.Inst = &inst
+		return expr
+	}
+
+	if expr.Lit == nil {
+		return expr
+	}
+
+	lit := *expr.Lit
+	switch {
+	case lit.Struct != nil:
+		lit.Struct = make(map[string]ts.Expr, len(expr.Lit.Struct))
+		for name, field := range expr.Lit.Struct {
+			lit.Struct[name] = substituteTypeParams(field, args)
+		}
+	case lit.Union != nil:
+		lit.Union = make([]ts.Expr, 0, len(expr.Lit.Union))
+		for _, el := range expr.Lit.Union {
+			lit.Union = append(lit.Union, substituteTypeParams(el, args))
+		}
+	}
+	expr.Lit = &lit
+
+	return expr
+}
+
+func formatTypeParams(params []ts.Param) string {
+	if len(params) == 0 {
+		return ""
+	}
+	ss := make([]string, 0, len(params))
+	for _, param := range params {
+		ss = append(ss, param.Name+" "+formatTypeExpr(param.Constr, ""))
+	}
+	return "<" + strings.Join(ss, ", ") + ">"
+}
+
+func formatTypeArgs(args []ts.Expr, indent string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	ss := make([]string, 0, len(args))
+	for _, arg := range args {
+		ss = append(ss, formatTypeExpr(arg, indent))
+	}
+	return "<" + strings.Join(ss, ", ") + ">"
+}
+
+// formatIO renders inports and outports in the order they are defined.
+func formatIO(io src.IO, typeOf func(name string, port src.Port, isInport bool) string) string {
+	format := func(ports map[string]src.Port, isInport bool) string {
+		names := make([]string, 0, len(ports))
+		for name := range ports {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			a, b := ports[names[i]].Meta.Start, ports[names[j]].Meta.Start
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+
+		ss := make([]string, 0, len(names))
+		for _, name := range names {
+			port := ports[name]
+			s := typeOf(name, port, isInport)
+			if name != "" {
+				s = formatPortName(name, port) + " " + s
+			}
+			ss = append(ss, s)
+		}
+
+		return "(" + strings.Join(ss, ", ") + ")"
+	}
+
+	return format(io.In, true) + " " + format(io.Out, false)
+}
+
+func formatPortName(name string, port src.Port) string {
+	if port.IsArray {
+		return "[" + name + "]"
+	}
+	return name
+}
+
+// formatTypeExpr renders type expression in the nevalang syntax.
+// Unlike ts.Expr.String it's deterministic because struct fields are sorted.
+func formatTypeExpr(expr ts.Expr, indent string) string {
+	if expr.Lit != nil {
+		switch {
+		case expr.Lit.Struct != nil:
+			if len(expr.Lit.Struct) == 0 {
+				return "struct {}"
+			}
+			names := make([]string, 0, len(expr.Lit.Struct))
+			for name := range expr.Lit.Struct {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var b strings.Builder
+			b.WriteString("struct {\n")
+			for _, name := range names {
+				fmt.Fprintf(&b, "%v    %v %v\n", indent, name, formatTypeExpr(expr.Lit.Struct[name], indent+"    "))
+			}
+			b.WriteString(indent + "}")
+			return b.String()
+		case expr.Lit.Enum != nil:
+			return "enum { " + strings.Join(expr.Lit.Enum, ", ") + " }"
+		case expr.Lit.Union != nil:
+			ss := make([]string, 0, len(expr.Lit.Union))
+			for _, el := range expr.Lit.Union {
+				ss = append(ss, formatTypeExpr(el, indent))
+			}
+			return strings.Join(ss, " | ")
+		}
+	}
+
+	if expr.Inst == nil {
+		return "any"
+	}
+
+	return expr.Inst.Ref.String() + formatTypeArgs(expr.Inst.Args, indent)
+}
+
+// formatConstValue renders constant value in the nevalang syntax.
+func formatConstValue(value src.ConstValue) string {
+	if value.Ref != nil {
+		return value.Ref.String()
+	}
+
+	msg := value.Message
+	if msg == nil {
+		return ""
+	}
+
+	switch {
+	case msg.Bool != nil:
+		return fmt.Sprint(*msg.Bool)
+	case msg.Int != nil:
+		return fmt.Sprint(*msg.Int)
+	case msg.Float != nil:
+		return fmt.Sprint(*msg.Float)
+	case msg.Str != nil:
+		return fmt.Sprintf("%q", *msg.Str)
+	case msg.Enum != nil:
+		return msg.Enum.EnumRef.String() + "::" + msg.Enum.MemberName
+	case msg.List != nil:
+		ss := make([]string, 0, len(msg.List))
+		for _, item := range msg.List {
+			ss = append(ss, formatConstValue(item))
+		}
+		return "[" + strings.Join(ss, ", ") + "]"
+	case msg.DictOrStruct != nil:
+		keys := make([]string, 0, len(msg.DictOrStruct))
+		for key := range msg.DictOrStruct {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		ss := make([]string, 0, len(keys))
+		for _, key := range keys {
+			ss = append(ss, key+": "+formatConstValue(msg.DictOrStruct[key]))
+		}
+		return "{ " + strings.Join(ss, ", ") + " }"
+	case msg.Union != nil && msg.Union.Data != nil:
+		return formatConstValue(*msg.Union.Data)
+	}
+
+	return msg.Meta.Text
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestTextDocumentHover(t *testing.T) {
+	source := `import { fmt }
+
+// greeting is printed by Main.
+const greeting string = 'hello'
+
+// User is a person.
+type User struct {
+	name string
+	age int
+}
+
+// Main prints the greeting.
+def Main(start any) (stop any) {
+	println fmt.Println<string>
+	wrap Wrap<maybe<User>>
+	---
+	:start -> { $greeting -> println:data }
+	println:res -> wrap:data
+	wrap:res -> :stop
+	println:err -> panic
+}
+
+def Wrap<T>(data T) (res list<T>, err error)
+`
+
+	tests := []struct {
+		name   string
+		cursor string // cursor is at the start of the first occurrence
+		code   string
+		doc    string
+	}{
+		{
+			name:   "node_keeps_named_types",
+			cursor: "println:data",
+			code:   "println fmt.Println<string>\n(data string) (res string, err error)",
+			doc:    "Println prints to stdout with newline and then sends message further.",
+		},
+		{
+			name:   "node_with_type_params_substituted",
+			cursor: "wrap:data",
+			code:   "wrap Wrap<maybe<User>>\n(data maybe<User>) (res list<maybe<User>>, err error)",
+		},
+		{
+			name:   "node_port",
+			cursor: "res -> wrap",
+			code:   "outport println:res string",
+		},
+		{
+			name:   "node_port_with_type_params_substituted",
+			cursor: "res -> :stop",
+			code:   "outport wrap:res list<maybe<User>>",
+		},
+		{
+			name:   "component_port",
+			cursor: "stop\n",
+			code:   "outport Main:stop any",
+		},
+		{
+			name:   "const",
+			cursor: "greeting ->",
+			code:   `const greeting string = "hello"`,
+			doc:    "greeting is printed by Main.",
+		},
+		{
+			name:   "type",
+			cursor: "User>>\n",
+			code:   "type User struct {\n    age int\n    name string\n}",
+			doc:    "User is a person.",
+		},
+		{
+			name:   "component",
+			cursor: "Wrap<maybe",
+			code:   "def Wrap<T any>(data T) (res list<T>, err error)",
+		},
+	}
+
+	// hover uses parsed build so module doesn't have to pass analysis
+	s, modPath, _ := newTestServer(t, map[string]string{"main/main.neva": source})
+	uri := pathToURI(filepath.Join(modPath, "main", "main.neva"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hover, err := s.TextDocumentHover(nil, &protocol.HoverParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+					Position:     positionOf(t, source, tt.cursor),
+				},
+			})
+			require.NoError(t, err)
+			require.NotNil(t, hover)
+
+			expected := "```neva\n" + tt.code + "\n```"
+			if tt.doc != "" {
+				expected += "\n\n" + tt.doc
+			}
+			require.Equal(t, expected, hover.Contents.(protocol.MarkupContent).Value)
+		})
+	}
+}
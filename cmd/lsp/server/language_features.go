@@ -3,6 +3,8 @@ package server
 import (
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
 )
 
 func (s *Server) TextDocumentCompletion(
@@ -10,16 +12,54 @@ func (s *Server) TextDocumentCompletion(
 	params *protocol.CompletionParams,
 ) (any, error) {
 	s.logger.Info("TextDocumentCompletion")
-	return []protocol.CompletionItem{}, nil
-	// return []protocol.CompletionItem{
-	// 	{
-	// 		Label: "Simple Test",
-	// 		Kind:  compiler.Pointer(protocol.CompletionItemKindVariable),
-	// 		Data: core.EntityRef{
-	// 			Pkg:  "test_pkg",
-	// 			Name: "TestName",
-	// 		},
-	// 		Detail: compiler.Pointer("Simple test completion"),
-	// 	},
-	// }, nil
+
+	index := s.currentIndex()
+	if index == nil {
+		return []protocol.CompletionItem{}, nil
+	}
+
+	text, ok := s.documentText(params.TextDocument.URI)
+	if !ok {
+		return []protocol.CompletionItem{}, nil
+	}
+
+	loc, ok := fileLocation(*index, uriToPath(params.TextDocument.URI))
+	if !ok {
+		return []protocol.CompletionItem{}, nil
+	}
+
+	c := completer{
+		server: s,
+		build:  index.Parsed,
+		loc:    loc,
+		file:   index.Parsed.Modules[loc.ModRef].Packages[loc.Package][loc.Filename],
+		scope:  src.NewScope(index.Parsed, loc),
+	}
+
+	ctx := getCompletionContext(text, params.Position.IndexIn(text))
+
+	if ctx.inImports {
+		return c.imports(), nil
+	}
+
+	if ctx.component == "" {
+		return c.entities(ctx.prefix), nil
+	}
+
+	// component could be renamed or added after the last indexing
+	comp, ok := c.file.Entities[ctx.component]
+	if !ok || comp.Kind != src.ComponentEntity {
+		return c.entities(ctx.prefix), nil
+	}
+
+	if ctx.inNetwork {
+		return c.network(comp.Component, ctx.prefix), nil
+	}
+
+	// node's type arguments can be any entities, not only components
+	if ctx.inTypeArgs {
+		return c.entities(ctx.prefix), nil
+	}
+
+	return c.entities(ctx.prefix, src.ComponentEntity, src.InterfaceEntity), nil
 }
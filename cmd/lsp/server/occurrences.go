@@ -0,0 +1,509 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nevalang/neva/cmd/lsp/indexer"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+)
+
+type targetKind uint8
+
+const (
+	entityTarget  targetKind = iota + 1 // type, const, interface or component
+	packageTarget                       // imported package
+	nodeTarget                          // node of the component
+	portTarget                          // port of the interface or component
+)
+
+// target is something that can be defined once and referenced many times.
+// It's comparable so occurrences of the same target can be found by equality.
+type target struct {
+	kind    targetKind
+	modRef  core.ModuleRef
+	pkg     string
+	entity  string // entity that defines node or port, empty for packages
+	node    string
+	port    string
+	outport bool
+}
+
+// occurrence is a place in the file where target is defined or referenced.
+// It always fits into a single line.
+type occurrence struct {
+	target target
+	start  core.Position
+	length int
+	isDef  bool
+	owner  string // entity which definition contains occurrence, empty for imports
+	node   string // node which port is referenced in port address
+}
+
+func (o occurrence) contains(pos core.Position) bool {
+	return pos.Line == o.start.Line &&
+		pos.Column >= o.start.Column &&
+		pos.Column <= o.start.Column+o.length
+}
+
+// occurrenceCollector walks source code of the single file and collects occurrences of all targets in it.
+type occurrenceCollector struct {
+	owner   string // entity that is being walked
+	build   src.Build
+	scope   src.Scope
+	file    src.File
+	sources *sourceCache
+	result  []occurrence
+}
+
+// fileOccurrences returns occurrences of all targets in the file at given location.
+func fileOccurrences(build src.Build, loc core.Location, sources *sourceCache) []occurrence {
+	file, ok := build.Modules[loc.ModRef].Packages[loc.Package][loc.Filename]
+	if !ok {
+		return nil
+	}
+
+	c := &occurrenceCollector{
+		build:   build,
+		scope:   src.NewScope(build, loc),
+		file:    file,
+		sources: sources,
+	}
+
+	for _, imp := range file.Imports {
+		c.addImport(imp)
+	}
+
+	for name, entity := range file.Entities {
+		c.owner = name
+		c.addEntity(name, entity)
+	}
+
+	return c.result
+}
+
+func (c *occurrenceCollector) add(t target, start core.Position, length int, isDef bool) {
+	c.result = append(c.result, occurrence{
+		target: t,
+		start:  start,
+		length: length,
+		isDef:  isDef,
+		owner:  c.owner,
+	})
+}
+
+func (c *occurrenceCollector) addImport(imp src.Import) {
+	t, ok := c.packageTarget(imp)
+	if !ok {
+		return
+	}
+	text := strings.SplitN(imp.Meta.Text, "\n", 2)[0]
+	c.add(t, imp.Meta.Start, len(text), false)
+}
+
+func (c *occurrenceCollector) addEntity(name string, entity src.Entity) {
+	loc := c.scope.Location()
+	self := target{
+		kind:   entityTarget,
+		modRef: loc.ModRef,
+		pkg:    loc.Package,
+		entity: name,
+	}
+
+	// entity definitions always start with entity name
+	c.add(self, entity.Meta().Start, len(name), true)
+
+	switch entity.Kind {
+	case src.TypeEntity:
+		c.addTypeParams(entity.Type.Params)
+		if entity.Type.BodyExpr != nil {
+			c.addTypeExpr(*entity.Type.BodyExpr)
+		}
+	case src.ConstEntity:
+		c.addTypeExpr(entity.Const.TypeExpr)
+		c.addConstValue(entity.Const.Value)
+	case src.InterfaceEntity:
+		c.addInterface(self, entity.Interface)
+	case src.ComponentEntity:
+		c.addInterface(self, entity.Component.Interface)
+		c.addComponent(self, entity.Component)
+	}
+}
+
+func (c *occurrenceCollector) addInterface(self target, iface src.Interface) {
+	c.addTypeParams(iface.TypeParams.Params)
+	for name, port := range iface.IO.In {
+		c.addPortDef(self, name, port, false)
+	}
+	for name, port := range iface.IO.Out {
+		c.addPortDef(self, name, port, true)
+	}
+}
+
+func (c *occurrenceCollector) addPortDef(self target, name string, port src.Port, outport bool) {
+	c.addTypeExpr(port.TypeExpr)
+
+	pos, ok := c.sources.findIdent(port.Meta, name)
+	if !ok {
+		return
+	}
+
+	self.kind = portTarget
+	self.port = name
+	self.outport = outport
+	c.add(self, pos, len(name), true)
+}
+
+func (c *occurrenceCollector) addComponent(self target, comp src.Component) {
+	for name, node := range comp.Nodes {
+		c.addNode(node)
+		if pos, ok := c.sources.findIdent(node.Meta, name); ok {
+			c.add(c.nodeTarget(self, name), pos, len(name), true)
+		}
+	}
+
+	for _, conn := range comp.Net {
+		c.addConnection(self, comp, conn)
+	}
+}
+
+func (c *occurrenceCollector) addNode(node src.Node) {
+	c.addEntityRef(node.EntityRef)
+	for _, arg := range node.TypeArgs {
+		c.addTypeExpr(arg)
+	}
+	for _, dep := range node.DIArgs {
+		c.addNode(dep)
+	}
+}
+
+func (c *occurrenceCollector) addConnection(self target, comp src.Component, conn src.Connection) {
+	if conn.ArrayBypass != nil {
+		c.addPortAddr(self, comp, conn.ArrayBypass.SenderOutport, true)
+		c.addPortAddr(self, comp, conn.ArrayBypass.ReceiverInport, false)
+	}
+	if conn.Normal != nil {
+		c.addNormalConnection(self, comp, *conn.Normal, false)
+	}
+}
+
+// addNormalConnection adds occurrences in senders and receivers of the connection.
+// Senders of chained connection are receivers of the connection it's chained to.
+func (c *occurrenceCollector) addNormalConnection(
+	self target,
+	comp src.Component,
+	conn src.NormalConnection,
+	isChained bool,
+) {
+	for _, sender := range conn.Senders {
+		if isChained && sender.PortAddr != nil {
+			c.addPortAddr(self, comp, *sender.PortAddr, false)
+			continue
+		}
+		c.addSender(self, comp, sender)
+	}
+	c.addReceivers(self, comp, conn.Receivers)
+}
+
+func (c *occurrenceCollector) addSender(self target, comp src.Component, sender src.ConnectionSender) {
+	switch {
+	case sender.PortAddr != nil:
+		c.addPortAddr(self, comp, *sender.PortAddr, true)
+	case sender.Const != nil:
+		c.addConstValue(sender.Const.Value)
+	case sender.Unary != nil:
+		c.addSender(self, comp, sender.Unary.Operand)
+	case sender.Binary != nil:
+		c.addSender(self, comp, sender.Binary.Left)
+		c.addSender(self, comp, sender.Binary.Right)
+	case sender.Ternary != nil:
+		c.addSender(self, comp, sender.Ternary.Condition)
+		c.addSender(self, comp, sender.Ternary.Left)
+		c.addSender(self, comp, sender.Ternary.Right)
+	}
+}
+
+func (c *occurrenceCollector) addReceivers(self target, comp src.Component, receivers []src.ConnectionReceiver) {
+	for _, receiver := range receivers {
+		switch {
+		case receiver.PortAddr != nil:
+			c.addPortAddr(self, comp, *receiver.PortAddr, false)
+		case receiver.DeferredConnection != nil:
+			c.addConnection(self, comp, *receiver.DeferredConnection)
+		case receiver.ChainedConnection != nil && receiver.ChainedConnection.Normal != nil:
+			c.addNormalConnection(self, comp, *receiver.ChainedConnection.Normal, true)
+		case receiver.Switch != nil:
+			for _, switchCase := range receiver.Switch.Cases {
+				c.addNormalConnection(self, comp, switchCase, false)
+			}
+			c.addReceivers(self, comp, receiver.Switch.Default)
+		}
+	}
+}
+
+// addPortAddr adds occurrences of the node and the port in port address like `node:port[idx]`.
+// Port address without node refers to the port of the component itself.
+func (c *occurrenceCollector) addPortAddr(self target, comp src.Component, addr src.PortAddr, isSender bool) {
+	text := addr.Meta.Text
+	colon := strings.IndexByte(text, ':')
+
+	var (
+		port    target
+		viaNode string
+	)
+	switch {
+	case isSender && addr.Node == "in":
+		port = self
+	case !isSender && addr.Node == "out":
+		port = self
+		port.outport = true
+	default:
+		node, ok := comp.Nodes[addr.Node]
+		if !ok {
+			return
+		}
+		if colon != 0 {
+			c.add(c.nodeTarget(self, addr.Node), addr.Meta.Start, len(addr.Node), false)
+		}
+		t, ok := c.entityTarget(node.EntityRef)
+		if !ok {
+			return
+		}
+		port = t
+		port.outport = isSender
+		viaNode = addr.Node
+	}
+
+	if addr.Port == "" || colon == -1 {
+		return
+	}
+
+	port.kind = portTarget
+	port.port = addr.Port
+	c.add(
+		port,
+		core.Position{
+			Line:   addr.Meta.Start.Line,
+			Column: addr.Meta.Start.Column + colon + 1,
+		},
+		len(addr.Port),
+		false,
+	)
+	c.result[len(c.result)-1].node = viaNode
+}
+
+func (c *occurrenceCollector) addTypeParams(params []ts.Param) {
+	for _, param := range params {
+		c.addTypeExpr(param.Constr)
+	}
+}
+
+func (c *occurrenceCollector) addTypeExpr(expr ts.Expr) {
+	if expr.Inst != nil {
+		c.addEntityRef(expr.Inst.Ref)
+		for _, arg := range expr.Inst.Args {
+			c.addTypeExpr(arg)
+		}
+	}
+	if expr.Lit != nil {
+		for _, field := range expr.Lit.Struct {
+			c.addTypeExpr(field)
+		}
+		for _, el := range expr.Lit.Union {
+			c.addTypeExpr(el)
+		}
+	}
+}
+
+func (c *occurrenceCollector) addConstValue(value src.ConstValue) {
+	if value.Ref != nil {
+		c.addEntityRef(*value.Ref)
+	}
+	if value.Message == nil {
+		return
+	}
+	if value.Message.Enum != nil {
+		c.addEntityRef(value.Message.Enum.EnumRef)
+	}
+	for _, item := range value.Message.List {
+		c.addConstValue(item)
+	}
+	for _, field := range value.Message.DictOrStruct {
+		c.addConstValue(field)
+	}
+}
+
+// addEntityRef adds occurrence of the entity and, for imported entities, occurrence of the package.
+func (c *occurrenceCollector) addEntityRef(ref core.EntityRef) {
+	// implicit refs like `any` of the port without type are not written by user
+	if ref.Meta.Text == "" {
+		return
+	}
+
+	nameStart := ref.Meta.Start
+	if ref.Pkg != "" {
+		if imp, ok := c.file.Imports[ref.Pkg]; ok {
+			if t, ok := c.packageTarget(imp); ok {
+				c.add(t, ref.Meta.Start, len(ref.Pkg), false)
+			}
+		}
+		nameStart.Column += len(ref.Pkg) + 1
+	}
+
+	t, ok := c.entityTarget(ref)
+	if !ok {
+		return
+	}
+	c.add(t, nameStart, len(ref.Name), false)
+}
+
+func (c *occurrenceCollector) entityTarget(ref core.EntityRef) (target, bool) {
+	_, loc, err := c.scope.Entity(ref)
+	if err != nil { // e.g. reference to type parameter
+		return target{}, false
+	}
+	return target{
+		kind:   entityTarget,
+		modRef: loc.ModRef,
+		pkg:    loc.Package,
+		entity: ref.Name,
+	}, true
+}
+
+func (c *occurrenceCollector) packageTarget(imp src.Import) (target, bool) {
+	loc := c.scope.Location()
+
+	modRef := loc.ModRef
+	if imp.Module != "@" {
+		dep, ok := c.build.Modules[loc.ModRef].Manifest.Deps[imp.Module]
+		if !ok {
+			return target{}, false
+		}
+		modRef = dep
+	}
+
+	return target{
+		kind:   packageTarget,
+		modRef: modRef,
+		pkg:    imp.Package,
+	}, true
+}
+
+func (c *occurrenceCollector) nodeTarget(self target, name string) target {
+	self.kind = nodeTarget
+	self.node = name
+	return self
+}
+
+// sourceCache reads files of the build from disk, each file at most once.
+// Some positions, like names of nodes and ports, are not stored in the source code abstractions
+// so they have to be found in the text.
+type sourceCache struct {
+	modulePaths map[core.ModuleRef]string
+	lines       map[string][]string
+}
+
+func newSourceCache(modulePaths map[core.ModuleRef]string) *sourceCache {
+	return &sourceCache{
+		modulePaths: modulePaths,
+		lines:       map[string][]string{},
+	}
+}
+
+// sourceCache returns source cache that sees unsaved changes of the opened documents,
+// the same way the index does.
+func (s *Server) sourceCache(index indexer.Index) *sourceCache {
+	sources := newSourceCache(index.ModulePaths)
+
+	s.documentsMutex.Lock()
+	for uri := range s.unsaved {
+		if text, ok := s.documents[uri]; ok {
+			sources.lines[uriToPath(uri)] = strings.Split(text, "\n")
+		}
+	}
+	s.documentsMutex.Unlock()
+
+	return sources
+}
+
+// path returns path to the file on disk.
+func (s *sourceCache) path(loc core.Location) (string, bool) {
+	modPath, ok := s.modulePaths[loc.ModRef]
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(modPath, loc.Package, loc.Filename+".neva"), true
+}
+
+// fileLines returns lines of the file at given location.
+func (s *sourceCache) fileLines(loc core.Location) ([]string, bool) {
+	path, ok := s.path(loc)
+	if !ok {
+		return nil, false
+	}
+
+	lines, ok := s.lines[path]
+	if ok {
+		return lines, true
+	}
+
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	lines = strings.Split(string(bb), "\n")
+	s.lines[path] = lines
+
+	return lines, true
+}
+
+// findIdent returns position of identifier written somewhere between start and stop of the meta.
+func (s *sourceCache) findIdent(meta core.Meta, ident string) (core.Position, bool) {
+	lines, ok := s.fileLines(meta.Location)
+	if !ok {
+		return core.Position{}, false
+	}
+
+	for line := meta.Start.Line; line <= meta.Stop.Line && line <= len(lines); line++ {
+		from := 0
+		if line == meta.Start.Line {
+			from = meta.Start.Column
+		}
+		if col, ok := indexIdent(lines[line-1], ident, from); ok {
+			return core.Position{Line: line, Column: col}, true
+		}
+	}
+
+	return core.Position{}, false
+}
+
+// indexIdent returns index of the first whole-word occurrence of identifier in the line, starting from given index.
+func indexIdent(line, ident string, from int) (int, bool) {
+	if ident == "" {
+		return 0, false
+	}
+	for from < len(line) {
+		i := strings.Index(line[from:], ident)
+		if i == -1 {
+			return 0, false
+		}
+		start := from + i
+		end := start + len(ident)
+		if (start == 0 || !isIdentChar(line[start-1])) &&
+			(end == len(line) || !isIdentChar(line[end])) {
+			return start, true
+		}
+		from = end
+	}
+	return 0, false
+}
+
+func isIdentChar(b byte) bool {
+	return b == '_' ||
+		'a' <= b && b <= 'z' ||
+		'A' <= b && b <= 'Z' ||
+		'0' <= b && b <= '9'
+}
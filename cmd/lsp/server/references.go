@@ -0,0 +1,79 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+func (s *Server) TextDocumentReferences(
+	glspCtx *glsp.Context,
+	params *protocol.ReferenceParams,
+) ([]protocol.Location, error) {
+	index := s.currentIndex()
+	if index == nil {
+		return nil, nil
+	}
+
+	sources := s.sourceCache(*index)
+
+	cursorOcc, _, ok := occurrenceAt(*index, sources, params.TextDocument.URI, params.Position)
+	if !ok {
+		return nil, nil
+	}
+
+	result := []protocol.Location{}
+	for _, occ := range targetOccurrences(index.Parsed, sources, cursorOcc.target) {
+		if occ.isDef && !params.Context.IncludeDeclaration {
+			continue
+		}
+		if location, ok := sources.location(occ.loc, occ.start, occ.length); ok {
+			result = append(result, location)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.URI != b.URI {
+			return a.URI < b.URI
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return a.Range.Start.Line < b.Range.Start.Line
+		}
+		return a.Range.Start.Character < b.Range.Start.Character
+	})
+
+	return result, nil
+}
+
+// locatedOccurrence is an occurrence together with location of the file it's in.
+type locatedOccurrence struct {
+	occurrence
+	loc core.Location
+}
+
+// targetOccurrences returns occurrences of the target in every file of the build.
+func targetOccurrences(build src.Build, sources *sourceCache, t target) []locatedOccurrence {
+	var result []locatedOccurrence
+	for modRef, mod := range build.Modules {
+		for pkgName, pkg := range mod.Packages {
+			for fileName := range pkg {
+				loc := core.Location{
+					ModRef:   modRef,
+					Package:  pkgName,
+					Filename: fileName,
+				}
+				for _, occ := range fileOccurrences(build, loc, sources) {
+					if occ.target == t {
+						result = append(result, locatedOccurrence{occ, loc})
+					}
+				}
+			}
+		}
+	}
+	return result
+}
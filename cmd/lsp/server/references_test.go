@@ -0,0 +1,76 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestTextDocumentReferences(t *testing.T) {
+	files := navigationFiles()
+	s, modPath, errs := newTestServer(t, navigationFiles())
+	require.False(t, errs.HasErrors(), errs.Error())
+
+	location := func(file, at string, length int) protocol.Location {
+		start := positionOf(t, files[file], at)
+		end := start
+		end.Character += uint32(length)
+		return protocol.Location{
+			URI:   pathToURI(filepath.Join(modPath, file)),
+			Range: protocol.Range{Start: start, End: end},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		file               string
+		cursor             string // cursor is at the start of the first occurrence
+		includeDeclaration bool
+		expected           []protocol.Location
+	}{
+		{
+			name:               "entity_used_by_other_package",
+			file:               "greet/greet.neva",
+			cursor:             "Hello",
+			includeDeclaration: true,
+			expected: []protocol.Location{
+				location("greet/greet.neva", "Hello", len("Hello")),
+				location("main/main.neva", "Hello", len("Hello")),
+			},
+		},
+		{
+			name:   "entity_without_declaration",
+			file:   "main/main.neva",
+			cursor: "Hello",
+			expected: []protocol.Location{
+				location("main/main.neva", "Hello", len("Hello")),
+			},
+		},
+		{
+			name:               "node",
+			file:               "greet/greet.neva",
+			cursor:             "pass Pass",
+			includeDeclaration: true,
+			expected: []protocol.Location{
+				location("greet/greet.neva", "pass Pass", len("pass")),
+				location("greet/greet.neva", "pass ->", len("pass")),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.TextDocumentReferences(nil, &protocol.ReferenceParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: pathToURI(filepath.Join(modPath, tt.file))},
+					Position:     positionOf(t, files[tt.file], tt.cursor),
+				},
+				Context: protocol.ReferenceContext{IncludeDeclaration: tt.includeDeclaration},
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+// TextDocumentPrepareRename checks that symbol under the cursor can be renamed and returns its range.
+// It's not part of the protocol.Handler because glsp declares wrong result type for it.
+func (s *Server) TextDocumentPrepareRename(
+	glspCtx *glsp.Context,
+	params protocol.PrepareRenameParams,
+) (*protocol.RangeWithPlaceholder, error) {
+	index := s.currentIndex()
+	if index == nil {
+		return nil, nil
+	}
+
+	sources := s.sourceCache(*index)
+
+	occ, loc, ok := occurrenceAt(*index, sources, params.TextDocument.URI, params.Position)
+	if !ok {
+		return nil, nil
+	}
+
+	if err := checkRenameTarget(index.Parsed, sources, occ.target); err != nil {
+		return nil, err
+	}
+
+	location, ok := sources.location(loc, occ.start, occ.length)
+	if !ok {
+		return nil, nil
+	}
+
+	return &protocol.RangeWithPlaceholder{
+		Range:       location.Range,
+		Placeholder: occ.target.name(),
+	}, nil
+}
+
+// TextDocumentRename renames entity, node or port under the cursor
+// and updates every reference to it, including references from other packages.
+func (s *Server) TextDocumentRename(
+	glspCtx *glsp.Context,
+	params *protocol.RenameParams,
+) (*protocol.WorkspaceEdit, error) {
+	index := s.currentIndex()
+	if index == nil {
+		return nil, nil
+	}
+
+	sources := s.sourceCache(*index)
+
+	occ, _, ok := occurrenceAt(*index, sources, params.TextDocument.URI, params.Position)
+	if !ok {
+		return nil, nil
+	}
+	t := occ.target
+
+	if err := checkRenameTarget(index.Parsed, sources, t); err != nil {
+		return nil, err
+	}
+	if err := checkNewName(index.Parsed, t, params.NewName); err != nil {
+		return nil, err
+	}
+
+	oldName := t.name()
+	changes := map[protocol.DocumentUri][]protocol.TextEdit{}
+	seen := map[protocol.Location]struct{}{}
+
+	for _, occ := range targetOccurrences(index.Parsed, sources, t) {
+		// index is updated with a delay so it can be behind the text of the documents
+		if !sources.hasText(occ.loc, occ.start, oldName) {
+			return nil, errors.New("source code changed since last indexing, try again")
+		}
+
+		location, ok := sources.location(occ.loc, occ.start, occ.length)
+		if !ok {
+			continue
+		}
+		if _, ok := seen[location]; ok {
+			continue
+		}
+		seen[location] = struct{}{}
+
+		changes[location.URI] = append(changes[location.URI], protocol.TextEdit{
+			Range:   location.Range,
+			NewText: params.NewName,
+		})
+	}
+
+	return &protocol.WorkspaceEdit{Changes: changes}, nil
+}
+
+// name returns the name of the target as it's written in the source code.
+func (t target) name() string {
+	switch t.kind {
+	case packageTarget:
+		return t.pkg
+	case nodeTarget:
+		return t.node
+	case portTarget:
+		return t.port
+	default:
+		return t.entity
+	}
+}
+
+// checkRenameTarget returns error if the target can't be renamed.
+// Only entities of the entry module and their nodes and ports are renamed,
+// the rest of the modules are dependencies and must not be edited.
+func checkRenameTarget(build src.Build, sources *sourceCache, t target) error {
+	if t.kind == packageTarget {
+		return errors.New("packages can't be renamed, rename the directory instead")
+	}
+
+	if t.modRef != build.EntryModRef {
+		return fmt.Errorf("%v is defined in dependency module %v and can't be renamed", t.name(), t.modRef)
+	}
+
+	entity, loc, ok := lookupTargetEntity(build, t)
+	if !ok {
+		return fmt.Errorf("definition of %v not found", t.name())
+	}
+
+	if t.kind != nodeTarget {
+		return nil
+	}
+
+	// name of the anonymous node is derived from its entity and is not written in the source code
+	node, ok := entity.Component.Nodes[t.node]
+	if !ok {
+		return fmt.Errorf("definition of %v not found", t.node)
+	}
+	meta := node.Meta
+	meta.Location = loc
+	if _, ok := sources.findIdent(meta, t.node); !ok {
+		return fmt.Errorf("node %v is anonymous, give it a name first", t.node)
+	}
+
+	return nil
+}
+
+var identRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+var keywords = map[string]struct{}{
+	"_":         {},
+	"const":     {},
+	"def":       {},
+	"enum":      {},
+	"false":     {},
+	"import":    {},
+	"interface": {},
+	"pub":       {},
+	"struct":    {},
+	"switch":    {},
+	"true":      {},
+	"type":      {},
+}
+
+// checkNewName returns error if the target can't be given the new name
+// because it's not a valid identifier or it's already taken by something else.
+func checkNewName(build src.Build, t target, newName string) error {
+	if !identRegexp.MatchString(newName) {
+		return fmt.Errorf("%q is not a valid identifier", newName)
+	}
+	if _, ok := keywords[newName]; ok {
+		return fmt.Errorf("%q is a keyword", newName)
+	}
+	if newName == t.name() {
+		return nil
+	}
+
+	entity, _, ok := lookupTargetEntity(build, t)
+	if !ok {
+		return fmt.Errorf("definition of %v not found", t.name())
+	}
+
+	switch t.kind {
+	case entityTarget:
+		if _, _, ok := build.Modules[t.modRef].Packages[t.pkg].Entity(newName); ok {
+			return fmt.Errorf("%v is already defined in package %v", newName, t.pkg)
+		}
+	case nodeTarget:
+		if newName == "in" || newName == "out" {
+			return fmt.Errorf("%v is reserved for ports of the component", newName)
+		}
+		if _, ok := entity.Component.Nodes[newName]; ok {
+			return fmt.Errorf("node %v already exists in %v", newName, t.entity)
+		}
+	case portTarget:
+		iface := entity.Interface
+		if entity.Kind == src.ComponentEntity {
+			iface = entity.Component.Interface
+		}
+		ports, direction := iface.IO.In, "inport"
+		if t.outport {
+			ports, direction = iface.IO.Out, "outport"
+		}
+		if _, ok := ports[newName]; ok {
+			return fmt.Errorf("%v %v already exists in %v", direction, newName, t.entity)
+		}
+	}
+
+	return nil
+}
+
+// lookupTargetEntity returns entity that defines the target and location of the file it's defined in.
+func lookupTargetEntity(build src.Build, t target) (src.Entity, core.Location, bool) {
+	entity, fileName, ok := build.Modules[t.modRef].Packages[t.pkg].Entity(t.entity)
+	if !ok {
+		return src.Entity{}, core.Location{}, false
+	}
+	return entity, core.Location{
+		ModRef:   t.modRef,
+		Package:  t.pkg,
+		Filename: fileName,
+	}, true
+}
+
+// hasText reports whether text is written in the file at given position.
+func (s *sourceCache) hasText(loc core.Location, start core.Position, text string) bool {
+	lines, ok := s.fileLines(loc)
+	if !ok || start.Line < 1 || start.Line > len(lines) {
+		return false
+	}
+	line := lines[start.Line-1]
+	end := start.Column + len(text)
+	return start.Column >= 0 && end <= len(line) && line[start.Column:end] == text
+}
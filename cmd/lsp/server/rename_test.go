@@ -0,0 +1,254 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// renameFiles returns module where nodes are used in deferred, chained and switch connections
+// and the port of the component is addressed by the node from the other package.
+func renameFiles() map[string]string {
+	return map[string]string{
+		"main/main.neva": `import { @:greet }
+
+def Main(start any) (stop any) {
+	hello greet.Hello, first Pass, second Pass, third Pass, fourth Pass
+	---
+	:start -> { true -> first }
+	first -> second -> third
+	third -> switch {
+		true -> hello:data
+		_ -> fourth
+	}
+	[hello:res, fourth] -> :stop
+}
+
+def Pass(data bool) (res bool) {
+	:data -> :res
+}
+`,
+		"greet/greet.neva": `pub def Hello(data any) (res any) {
+	pass Pass
+	---
+	:data -> pass -> :res
+}
+
+def Pass(data any) (res any) {
+	:data -> :res
+}
+`,
+	}
+}
+
+func TestTextDocumentRename(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		cursor   string // cursor is at the start of the first occurrence
+		newName  string
+		expected map[string]string // changed files
+		err      string
+	}{
+		{
+			name:    "entity_used_by_other_package",
+			file:    "main/main.neva",
+			cursor:  "Hello",
+			newName: "Greet",
+			expected: map[string]string{
+				"main/main.neva": `import { @:greet }
+
+def Main(start any) (stop any) {
+	hello greet.Greet, first Pass, second Pass, third Pass, fourth Pass
+	---
+	:start -> { true -> first }
+	first -> second -> third
+	third -> switch {
+		true -> hello:data
+		_ -> fourth
+	}
+	[hello:res, fourth] -> :stop
+}
+
+def Pass(data bool) (res bool) {
+	:data -> :res
+}
+`,
+				"greet/greet.neva": `pub def Greet(data any) (res any) {
+	pass Pass
+	---
+	:data -> pass -> :res
+}
+
+def Pass(data any) (res any) {
+	:data -> :res
+}
+`,
+			},
+		},
+		{
+			name:    "node_in_deferred_connection",
+			file:    "main/main.neva",
+			cursor:  "first }",
+			newName: "one",
+			expected: map[string]string{
+				"main/main.neva": `import { @:greet }
+
+def Main(start any) (stop any) {
+	hello greet.Hello, one Pass, second Pass, third Pass, fourth Pass
+	---
+	:start -> { true -> one }
+	one -> second -> third
+	third -> switch {
+		true -> hello:data
+		_ -> fourth
+	}
+	[hello:res, fourth] -> :stop
+}
+
+def Pass(data bool) (res bool) {
+	:data -> :res
+}
+`,
+			},
+		},
+		{
+			name:    "node_in_chained_connection",
+			file:    "main/main.neva",
+			cursor:  "second ->",
+			newName: "two",
+			expected: map[string]string{
+				"main/main.neva": `import { @:greet }
+
+def Main(start any) (stop any) {
+	hello greet.Hello, first Pass, two Pass, third Pass, fourth Pass
+	---
+	:start -> { true -> first }
+	first -> two -> third
+	third -> switch {
+		true -> hello:data
+		_ -> fourth
+	}
+	[hello:res, fourth] -> :stop
+}
+
+def Pass(data bool) (res bool) {
+	:data -> :res
+}
+`,
+			},
+		},
+		{
+			name:    "node_in_switch_receivers",
+			file:    "main/main.neva",
+			cursor:  "hello:data",
+			newName: "greeter",
+			expected: map[string]string{
+				"main/main.neva": `import { @:greet }
+
+def Main(start any) (stop any) {
+	greeter greet.Hello, first Pass, second Pass, third Pass, fourth Pass
+	---
+	:start -> { true -> first }
+	first -> second -> third
+	third -> switch {
+		true -> greeter:data
+		_ -> fourth
+	}
+	[greeter:res, fourth] -> :stop
+}
+
+def Pass(data bool) (res bool) {
+	:data -> :res
+}
+`,
+			},
+		},
+		{
+			name:    "port_addressed_by_other_package",
+			file:    "greet/greet.neva",
+			cursor:  "data any",
+			newName: "name",
+			expected: map[string]string{
+				"main/main.neva": `import { @:greet }
+
+def Main(start any) (stop any) {
+	hello greet.Hello, first Pass, second Pass, third Pass, fourth Pass
+	---
+	:start -> { true -> first }
+	first -> second -> third
+	third -> switch {
+		true -> hello:name
+		_ -> fourth
+	}
+	[hello:res, fourth] -> :stop
+}
+
+def Pass(data bool) (res bool) {
+	:data -> :res
+}
+`,
+				"greet/greet.neva": `pub def Hello(name any) (res any) {
+	pass Pass
+	---
+	:name -> pass -> :res
+}
+
+def Pass(data any) (res any) {
+	:data -> :res
+}
+`,
+			},
+		},
+		{
+			name:    "name_is_taken",
+			file:    "greet/greet.neva",
+			cursor:  "Hello",
+			newName: "Pass",
+			err:     "Pass is already defined in package greet",
+		},
+		{
+			name:    "keyword",
+			file:    "greet/greet.neva",
+			cursor:  "pass Pass",
+			newName: "def",
+			err:     `"def" is a keyword`,
+		},
+		{
+			name:    "package",
+			file:    "main/main.neva",
+			cursor:  "greet.Hello",
+			newName: "hello",
+			err:     "packages can't be renamed, rename the directory instead",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renameFiles()
+			s, modPath, errs := newTestServer(t, renameFiles())
+			require.False(t, errs.HasErrors(), errs.Error())
+
+			edit, err := s.TextDocumentRename(nil, &protocol.RenameParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: pathToURI(filepath.Join(modPath, tt.file))},
+					Position:     positionOf(t, files[tt.file], tt.cursor),
+				},
+				NewName: tt.newName,
+			})
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Len(t, edit.Changes, len(tt.expected))
+			for file, expected := range tt.expected {
+				uri := pathToURI(filepath.Join(modPath, file))
+				require.Equal(t, expected, applyEdits(files[file], edit.Changes[uri]))
+			}
+		})
+	}
+}
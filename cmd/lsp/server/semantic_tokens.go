@@ -0,0 +1,453 @@
+package server
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	generated "github.com/nevalang/neva/internal/compiler/parser/generated"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+// Token types are encoded by their index in the legend.
+const (
+	tokenNamespace uint32 = iota
+	tokenType
+	tokenStruct
+	tokenEnum
+	tokenInterface
+	tokenTypeParameter
+	tokenParameter
+	tokenVariable
+	tokenProperty
+	tokenEnumMember
+	tokenFunction
+	tokenMacro
+	tokenOperator
+)
+
+// Token modifiers are encoded as bit flags in the order of the legend.
+const (
+	modifierDeclaration uint32 = 1 << iota
+	modifierReadonly
+	modifierDefaultLibrary
+)
+
+var semanticTokensLegend = protocol.SemanticTokensLegend{
+	TokenTypes: []string{
+		tokenNamespace:     string(protocol.SemanticTokenTypeNamespace),
+		tokenType:          string(protocol.SemanticTokenTypeType),
+		tokenStruct:        string(protocol.SemanticTokenTypeStruct),
+		tokenEnum:          string(protocol.SemanticTokenTypeEnum),
+		tokenInterface:     string(protocol.SemanticTokenTypeInterface),
+		tokenTypeParameter: string(protocol.SemanticTokenTypeTypeParameter),
+		tokenParameter:     string(protocol.SemanticTokenTypeParameter),
+		tokenVariable:      string(protocol.SemanticTokenTypeVariable),
+		tokenProperty:      string(protocol.SemanticTokenTypeProperty),
+		tokenEnumMember:    string(protocol.SemanticTokenTypeEnumMember),
+		tokenFunction:      string(protocol.SemanticTokenTypeFunction),
+		tokenMacro:         string(protocol.SemanticTokenTypeMacro),
+		tokenOperator:      string(protocol.SemanticTokenTypeOperator),
+	},
+	TokenModifiers: []string{
+		string(protocol.SemanticTokenModifierDeclaration),
+		string(protocol.SemanticTokenModifierReadonly),
+		string(protocol.SemanticTokenModifierDefaultLibrary),
+	},
+}
+
+// TextDocumentSemanticTokensFull returns semantic tokens of the whole document.
+func (s *Server) TextDocumentSemanticTokensFull(
+	glspCtx *glsp.Context,
+	params *protocol.SemanticTokensParams,
+) (*protocol.SemanticTokens, error) {
+	tokens := s.semanticTokens(params.TextDocument.URI)
+	return &protocol.SemanticTokens{Data: encodeSemanticTokens(tokens)}, nil
+}
+
+// TextDocumentSemanticTokensRange returns semantic tokens of the visible part of the document.
+func (s *Server) TextDocumentSemanticTokensRange(
+	glspCtx *glsp.Context,
+	params *protocol.SemanticTokensRangeParams,
+) (any, error) {
+	tokens := s.semanticTokens(params.TextDocument.URI)
+
+	inRange := make([]semanticToken, 0, len(tokens))
+	for _, token := range tokens {
+		if token.line >= params.Range.Start.Line && token.line <= params.Range.End.Line {
+			inRange = append(inRange, token)
+		}
+	}
+
+	return &protocol.SemanticTokens{Data: encodeSemanticTokens(inRange)}, nil
+}
+
+type semanticToken struct {
+	line, column, length uint32 // 0-based position of the token
+	typ, modifiers       uint32
+}
+
+// semanticTokens parses current text of the document and classifies its identifiers and operators.
+// References to entities are resolved with the index, so highlighting knows
+// whether `Foo` is a component, an interface, a type or a constant.
+// Syntax errors are ignored to keep highlighting of the rest of the document while user types.
+func (s *Server) semanticTokens(uri string) []semanticToken {
+	text, ok := s.documentText(uri)
+	if !ok {
+		return nil
+	}
+
+	l := &semanticTokensListener{
+		BasenevaListener: &generated.BasenevaListener{},
+		lines:            strings.Split(text, "\n"),
+		typeParams:       map[string]struct{}{},
+	}
+
+	if index := s.currentIndex(); index != nil {
+		if loc, ok := fileLocation(*index, uriToPath(uri)); ok {
+			scope := src.NewScope(index.Parsed, loc)
+			l.scope = &scope
+		}
+	}
+
+	lexer := generated.NewnevaLexer(antlr.NewInputStream(text))
+	lexer.RemoveErrorListeners()
+	prsr := generated.NewnevaParser(antlr.NewCommonTokenStream(lexer, 0))
+	prsr.RemoveErrorListeners()
+	prsr.BuildParseTrees = true
+
+	antlr.ParseTreeWalkerDefault.Walk(l, prsr.Prog())
+
+	sort.SliceStable(l.tokens, func(i, j int) bool {
+		if l.tokens[i].line != l.tokens[j].line {
+			return l.tokens[i].line < l.tokens[j].line
+		}
+		return l.tokens[i].column < l.tokens[j].column
+	})
+
+	return l.tokens
+}
+
+// encodeSemanticTokens encodes sorted tokens with positions relative to the previous token.
+func encodeSemanticTokens(tokens []semanticToken) []protocol.UInteger {
+	data := make([]protocol.UInteger, 0, len(tokens)*5)
+
+	var line, column uint32
+	for _, token := range tokens {
+		if token.line != line {
+			column = 0
+		}
+		data = append(data, token.line-line, token.column-column, token.length, token.typ, token.modifiers)
+		line, column = token.line, token.column
+	}
+
+	return data
+}
+
+type semanticTokensListener struct {
+	*generated.BasenevaListener
+
+	lines      []string            // lines of the document to convert columns to UTF-16 units
+	scope      *src.Scope          // nil if the document is not indexed
+	typeParams map[string]struct{} // type parameters of the current entity
+	tokens     []semanticToken
+}
+
+func (l *semanticTokensListener) add(node antlr.TerminalNode, typ, modifiers uint32) {
+	if node == nil {
+		return
+	}
+	l.addSpan(node.GetSymbol(), node.GetSymbol(), typ, modifiers)
+}
+
+// addSpan adds token from the start of the first symbol to the end of the last one, both must be on the same line.
+func (l *semanticTokensListener) addSpan(first, last antlr.Token, typ, modifiers uint32) {
+	// tokens inserted by error recovery are not written in the source code
+	if first == nil || last == nil || first.GetTokenIndex() < 0 || last.GetTokenIndex() < 0 {
+		return
+	}
+	if first.GetLine() != last.GetLine() {
+		return
+	}
+
+	// columns of antlr are in runes while LSP counts UTF-16 code units
+	line := first.GetLine() - 1
+	if line >= len(l.lines) {
+		return
+	}
+	runes := []rune(l.lines[line])
+	start := first.GetColumn()
+	end := last.GetColumn() + len([]rune(last.GetText()))
+	if start > end || end > len(runes) {
+		return
+	}
+
+	l.tokens = append(l.tokens, semanticToken{
+		line:      uint32(line),
+		column:    uint32(len(utf16.Encode(runes[:start]))),
+		length:    uint32(len(utf16.Encode(runes[start:end]))),
+		typ:       typ,
+		modifiers: modifiers,
+	})
+}
+
+// addTerminals adds direct children of the rule that are written as one of the given texts.
+func (l *semanticTokensListener) addTerminals(ctx antlr.ParserRuleContext, typ uint32, texts ...string) {
+	for _, child := range ctx.GetChildren() {
+		terminal, ok := child.(antlr.TerminalNode)
+		if !ok {
+			continue
+		}
+		for _, text := range texts {
+			if terminal.GetText() == text {
+				l.add(terminal, typ, 0)
+			}
+		}
+	}
+}
+
+func (l *semanticTokensListener) EnterStmt(ctx *generated.StmtContext) {
+	l.typeParams = map[string]struct{}{}
+}
+
+// Imports
+
+func (l *semanticTokensListener) EnterImportAlias(ctx *generated.ImportAliasContext) {
+	l.add(ctx.IDENTIFIER(), tokenNamespace, 0)
+}
+
+func (l *semanticTokensListener) EnterImportPathPkg(ctx *generated.ImportPathPkgContext) {
+	for _, ident := range ctx.AllIDENTIFIER() {
+		l.add(ident, tokenNamespace, 0)
+	}
+}
+
+// Entities
+
+func (l *semanticTokensListener) EnterTypeDef(ctx *generated.TypeDefContext) {
+	typ := tokenType
+	if expr := ctx.TypeExpr(); expr != nil && expr.TypeLitExpr() != nil {
+		if expr.TypeLitExpr().EnumTypeExpr() != nil {
+			typ = tokenEnum
+		} else {
+			typ = tokenStruct
+		}
+	}
+	l.add(ctx.IDENTIFIER(), typ, modifierDeclaration)
+}
+
+func (l *semanticTokensListener) EnterInterfaceDef(ctx *generated.InterfaceDefContext) {
+	typ := tokenInterface
+	if _, ok := ctx.GetParent().(*generated.CompDefContext); ok {
+		typ = tokenFunction
+	}
+	l.add(ctx.IDENTIFIER(), typ, modifierDeclaration)
+}
+
+func (l *semanticTokensListener) EnterConstDef(ctx *generated.ConstDefContext) {
+	l.add(ctx.IDENTIFIER(), tokenVariable, modifierDeclaration|modifierReadonly)
+}
+
+func (l *semanticTokensListener) EnterTypeParam(ctx *generated.TypeParamContext) {
+	if ident := ctx.IDENTIFIER(); ident != nil {
+		l.typeParams[ident.GetText()] = struct{}{}
+		l.add(ident, tokenTypeParameter, modifierDeclaration)
+	}
+}
+
+func (l *semanticTokensListener) EnterSinglePortDef(ctx *generated.SinglePortDefContext) {
+	l.add(ctx.IDENTIFIER(), tokenParameter, modifierDeclaration)
+}
+
+func (l *semanticTokensListener) EnterArrayPortDef(ctx *generated.ArrayPortDefContext) {
+	l.add(ctx.IDENTIFIER(), tokenParameter, modifierDeclaration)
+}
+
+// Types and constant values
+
+func (l *semanticTokensListener) EnterStructField(ctx *generated.StructFieldContext) {
+	l.add(ctx.IDENTIFIER(), tokenProperty, modifierDeclaration)
+}
+
+func (l *semanticTokensListener) EnterEnumTypeExpr(ctx *generated.EnumTypeExprContext) {
+	for _, ident := range ctx.AllIDENTIFIER() {
+		l.add(ident, tokenEnumMember, modifierDeclaration)
+	}
+}
+
+func (l *semanticTokensListener) EnterEnumLit(ctx *generated.EnumLitContext) {
+	l.add(ctx.IDENTIFIER(), tokenEnumMember, 0)
+}
+
+func (l *semanticTokensListener) EnterStructValueField(ctx *generated.StructValueFieldContext) {
+	l.add(ctx.IDENTIFIER(), tokenProperty, 0)
+}
+
+// Entity references
+
+func (l *semanticTokensListener) EnterEntityRef(ctx *generated.EntityRefContext) {
+	var (
+		ref   core.EntityRef
+		ident antlr.TerminalNode
+	)
+
+	if imported := ctx.ImportedEntityRef(); imported != nil {
+		if imported.PkgRef() == nil || imported.EntityName() == nil {
+			return
+		}
+		l.add(imported.PkgRef().IDENTIFIER(), tokenNamespace, 0)
+		ref.Pkg = imported.PkgRef().GetText()
+		ident = imported.EntityName().IDENTIFIER()
+	} else if local := ctx.LocalEntityRef(); local != nil {
+		ident = local.IDENTIFIER()
+	}
+
+	if ident == nil {
+		return
+	}
+	ref.Name = ident.GetText()
+
+	_, isTypeExpr := ctx.GetParent().(*generated.TypeInstExprContext)
+	if _, ok := l.typeParams[ref.Name]; ok && ref.Pkg == "" && isTypeExpr {
+		l.add(ident, tokenTypeParameter, 0)
+		return
+	}
+
+	if typ, modifiers, ok := l.resolve(ref); ok {
+		l.add(ident, typ, modifiers)
+		return
+	}
+
+	// reference can't be resolved while the index is outdated, so the kind is guessed by where it's used
+	switch ctx.GetParent().(type) {
+	case *generated.TypeInstExprContext:
+		l.add(ident, tokenType, 0)
+	case *generated.NodeInstContext:
+		l.add(ident, tokenFunction, 0)
+	case *generated.EnumLitContext:
+		l.add(ident, tokenEnum, 0)
+	case *generated.SenderConstRefContext, *generated.CompositeItemContext, *generated.ConstDefContext:
+		l.add(ident, tokenVariable, modifierReadonly)
+	}
+}
+
+// resolve returns token type and modifiers of the referenced entity.
+func (l *semanticTokensListener) resolve(ref core.EntityRef) (uint32, uint32, bool) {
+	if l.scope == nil {
+		return 0, 0, false
+	}
+
+	entity, loc, err := l.scope.Entity(ref)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	var modifiers uint32
+	if loc.ModRef.Path == "std" {
+		modifiers |= modifierDefaultLibrary
+	}
+
+	switch entity.Kind {
+	case src.TypeEntity:
+		if body := entity.Type.BodyExpr; body != nil && body.Lit != nil {
+			if body.Lit.Enum != nil {
+				return tokenEnum, modifiers, true
+			}
+			return tokenStruct, modifiers, true
+		}
+		return tokenType, modifiers, true
+	case src.ConstEntity:
+		return tokenVariable, modifiers | modifierReadonly, true
+	case src.InterfaceEntity:
+		return tokenInterface, modifiers, true
+	default:
+		return tokenFunction, modifiers, true
+	}
+}
+
+// Compiler directives
+
+func (l *semanticTokensListener) EnterCompilerDirective(ctx *generated.CompilerDirectiveContext) {
+	ident := ctx.IDENTIFIER()
+	if ident == nil {
+		return
+	}
+	l.addSpan(ctx.GetStart(), ident.GetSymbol(), tokenMacro, 0)
+
+	args := ctx.CompilerDirectivesArgs()
+	if args == nil {
+		return
+	}
+
+	// only some arguments are references to entities, the rest are names of runtime functions
+	for _, arg := range args.AllCompiler_directive_arg() {
+		idents := arg.AllIDENTIFIER()
+		switch {
+		case ident.GetText() == "bind":
+			// `#bind(const)`
+		case ident.GetText() == "extern" && len(idents) == 2:
+			// `#extern(type func)`
+			idents = idents[:1]
+		default:
+			continue
+		}
+		for _, argIdent := range idents {
+			if typ, modifiers, ok := l.resolve(core.EntityRef{Name: argIdent.GetText()}); ok {
+				l.add(argIdent, typ, modifiers)
+			}
+		}
+	}
+}
+
+// Networks
+
+func (l *semanticTokensListener) EnterCompNodeDef(ctx *generated.CompNodeDefContext) {
+	l.add(ctx.IDENTIFIER(), tokenVariable, modifierDeclaration)
+}
+
+func (l *semanticTokensListener) EnterPortAddrNode(ctx *generated.PortAddrNodeContext) {
+	l.add(ctx.IDENTIFIER(), tokenVariable, 0)
+}
+
+func (l *semanticTokensListener) EnterPortAddrPort(ctx *generated.PortAddrPortContext) {
+	l.add(ctx.IDENTIFIER(), tokenParameter, 0)
+}
+
+func (l *semanticTokensListener) EnterStructSelectors(ctx *generated.StructSelectorsContext) {
+	for _, ident := range ctx.AllIDENTIFIER() {
+		l.add(ident, tokenProperty, 0)
+	}
+}
+
+func (l *semanticTokensListener) EnterNormConnDef(ctx *generated.NormConnDefContext) {
+	l.addTerminals(ctx, tokenOperator, "->")
+}
+
+func (l *semanticTokensListener) EnterArrBypassConnDef(ctx *generated.ArrBypassConnDefContext) {
+	l.addTerminals(ctx, tokenOperator, "=>")
+}
+
+func (l *semanticTokensListener) EnterDefaultCase(ctx *generated.DefaultCaseContext) {
+	l.addTerminals(ctx, tokenOperator, "->")
+}
+
+func (l *semanticTokensListener) EnterErrGuard(ctx *generated.ErrGuardContext) {
+	l.addTerminals(ctx, tokenOperator, "?")
+}
+
+func (l *semanticTokensListener) EnterUnaryOp(ctx *generated.UnaryOpContext) {
+	l.addSpan(ctx.GetStart(), ctx.GetStop(), tokenOperator, 0)
+}
+
+func (l *semanticTokensListener) EnterBinaryOp(ctx *generated.BinaryOpContext) {
+	l.addSpan(ctx.GetStart(), ctx.GetStop(), tokenOperator, 0)
+}
+
+func (l *semanticTokensListener) EnterTernaryExpr(ctx *generated.TernaryExprContext) {
+	l.addTerminals(ctx, tokenOperator, "?", ":")
+}
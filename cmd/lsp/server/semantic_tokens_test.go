@@ -0,0 +1,129 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestSemanticTokens(t *testing.T) {
+	source := `import { fmt }
+
+type Id int
+type User struct { name string }
+type Color enum { Red, Green }
+const greeting string = '😀'
+const colors list<Color> = [Color::Red]
+
+interface IPrinter<T>(data T) (res T)
+
+#extern(println)
+def Print<T>(data T) (res T)
+
+def Main(start any) (stop any) {
+	println fmt.Println<User>
+	---
+	:start -> { '😀' -> println:data }
+	println:res -> .name -> :stop
+	println:err -> panic
+}
+`
+
+	// tokens are computed from the text so the document doesn't have to pass analysis
+	s, modPath, _ := newTestServer(t, map[string]string{"main/main.neva": source})
+	uri := pathToURI(filepath.Join(modPath, "main", "main.neva"))
+
+	// tokens are rendered as `text type modifiers...`
+	lines := strings.Split(source, "\n")
+	var actual []string
+	for _, token := range s.semanticTokens(uri) {
+		line := utf16.Encode([]rune(lines[token.line]))
+		rendered := string(utf16.Decode(line[token.column:token.column+token.length])) +
+			" " + semanticTokensLegend.TokenTypes[token.typ]
+		for i, modifier := range semanticTokensLegend.TokenModifiers {
+			if token.modifiers&(1<<i) != 0 {
+				rendered += " " + modifier
+			}
+		}
+		actual = append(actual, rendered)
+	}
+
+	// tokens after the emoji are found, although it takes two UTF-16 code units
+	require.Equal(t, []string{
+		"fmt namespace",
+		"Id type declaration",
+		"int type defaultLibrary",
+		"User struct declaration",
+		"name property declaration",
+		"string type defaultLibrary",
+		"Color enum declaration",
+		"Red enumMember declaration",
+		"Green enumMember declaration",
+		"greeting variable declaration readonly",
+		"string type defaultLibrary",
+		"colors variable declaration readonly",
+		"list type defaultLibrary",
+		"Color enum",
+		"Color enum",
+		"Red enumMember",
+		"IPrinter interface declaration",
+		"T typeParameter declaration",
+		"data parameter declaration",
+		"T typeParameter",
+		"res parameter declaration",
+		"T typeParameter",
+		"#extern macro",
+		"Print function declaration",
+		"T typeParameter declaration",
+		"data parameter declaration",
+		"T typeParameter",
+		"res parameter declaration",
+		"T typeParameter",
+		"Main function declaration",
+		"start parameter declaration",
+		"any type defaultLibrary",
+		"stop parameter declaration",
+		"any type defaultLibrary",
+		"println variable declaration",
+		"fmt namespace",
+		"Println function defaultLibrary",
+		"User struct",
+		"start parameter",
+		"-> operator",
+		"-> operator",
+		"println variable",
+		"data parameter",
+		"println variable",
+		"res parameter",
+		"-> operator",
+		"name property",
+		"-> operator",
+		"stop parameter",
+		"println variable",
+		"err parameter",
+		"-> operator",
+		"panic variable",
+	}, actual)
+}
+
+func TestEncodeSemanticTokens(t *testing.T) {
+	tokens := []semanticToken{
+		{line: 0, column: 4, length: 3, typ: tokenFunction},
+		{line: 0, column: 10, length: 2, typ: tokenVariable, modifiers: modifierDeclaration},
+		{line: 2, column: 1, length: 5, typ: tokenParameter},
+		{line: 2, column: 8, length: 1, typ: tokenOperator},
+		{line: 5, column: 0, length: 4, typ: tokenType, modifiers: modifierDefaultLibrary},
+	}
+
+	require.Equal(t, []protocol.UInteger{
+		0, 4, 3, tokenFunction, 0,
+		0, 6, 2, tokenVariable, modifierDeclaration,
+		2, 1, 5, tokenParameter, 0,
+		0, 7, 1, tokenOperator, 0,
+		3, 0, 4, tokenType, modifierDefaultLibrary,
+	}, encodeSemanticTokens(tokens))
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"sync"
 	"time"
@@ -12,80 +13,230 @@ import (
 
 	"github.com/nevalang/neva/cmd/lsp/indexer"
 	"github.com/nevalang/neva/internal/compiler"
-	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
 )
 
 type Server struct {
 	workspacePath string
 	name, version string
 
-	handler *Handler
-	logger  commonlog.Logger
-	indexer indexer.Indexer
+	handler  *Handler
+	logger   commonlog.Logger
+	indexer  indexer.Indexer
+	resolver ts.Resolver
 
 	indexMutex *sync.Mutex
-	index      *src.Build
+	index      *indexer.Index
 
 	problemsMutex *sync.Mutex
 	problemFiles  map[string]struct{} // we only need to store file urls but not their problems
 
 	activeFile      string
 	activeFileMutex *sync.Mutex
+
+	documentsMutex *sync.Mutex
+	documents      map[string]string   // text of opened documents by their uri
+	unsaved        map[string]struct{} // documents which text differs from what's on disk
+	pending        map[string]struct{} // documents changed since the last indexing
+
+	indexingMutex *sync.Mutex // full scans and updates must not overlap
+	updateTimer   *time.Timer
+
+	refreshSemanticTokens glsp.CallFunc // nil if client doesn't support refresh of semantic tokens
 }
 
+// updateDelay is how long server waits for the user to stop typing before updating the index.
+const updateDelay = 300 * time.Millisecond
+
 // indexAndNotifyProblems does full scan of the workspace
-// and sends diagnostics if there are any problems
+// and sends diagnostics if there are any problems.
+// Unsaved changes of opened documents are applied on top of what's on disk.
 func (s *Server) indexAndNotifyProblems(notify glsp.NotifyFunc) error {
-	build, found, compilerErr := s.indexer.FullScan(
+	s.indexingMutex.Lock()
+	defer s.indexingMutex.Unlock()
+
+	index, found, compilerErrs := s.indexer.FullScan(
 		context.Background(),
 		s.workspacePath,
 	)
 	if !found {
+		if len(compilerErrs) != 0 {
+			s.notifyProblems(notify, compilerErrs, nil)
+		}
 		return nil
 	}
 
-	s.indexMutex.Lock()
-	s.index = &build
-	s.indexMutex.Unlock()
+	s.documentsMutex.Lock()
+	s.pending = make(map[string]struct{})
+	files := s.documentsContent(s.unsaved)
+	s.documentsMutex.Unlock()
+
+	if len(files) != 0 {
+		index, compilerErrs = s.indexer.Update(index, files)
+	}
+
+	s.setIndexAndNotifyProblems(notify, index, compilerErrs)
+
+	return nil
+}
+
+// scheduleUpdate updates the index when there are no changes during updateDelay.
+func (s *Server) scheduleUpdate(notify glsp.NotifyFunc) {
+	s.documentsMutex.Lock()
+	defer s.documentsMutex.Unlock()
+
+	if s.updateTimer != nil {
+		s.updateTimer.Stop()
+	}
 
-	if compilerErr == nil {
-		// clear problems
-		s.problemsMutex.Lock()
-		for uri := range s.problemFiles {
-			notify(
-				protocol.ServerTextDocumentPublishDiagnostics,
-				protocol.PublishDiagnosticsParams{
-					URI:         uri,
-					Diagnostics: []protocol.Diagnostic{},
-				},
-			)
+	s.updateTimer = time.AfterFunc(updateDelay, func() {
+		if err := s.updateAndNotifyProblems(notify); err != nil {
+			s.logger.Error("update index", "err", err)
 		}
-		s.problemFiles = make(map[string]struct{})
-		s.logger.Info("full index without problems, sent empty diagnostics")
-		s.problemsMutex.Unlock()
+	})
+}
+
+// updateAndNotifyProblems re-indexes documents changed since the last indexing
+// and sends diagnostics if there are any problems.
+func (s *Server) updateAndNotifyProblems(notify glsp.NotifyFunc) error {
+	index := s.currentIndex()
+	if index == nil {
+		return s.indexAndNotifyProblems(notify)
+	}
+
+	s.indexingMutex.Lock()
+	defer s.indexingMutex.Unlock()
+
+	s.documentsMutex.Lock()
+	files := s.documentsContent(s.pending)
+	s.pending = make(map[string]struct{})
+	s.documentsMutex.Unlock()
+
+	if len(files) == 0 {
 		return nil
 	}
 
-	// remember problem and send diagnostic
-	s.problemsMutex.Lock()
-	uri := filepath.Join(s.workspacePath, compilerErr.Meta.Location.String()) // we assume compilerErr is deepest child (for now)
-	s.problemFiles[uri] = struct{}{}
-	notify(
-		protocol.ServerTextDocumentPublishDiagnostics,
-		s.createDiagnostics(*compilerErr, uri),
-	)
-	s.logger.Info("diagnostic sent:", "err", compilerErr)
-	s.problemsMutex.Unlock()
+	// index could be replaced by full scan while we were waiting for the lock
+	index = s.currentIndex()
+	updated, compilerErrs := s.indexer.Update(*index, files)
+
+	s.setIndexAndNotifyProblems(notify, updated, compilerErrs)
 
 	return nil
 }
 
-func (s *Server) createDiagnostics(
-	compilerErr compiler.Error, // deepest child (for now) compiler error
-	uri string,
-) protocol.PublishDiagnosticsParams {
-	var startStopRange protocol.Range
+// documentsContent returns content of the given .neva documents by their paths on disk.
+// Documents that are not opened anymore are read from disk.
+// Caller must hold documentsMutex.
+func (s *Server) documentsContent(uris map[string]struct{}) map[string][]byte {
+	files := make(map[string][]byte, len(uris))
+	for uri := range uris {
+		path := uriToPath(uri)
+		if filepath.Ext(path) != ".neva" {
+			continue
+		}
+		if text, ok := s.documents[uri]; ok {
+			files[path] = []byte(text)
+			continue
+		}
+		bb, err := os.ReadFile(path)
+		if err != nil {
+			s.logger.Error("read document", "path", path, "err", err)
+			continue
+		}
+		files[path] = bb
+	}
+	return files
+}
+
+func (s *Server) setIndexAndNotifyProblems(
+	notify glsp.NotifyFunc,
+	index indexer.Index,
+	compilerErrs compiler.Errors,
+) {
+	s.indexMutex.Lock()
+	s.index = &index
+	s.indexMutex.Unlock()
+
+	s.notifyProblems(notify, compilerErrs, index.ModulePaths)
+
+	// references in opened documents may resolve to other entities now
+	if s.refreshSemanticTokens != nil {
+		// client answers the request only after the current message is handled
+		go s.refreshSemanticTokens(protocol.MethodWorkspaceSemanticTokensRefresh, nil, nil)
+	}
+}
+
+// notifyProblems sends diagnostics for every file with problems
+// and clears diagnostics of the files that don't have problems anymore.
+func (s *Server) notifyProblems(
+	notify glsp.NotifyFunc,
+	compilerErrs compiler.Errors,
+	modulePaths map[core.ModuleRef]string,
+) {
+	problems := make(map[string][]protocol.Diagnostic)
+	for _, compilerErr := range compilerErrs {
+		deepest := compilerErr.Unwrap()
+		uri := s.problemURI(deepest, modulePaths)
+		problems[uri] = append(problems[uri], s.createDiagnostic(*deepest))
+	}
+
+	s.problemsMutex.Lock()
+	defer s.problemsMutex.Unlock()
+
+	// clear problems that are gone
+	for uri := range s.problemFiles {
+		if _, ok := problems[uri]; ok {
+			continue
+		}
+		notify(
+			protocol.ServerTextDocumentPublishDiagnostics,
+			protocol.PublishDiagnosticsParams{
+				URI:         uri,
+				Diagnostics: []protocol.Diagnostic{},
+			},
+		)
+	}
+
+	// remember problems and send diagnostics
+	s.problemFiles = make(map[string]struct{}, len(problems))
+	for uri, diagnostics := range problems {
+		s.problemFiles[uri] = struct{}{}
+		notify(
+			protocol.ServerTextDocumentPublishDiagnostics,
+			protocol.PublishDiagnosticsParams{
+				URI:         uri,
+				Diagnostics: diagnostics,
+			},
+		)
+		s.logger.Info("diagnostics sent:", "uri", uri, "count", len(diagnostics))
+	}
+}
+
+// problemURI returns uri of the file where problem is.
+// Problems of modules and packages, that are not related to specific file, are reported in the manifest.
+func (s *Server) problemURI(compilerErr *compiler.Error, modulePaths map[core.ModuleRef]string) string {
+	var loc core.Location
 	if compilerErr.Meta != nil {
+		loc = compilerErr.Meta.Location
+	}
+
+	modPath, ok := modulePaths[loc.ModRef]
+	if !ok {
+		modPath = s.workspacePath
+	}
+
+	if loc.Filename == "" {
+		return pathToURI(filepath.Join(modPath, "neva.yml"))
+	}
+
+	return pathToURI(filepath.Join(modPath, loc.Package, loc.Filename+".neva"))
+}
+
+func (s *Server) createDiagnostic(compilerErr compiler.Error) protocol.Diagnostic {
+	var startStopRange protocol.Range
+	if compilerErr.Meta != nil && compilerErr.Meta.Start.Line > 0 {
 		// If stop is 0 0, set it to the same as start but with character incremented by 1
 		if compilerErr.Meta.Stop.Line == 0 && compilerErr.Meta.Stop.Column == 0 {
 			compilerErr.Meta.Stop = compilerErr.Meta.Start
@@ -108,16 +259,22 @@ func (s *Server) createDiagnostics(
 		startStopRange.End.Line--
 	}
 
-	return protocol.PublishDiagnosticsParams{
-		URI: uri, // uri must be full path to the file, make sure all compiler errors include full location
-		Diagnostics: []protocol.Diagnostic{
-			{
-				Range:    startStopRange,
-				Severity: compiler.Pointer(protocol.DiagnosticSeverityError),
-				Source:   compiler.Pointer("compiler"),
-				Message:  compilerErr.Message, // we don't use Error() because it will duplicate location
-				Data:     time.Now(),
-			},
-		},
+	severity := protocol.DiagnosticSeverityError
+	if compilerErr.Severity == compiler.SeverityWarning {
+		severity = protocol.DiagnosticSeverityWarning
+	}
+
+	var code *protocol.IntegerOrString
+	if compilerErr.Code != "" {
+		code = &protocol.IntegerOrString{Value: string(compilerErr.Code)}
+	}
+
+	return protocol.Diagnostic{
+		Range:    startStopRange,
+		Severity: &severity,
+		Code:     code,
+		Source:   compiler.Pointer("compiler"),
+		Message:  compilerErr.Message, // we don't use Error() because it will duplicate location
+		Data:     time.Now(),
 	}
 }
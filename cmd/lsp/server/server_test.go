@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/commonlog"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/nevalang/neva/cmd/lsp/indexer"
+	"github.com/nevalang/neva/internal/builder"
+	"github.com/nevalang/neva/internal/compiler"
+	"github.com/nevalang/neva/internal/compiler/analyzer"
+	"github.com/nevalang/neva/internal/compiler/parser"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+	"github.com/nevalang/neva/pkg"
+)
+
+// newTestServer indexes module made of given files and returns server that uses the index,
+// path of the module and problems found in it. Paths of the files are relative to the module.
+func newTestServer(t *testing.T, files map[string]string) (*Server, string, compiler.Errors) {
+	t.Helper()
+
+	// builder writes stdlib to the home directory
+	t.Setenv("HOME", t.TempDir())
+
+	modPath := t.TempDir()
+	files["neva.yml"] = "neva: " + pkg.Version
+	for name, content := range files {
+		path := filepath.Join(modPath, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	p := parser.New()
+	terminator := ts.Terminator{}
+	checker := ts.MustNewSubtypeChecker(terminator)
+	resolver := ts.MustNewResolver(ts.Validator{}, checker, terminator)
+	idx := indexer.New(builder.MustNew(p), p, analyzer.MustNew(resolver), commonlog.GetLogger("test"))
+
+	index, found, errs := idx.FullScan(context.Background(), modPath)
+	require.True(t, found)
+
+	s := &Server{
+		workspacePath:   modPath,
+		logger:          commonlog.GetLogger("test"),
+		indexer:         idx,
+		resolver:        resolver,
+		indexMutex:      &sync.Mutex{},
+		index:           &index,
+		problemsMutex:   &sync.Mutex{},
+		problemFiles:    map[string]struct{}{},
+		activeFileMutex: &sync.Mutex{},
+		documentsMutex:  &sync.Mutex{},
+		documents:       map[string]string{},
+		unsaved:         map[string]struct{}{},
+		pending:         map[string]struct{}{},
+		indexingMutex:   &sync.Mutex{},
+	}
+
+	return s, modPath, errs
+}
+
+// applyEdits returns text with given non-overlapping edits applied.
+func applyEdits(text string, edits []protocol.TextEdit) string {
+	edits = append([]protocol.TextEdit(nil), edits...)
+	sort.Slice(edits, func(i, j int) bool {
+		a, b := edits[i].Range.Start, edits[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line > b.Line
+		}
+		return a.Character > b.Character
+	})
+
+	lines := strings.SplitAfter(text, "\n")
+	offset := func(pos protocol.Position) int {
+		n := 0
+		for _, line := range lines[:pos.Line] {
+			n += len(line)
+		}
+		return n + int(pos.Character)
+	}
+
+	for _, edit := range edits {
+		start, end := offset(edit.Range.Start), offset(edit.Range.End)
+		text = text[:start] + edit.NewText + text[end:]
+	}
+
+	return text
+}
+
+// navigationFiles returns module with two packages, where main package uses entity of the other one.
+func navigationFiles() map[string]string {
+	return map[string]string{
+		"main/main.neva": `import { @:greet }
+
+def Main(start any) (stop any) {
+	hello greet.Hello
+	---
+	:start -> hello -> :stop
+}
+`,
+		"greet/greet.neva": `pub def Hello(data any) (res any) {
+	pass Pass
+	---
+	:data -> pass -> :res
+}
+
+def Pass(data any) (res any) {
+	:data -> :res
+}
+`,
+	}
+}
+
+// positionOf returns position of the first occurrence of substr in the text.
+func positionOf(t *testing.T, text, substr string) protocol.Position {
+	t.Helper()
+	i := strings.Index(text, substr)
+	require.NotEqual(t, -1, i, substr)
+	line := strings.Count(text[:i], "\n")
+	return protocol.Position{
+		Line:      uint32(line),
+		Character: uint32(i - strings.LastIndex(text[:i], "\n") - 1),
+	}
+}
+
+// diagnosticsRecorder returns notify function that records published diagnostics by uri.
+func diagnosticsRecorder() (glsp.NotifyFunc, chan protocol.PublishDiagnosticsParams) {
+	published := make(chan protocol.PublishDiagnosticsParams, 100)
+	notify := func(method string, params any) {
+		if method == protocol.ServerTextDocumentPublishDiagnostics {
+			published <- params.(protocol.PublishDiagnosticsParams)
+		}
+	}
+	return notify, published
+}
+
+func TestScheduleUpdate(t *testing.T) {
+	s, modPath, errs := newTestServer(t, navigationFiles())
+	require.False(t, errs.HasErrors(), errs.Error())
+	notify, published := diagnosticsRecorder()
+	greetURI := pathToURI(filepath.Join(modPath, "greet", "greet.neva"))
+	mainURI := pathToURI(filepath.Join(modPath, "main", "main.neva"))
+
+	require.NoError(t, s.TextDocumentDidOpen(&glsp.Context{Notify: notify}, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: greetURI, Text: navigationFiles()["greet/greet.neva"]},
+	}))
+
+	// every change postpones the update so only the last one is indexed
+	for _, text := range []string{"pub def Hello(", "pub def Greet(data any) (res any) {\n\t:data -> :res\n}\n"} {
+		require.NoError(t, s.TextDocumentDidChange(&glsp.Context{Notify: notify}, &protocol.DidChangeTextDocumentParams{
+			TextDocument:   protocol.VersionedTextDocumentIdentifier{TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: greetURI}},
+			ContentChanges: []any{protocol.TextDocumentContentChangeEventWhole{Text: text}},
+		}))
+	}
+
+	select {
+	case params := <-published:
+		require.Equal(t, mainURI, params.URI)
+		require.Len(t, params.Diagnostics, 1)
+		require.Equal(t, compiler.ErrorCodeEntityNotFound, diagnosticCode(params.Diagnostics[0]))
+	case <-time.After(10 * time.Second):
+		t.Fatal("index is not updated")
+	}
+
+	select {
+	case params := <-published:
+		t.Fatalf("unexpected diagnostics: %v", params)
+	case <-time.After(3 * updateDelay):
+	}
+
+	index := s.currentIndex()
+	require.Nil(t, index.Analyzed)
+	_, ok := index.Parsed.Modules[index.Parsed.EntryModRef].Packages["greet"]["greet"].Entities["Greet"]
+	require.True(t, ok)
+}
+
+func TestUpdateAndNotifyProblems(t *testing.T) {
+	s, modPath, errs := newTestServer(t, navigationFiles())
+	require.False(t, errs.HasErrors(), errs.Error())
+	notify, published := diagnosticsRecorder()
+	greetURI := pathToURI(filepath.Join(modPath, "greet", "greet.neva"))
+	mainURI := pathToURI(filepath.Join(modPath, "main", "main.neva"))
+
+	// nothing has changed
+	index := s.currentIndex()
+	require.NoError(t, s.updateAndNotifyProblems(notify))
+	require.Same(t, index, s.currentIndex())
+	require.Empty(t, published)
+
+	// unsaved change breaks the package that depends on the changed one
+	s.documents[greetURI] = "pub def Greet(data any) (res any) {\n\t:data -> :res\n}\n"
+	s.unsaved[greetURI] = struct{}{}
+	s.pending[greetURI] = struct{}{}
+	require.NoError(t, s.updateAndNotifyProblems(notify))
+	require.Len(t, published, 1)
+	params := <-published
+	require.Equal(t, mainURI, params.URI)
+	require.Len(t, params.Diagnostics, 1)
+	require.Nil(t, s.currentIndex().Analyzed)
+	require.Empty(t, s.pending)
+
+	// closing document discards its changes and clears the problems
+	require.NoError(t, s.TextDocumentDidClose(&glsp.Context{Notify: notify}, &protocol.DidCloseTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: greetURI},
+	}))
+	s.updateTimer.Stop()
+	require.NoError(t, s.updateAndNotifyProblems(notify))
+	require.Len(t, published, 1)
+	params = <-published
+	require.Equal(t, mainURI, params.URI)
+	require.Empty(t, params.Diagnostics)
+	require.NotNil(t, s.currentIndex().Analyzed)
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+// TextDocumentDocumentSymbol returns outline of the file: its entities and nodes of its components.
+func (s *Server) TextDocumentDocumentSymbol(
+	glspCtx *glsp.Context,
+	params *protocol.DocumentSymbolParams,
+) (any, error) {
+	symbols := []protocol.DocumentSymbol{}
+
+	index := s.currentIndex()
+	if index == nil {
+		return symbols, nil
+	}
+
+	loc, ok := fileLocation(*index, uriToPath(params.TextDocument.URI))
+	if !ok {
+		return symbols, nil
+	}
+
+	sources := s.sourceCache(*index)
+	file := index.Parsed.Modules[loc.ModRef].Packages[loc.Package][loc.Filename]
+
+	for name, entity := range file.Entities {
+		meta := *entity.Meta()
+		meta.Location = loc
+
+		rng, selection := symbolRanges(sources, meta, meta.Start, name)
+		symbol := protocol.DocumentSymbol{
+			Name:           name,
+			Kind:           symbolKind(entity),
+			Range:          rng,
+			SelectionRange: selection,
+		}
+
+		if entity.Kind == src.ComponentEntity {
+			symbol.Children = nodeSymbols(sources, loc, entity.Component)
+		}
+
+		symbols = append(symbols, symbol)
+	}
+
+	sortDocumentSymbols(symbols)
+
+	return symbols, nil
+}
+
+func nodeSymbols(sources *sourceCache, loc core.Location, comp src.Component) []protocol.DocumentSymbol {
+	symbols := make([]protocol.DocumentSymbol, 0, len(comp.Nodes))
+
+	for name, node := range comp.Nodes {
+		meta := node.Meta
+		meta.Location = loc
+
+		// name of the anonymous node is not written in the source code
+		namePos, ok := sources.findIdent(meta, name)
+		if !ok {
+			namePos = meta.Start
+		}
+
+		detail := node.EntityRef.String() + formatTypeArgs(node.TypeArgs, "")
+		rng, selection := symbolRanges(sources, meta, namePos, name)
+
+		symbols = append(symbols, protocol.DocumentSymbol{
+			Name:           name,
+			Detail:         &detail,
+			Kind:           protocol.SymbolKindVariable,
+			Range:          rng,
+			SelectionRange: selection,
+		})
+	}
+
+	sortDocumentSymbols(symbols)
+
+	return symbols
+}
+
+func sortDocumentSymbols(symbols []protocol.DocumentSymbol) {
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := symbols[i].Range.Start, symbols[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Character < b.Character
+	})
+}
+
+// WorkspaceSymbol returns entities of all the modules of the build which names match the query.
+func (s *Server) WorkspaceSymbol(
+	glspCtx *glsp.Context,
+	params *protocol.WorkspaceSymbolParams,
+) ([]protocol.SymbolInformation, error) {
+	symbols := []protocol.SymbolInformation{}
+
+	index := s.currentIndex()
+	if index == nil {
+		return symbols, nil
+	}
+
+	sources := s.sourceCache(*index)
+
+	for modRef, mod := range index.Parsed.Modules {
+		for pkgName, pkg := range mod.Packages {
+			container := pkgName
+			if modRef != index.Parsed.EntryModRef {
+				container = modRef.Path + ":" + pkgName
+			}
+
+			for result := range pkg.Entities() {
+				if !matchesSymbolQuery(result.EntityName, params.Query) {
+					continue
+				}
+
+				loc := core.Location{
+					ModRef:   modRef,
+					Package:  pkgName,
+					Filename: result.FileName,
+				}
+				path, ok := sources.path(loc)
+				if !ok {
+					continue
+				}
+
+				meta := *result.Entity.Meta()
+				meta.Location = loc
+				rng, _ := symbolRanges(sources, meta, meta.Start, result.EntityName)
+				symbols = append(symbols, protocol.SymbolInformation{
+					Name: result.EntityName,
+					Kind: symbolKind(result.Entity),
+					Location: protocol.Location{
+						URI:   pathToURI(path),
+						Range: rng,
+					},
+					ContainerName: &container,
+				})
+			}
+		}
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].Name != symbols[j].Name {
+			return symbols[i].Name < symbols[j].Name
+		}
+		return *symbols[i].ContainerName < *symbols[j].ContainerName
+	})
+
+	return symbols, nil
+}
+
+// matchesSymbolQuery reports whether query characters appear in the name in the same order, ignoring case.
+// This way `prln` matches `Println`.
+func matchesSymbolQuery(name, query string) bool {
+	name = strings.ToLower(name)
+	for _, r := range strings.ToLower(query) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		i := strings.IndexRune(name, r)
+		if i == -1 {
+			return false
+		}
+		name = name[i+1:]
+	}
+	return true
+}
+
+func symbolKind(entity src.Entity) protocol.SymbolKind {
+	switch entity.Kind {
+	case src.TypeEntity:
+		if body := entity.Type.BodyExpr; body != nil && body.Lit != nil && body.Lit.Enum != nil {
+			return protocol.SymbolKindEnum
+		}
+		return protocol.SymbolKindStruct
+	case src.ConstEntity:
+		return protocol.SymbolKindConstant
+	case src.InterfaceEntity:
+		return protocol.SymbolKindInterface
+	default:
+		return protocol.SymbolKindFunction
+	}
+}
+
+// symbolRanges returns range of the whole definition and range of its name.
+// Location of the meta must be set to find where the definition ends in the source code.
+func symbolRanges(
+	sources *sourceCache,
+	meta core.Meta,
+	namePos core.Position,
+	name string,
+) (protocol.Range, protocol.Range) {
+	selection := protocol.Range{
+		Start: protocol.Position{
+			Line:      uint32(namePos.Line - 1),
+			Character: uint32(namePos.Column),
+		},
+		End: protocol.Position{
+			Line:      uint32(namePos.Line - 1),
+			Character: uint32(namePos.Column + len(name)),
+		},
+	}
+
+	rng := protocol.Range{
+		Start: protocol.Position{
+			Line:      uint32(meta.Start.Line - 1),
+			Character: uint32(meta.Start.Column),
+		},
+		End: sources.definitionEnd(meta.Location, meta.Stop),
+	}
+
+	if positionLess(selection.Start, rng.Start) {
+		rng.Start = selection.Start
+	}
+	if positionLess(rng.End, selection.End) {
+		rng.End = selection.End
+	}
+
+	return rng, selection
+}
+
+// definitionEnd returns position right after the last character of the definition.
+// Stop of the meta points to the start of the last token, which can be a line break
+// after the definition, so trailing whitespace and empty lines are skipped.
+func (s *sourceCache) definitionEnd(loc core.Location, stop core.Position) protocol.Position {
+	lines, ok := s.fileLines(loc)
+	if !ok {
+		return protocol.Position{Line: uint32(stop.Line - 1), Character: uint32(stop.Column + 1)}
+	}
+
+	column := stop.Column + 1
+	for line := stop.Line - 1; line >= 0 && line < len(lines); line-- {
+		runes := []rune(strings.TrimRightFunc(lines[line], unicode.IsSpace))
+		column = min(column, len(runes))
+		if column > 0 {
+			return protocol.Position{
+				Line:      uint32(line),
+				Character: uint32(len(utf16.Encode(runes[:column]))),
+			}
+		}
+		column = math.MaxInt // previous line ends where its text ends
+	}
+
+	return protocol.Position{Line: uint32(stop.Line - 1), Character: uint32(stop.Column + 1)}
+}
+
+func positionLess(a, b protocol.Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Character < b.Character
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const symbolsSource = `import { fmt }
+
+type User struct {
+	name string
+}
+
+type Color enum { Red, Green }
+
+const greeting string = 'hello'
+
+interface IPrinter(data any) (res any)
+
+def Main(start any) (stop any) {
+	println fmt.Println<string>
+	pass Pass
+	panic Panic
+	---
+	:start -> { $greeting -> println }
+	println:res -> pass -> :stop
+	println:err -> panic
+}
+
+def Pass(data any) (res any) {
+	:data -> :res
+}
+`
+
+func TestTextDocumentDocumentSymbol(t *testing.T) {
+	s, modPath, errs := newTestServer(t, map[string]string{"main/main.neva": symbolsSource})
+	require.False(t, errs.HasErrors(), errs.Error())
+
+	resp, err := s.TextDocumentDocumentSymbol(nil, &protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: pathToURI(filepath.Join(modPath, "main", "main.neva"))},
+	})
+	require.NoError(t, err)
+	symbols := resp.([]protocol.DocumentSymbol)
+
+	type symbol struct {
+		name     string
+		kind     protocol.SymbolKind
+		children []string
+	}
+	actual := make([]symbol, 0, len(symbols))
+	for _, sym := range symbols {
+		children := []string{}
+		for _, child := range sym.Children {
+			require.Equal(t, protocol.SymbolKindVariable, child.Kind)
+			children = append(children, child.Name+" "+*child.Detail)
+		}
+		actual = append(actual, symbol{sym.Name, sym.Kind, children})
+	}
+	require.Equal(t, []symbol{
+		{"User", protocol.SymbolKindStruct, []string{}},
+		{"Color", protocol.SymbolKindEnum, []string{}},
+		{"greeting", protocol.SymbolKindConstant, []string{}},
+		{"IPrinter", protocol.SymbolKindInterface, []string{}},
+		{"Main", protocol.SymbolKindFunction, []string{"println fmt.Println<string>", "pass Pass", "panic Panic"}},
+		{"Pass", protocol.SymbolKindFunction, []string{}},
+	}, actual)
+
+	// names are selected
+	main := symbols[4]
+	require.Equal(t, positionOf(t, symbolsSource, "Main"), main.SelectionRange.Start)
+	require.Equal(t, positionOf(t, symbolsSource, "println fmt"), main.Children[0].SelectionRange.Start)
+	require.Equal(t, uint32(len("println")), main.Children[0].SelectionRange.End.Character-main.Children[0].SelectionRange.Start.Character)
+
+	// component ends with the closing brace
+	require.Equal(t, positionOf(t, symbolsSource, "Main"), main.Range.Start)
+	end := positionOf(t, symbolsSource, "}\n\ndef Pass")
+	end.Character++
+	require.Equal(t, end, main.Range.End)
+
+	// ranges don't go past the end of the line
+	lines := strings.Split(symbolsSource, "\n")
+	for _, sym := range symbols {
+		for _, rng := range []protocol.Range{sym.Range, sym.SelectionRange} {
+			require.LessOrEqual(t, int(rng.End.Character), len(lines[rng.End.Line]), sym.Name)
+		}
+	}
+}
+
+func TestWorkspaceSymbol(t *testing.T) {
+	s, _, errs := newTestServer(t, map[string]string{"main/main.neva": symbolsSource})
+	require.False(t, errs.HasErrors(), errs.Error())
+
+	tests := []struct {
+		query    string
+		expected []string // `name kind container`
+	}{
+		{
+			query:    "greeting",
+			expected: []string{"greeting 14 main"},
+		},
+		{
+			query:    "IPRINTER",
+			expected: []string{"IPrinter 11 main"},
+		},
+		{
+			query:    "prln",
+			expected: []string{"Println 12 std:fmt"},
+		},
+		{
+			query:    "pass",
+			expected: []string{"Pass 12 main", "Pass 12 std:builtin"},
+		},
+		{
+			query:    "clr",
+			expected: []string{"Accumulator 12 std:builtin", "Color 10 main"},
+		},
+		{
+			query:    "nonexistent",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			symbols, err := s.WorkspaceSymbol(nil, &protocol.WorkspaceSymbolParams{Query: tt.query})
+			require.NoError(t, err)
+
+			actual := []string{}
+			for _, sym := range symbols {
+				actual = append(actual, fmt.Sprintf("%v %v %v", sym.Name, sym.Kind, *sym.ContainerName))
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"os"
+
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
@@ -12,6 +14,11 @@ func (s *Server) TextDocumentDidOpen(
 	s.activeFileMutex.Lock()
 	s.activeFile = params.TextDocument.URI
 	s.activeFileMutex.Unlock()
+
+	s.documentsMutex.Lock()
+	s.documents[params.TextDocument.URI] = params.TextDocument.Text
+	s.documentsMutex.Unlock()
+
 	return nil
 }
 
@@ -22,6 +29,48 @@ func (s *Server) TextDocumentDidChange(
 	s.activeFileMutex.Lock()
 	s.activeFile = params.TextDocument.URI
 	s.activeFileMutex.Unlock()
+
+	s.documentsMutex.Lock()
+
+	text := s.documents[params.TextDocument.URI]
+	for _, change := range params.ContentChanges {
+		switch change := change.(type) {
+		case protocol.TextDocumentContentChangeEvent:
+			start, end := change.Range.IndexesIn(text)
+			text = text[:start] + change.Text + text[end:]
+		case protocol.TextDocumentContentChangeEventWhole:
+			text = change.Text
+		}
+	}
+	s.documents[params.TextDocument.URI] = text
+	s.unsaved[params.TextDocument.URI] = struct{}{}
+	s.pending[params.TextDocument.URI] = struct{}{}
+
+	s.documentsMutex.Unlock()
+
+	s.scheduleUpdate(glspCtx.Notify)
+
+	return nil
+}
+
+func (s *Server) TextDocumentDidClose(
+	glspCtx *glsp.Context,
+	params *protocol.DidCloseTextDocumentParams,
+) error {
+	s.documentsMutex.Lock()
+	delete(s.documents, params.TextDocument.URI)
+	_, unsaved := s.unsaved[params.TextDocument.URI]
+	if unsaved {
+		// unsaved changes are discarded so the index must be reverted to what's on disk
+		delete(s.unsaved, params.TextDocument.URI)
+		s.pending[params.TextDocument.URI] = struct{}{}
+	}
+	s.documentsMutex.Unlock()
+
+	if unsaved {
+		s.scheduleUpdate(glspCtx.Notify)
+	}
+
 	return nil
 }
 
@@ -30,5 +79,28 @@ func (s *Server) TextDocumentDidSave(
 	params *protocol.DidSaveTextDocumentParams,
 ) error {
 	s.logger.Info("TextDocumentDidSave")
+
+	s.documentsMutex.Lock()
+	delete(s.unsaved, params.TextDocument.URI)
+	s.documentsMutex.Unlock()
+
 	return s.indexAndNotifyProblems(glspCtx.Notify)
 }
+
+// documentText returns text of the document as it's seen in the editor,
+// which can be different from what's on disk if document has unsaved changes.
+func (s *Server) documentText(uri string) (string, bool) {
+	s.documentsMutex.Lock()
+	text, ok := s.documents[uri]
+	s.documentsMutex.Unlock()
+	if ok {
+		return text, true
+	}
+
+	bb, err := os.ReadFile(uriToPath(uri))
+	if err != nil {
+		return "", false
+	}
+
+	return string(bb), true
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+)
+
+// resolveNodePort returns port of the node's interface and its type with node's type arguments substituted.
+// Scope must point to the file where node is defined
+// and frame must contain type parameters of the component that node belongs to.
+func (s *Server) resolveNodePort(
+	scope src.Scope,
+	frame map[string]ts.Def,
+	node src.Node,
+	portName string,
+	isInport bool,
+) (src.Port, ts.Expr, bool) {
+	entity, loc, err := scope.Entity(node.EntityRef)
+	if err != nil {
+		return src.Port{}, ts.Expr{}, false
+	}
+
+	iface := entity.Interface
+	if entity.Kind == src.ComponentEntity {
+		iface = entity.Component.Interface
+	}
+
+	ports := iface.IO.Out
+	if isInport {
+		ports = iface.IO.In
+	}
+
+	port, ok := ports[portName]
+	if !ok {
+		return src.Port{}, ts.Expr{}, false
+	}
+
+	nodeFrame, ok := s.resolveNodeFrame(scope, frame, iface.TypeParams.Params, node.TypeArgs)
+	if !ok {
+		return src.Port{}, ts.Expr{}, false
+	}
+
+	resolved, err := s.resolver.ResolveExprWithFrame(port.TypeExpr, nodeFrame, scope.Relocate(loc))
+	if err != nil {
+		return src.Port{}, ts.Expr{}, false
+	}
+
+	return port, resolved, true
+}
+
+// resolveNodeFrame maps type parameters of the node's entity to resolved type arguments of the node.
+// Parameters without arguments are mapped to their constraints.
+func (s *Server) resolveNodeFrame(
+	scope src.Scope,
+	frame map[string]ts.Def,
+	params []ts.Param,
+	args []ts.Expr,
+) (map[string]ts.Def, bool) {
+	result := make(map[string]ts.Def, len(params))
+	for i, param := range params {
+		arg := param.Constr
+		if i < len(args) {
+			resolved, err := s.resolver.ResolveExprWithFrame(args[i], frame, scope)
+			if err != nil {
+				return nil, false
+			}
+			arg = resolved
+		}
+		result[param.Name] = ts.Def{
+			BodyExpr: &arg,
+			Meta:     arg.Meta,
+		}
+	}
+	return result, true
+}
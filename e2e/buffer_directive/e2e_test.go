@@ -0,0 +1,28 @@
+package test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test(t *testing.T) {
+	cmd := exec.Command("neva", "run", "main")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, "{}\n", string(out))
+
+	// buffer is applied to the channel of the node's inport
+	output := t.TempDir()
+	cmd = exec.Command("neva", "build", "--target", "go", "--output", output, "main")
+	out, err = cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	mainGo, err := os.ReadFile(filepath.Join(output, "main.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(mainGo), "in_start_to_print_in_data = make(chan runtime.OrderedMsg, 4)")
+	require.Contains(t, string(mainGo), "print_out_res_to_out_stop = make(chan runtime.OrderedMsg)\n")
+}
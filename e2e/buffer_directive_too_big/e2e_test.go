@@ -0,0 +1,16 @@
+package test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test(t *testing.T) {
+	cmd := exec.Command("neva", "build", "--target", "go", "--output", t.TempDir(), "main")
+	out, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	require.Contains(t, string(out), "main/main.neva:6:1: Buffer size must not exceed 65536, got 65537")
+	require.Equal(t, 1, cmd.ProcessState.ExitCode())
+}
@@ -0,0 +1,23 @@
+package test
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test(t *testing.T) {
+	t.Cleanup(func() { os.Remove("bytes.bin") })
+
+	cmd := exec.Command("neva", "run", "main")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, "Hello, bytes!\n", string(out))
+	require.Equal(t, 0, cmd.ProcessState.ExitCode())
+
+	data, err := os.ReadFile("bytes.bin")
+	require.NoError(t, err)
+	require.Equal(t, []byte("Hello, bytes!"), data)
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Program blocked on reading stdin must exit after drain timeout when it gets a signal.
+func Test(t *testing.T) {
+	output := t.TempDir()
+	build := exec.Command("neva", "build", "--drain-timeout", "200ms", "--output", output, "main")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	cmd := exec.Command(filepath.Join(output, "output"))
+	stdin, err := cmd.StdinPipe() // kept open so program stays blocked
+	require.NoError(t, err)
+	defer stdin.Close()
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	require.NoError(t, cmd.Start())
+
+	// program is started when it asks for input
+	prompt := make([]byte, len("Enter the name: "))
+	_, err = bufio.NewReader(stdout).Read(prompt)
+	require.NoError(t, err)
+	require.Equal(t, "Enter the name: ", string(prompt))
+
+	require.NoError(t, cmd.Process.Signal(syscall.SIGTERM))
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	select {
+	case err := <-exited:
+		require.Error(t, err)
+		require.Equal(t, 1, cmd.ProcessState.ExitCode())
+		require.Equal(t, "runtime error: program didn't finish in time after shutdown signal\n", stderr.String())
+	case <-time.After(5 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("program didn't exit after drain timeout")
+	}
+}
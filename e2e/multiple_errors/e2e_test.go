@@ -0,0 +1,20 @@
+package test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test(t *testing.T) {
+	cmd := exec.Command("neva", "run", "main")
+	out, _ := cmd.CombinedOutput()
+	require.Equal(t, 1, cmd.ProcessState.ExitCode())
+	require.Equal(
+		t,
+		"main/main.neva:6:11: Port not found 'println:dat'\n"+
+			"main/main.neva:11:7: Referenced inport not found in component's interface: z\n",
+		string(out),
+	)
+}
@@ -0,0 +1,16 @@
+package test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test(t *testing.T) {
+	cmd := exec.Command("neva", "run", "main")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, "мир\n[2,3]\nslice bounds out of range [2:5] with length 3\n", string(out))
+	require.Equal(t, 0, cmd.ProcessState.ExitCode())
+}
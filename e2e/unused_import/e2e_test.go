@@ -0,0 +1,20 @@
+package test
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("neva", "run", "main")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	require.NoError(t, cmd.Run())
+	require.Equal(t, "42\n", stdout.String())
+	require.Equal(t, "warning: main/main.neva:3:1: Unused import: strings\n", stderr.String())
+}
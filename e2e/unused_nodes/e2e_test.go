@@ -0,0 +1,20 @@
+package test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test(t *testing.T) {
+	cmd := exec.Command("neva", "run", "main")
+	out, _ := cmd.CombinedOutput()
+	require.Equal(t, 1, cmd.ProcessState.ExitCode())
+	require.Equal(
+		t,
+		"main/main.neva:2:10: Unused node found: p2\n"+
+			"main/main.neva:2:19: Unused node found: p3\n",
+		string(out),
+	)
+}
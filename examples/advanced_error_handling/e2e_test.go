@@ -3,6 +3,7 @@ package test
 import (
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -19,14 +20,17 @@ func Test(t *testing.T) {
 	for i := 0; i < 1; i++ {
 		cmd := exec.Command("neva", "run", "advanced_error_handling")
 		out, err := cmd.CombinedOutput()
-		require.NoError(t, err)
-		require.Equal(
+		require.Error(t, err)
+		require.True(
 			t,
-			`panic: {"text": "Get \"definitely%20not%20a%20valid%20URL\":  unsupported protocol scheme \"\""}
+			strings.HasPrefix(
+				string(out),
+				`panic: {"text": "Get \"definitely%20not%20a%20valid%20URL\":  unsupported protocol scheme \"\""}
 `,
+			),
 			string(out),
 		)
 
-		require.Equal(t, 0, cmd.ProcessState.ExitCode())
+		require.Equal(t, 1, cmd.ProcessState.ExitCode())
 	}
 }
@@ -37,6 +37,8 @@ func Test(t *testing.T) {
 	cmd = exec.Command("neva", "run", "switch")
 	cmd.Stdin = strings.NewReader("Charlie\n")
 	out, err = cmd.CombinedOutput()
-	require.NoError(t, err)
-	require.Equal(t, "Enter the name: panic: Charlie\n", string(out))
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(string(out), "Enter the name: panic: Charlie\n"), string(out))
+	require.Contains(t, string(out), "panicked at: panic1\n")
+	require.Equal(t, 1, cmd.ProcessState.ExitCode())
 }
@@ -28,6 +28,9 @@ func Test(t *testing.T) {
 	// Test panic case with "Bob"
 	cmd = exec.Command("neva", "run", "switch_fan_out")
 	cmd.Stdin = strings.NewReader("Bob\n")
-	out, _ = cmd.CombinedOutput()
-	require.Equal(t, "Enter the name: panic: Bob\n", string(out))
+	out, err = cmd.CombinedOutput()
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(string(out), "Enter the name: panic: Bob\n"), string(out))
+	require.Contains(t, string(out), "panicked at: panic1\n")
+	require.Equal(t, 1, cmd.ProcessState.ExitCode())
 }
@@ -2,6 +2,7 @@ package builder
 
 import (
 	"context"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -17,6 +18,10 @@ type Builder struct {
 	manifestParser ManifestParser
 	thirdPartyPath string
 	stdLibPath     string
+	// offline builder only uses vendored and already downloaded dependencies.
+	offline bool
+	// sources are looked up for dependencies that are not git URLs, before downloading them over https.
+	sources []source
 }
 
 type ManifestParser interface {
@@ -42,11 +47,6 @@ func (b Builder) Build(
 	}
 	entryMod.Manifest.Deps["std"] = stdModRef
 
-	// inject entry mod into the build
-	mods := map[core.ModuleRef]compiler.RawModule{
-		{Path: "@"}: entryMod,
-	}
-
 	// load stdlib module
 	stdMod, _, err := b.LoadModuleByPath(ctx, b.stdLibPath)
 	if err != nil {
@@ -55,9 +55,6 @@ func (b Builder) Build(
 		}
 	}
 
-	// inject stdlib module to build
-	mods[stdModRef] = stdMod
-
 	release, err := acquireLockFile()
 	if err != nil {
 		return compiler.RawBuild{}, "", &compiler.Error{
@@ -66,44 +63,111 @@ func (b Builder) Build(
 	}
 	defer release()
 
-	q := newQueue(entryMod.Manifest.Deps)
-
-	for !q.empty() {
-		depModRef := q.dequeue()
+	entryModRef := core.ModuleRef{Path: "@"}
 
-		if _, ok := mods[depModRef]; ok {
-			continue
+	resolved, err := b.resolveDeps(ctx, entryModRef, entryMod, entryModRootPath)
+	if err != nil {
+		return compiler.RawBuild{}, "", &compiler.Error{
+			Message: err.Error(),
 		}
+	}
 
-		depWD, _, err := b.downloadDep(depModRef)
-		if err != nil {
-			return compiler.RawBuild{}, "", &compiler.Error{
-				Message: "download dep: " + err.Error(),
-			}
+	mods := make(map[core.ModuleRef]compiler.RawModule, len(resolved.mods)+1)
+	for depModRef, depMod := range resolved.mods {
+		if depModRef != entryModRef {
+			// inject stdlib dep into every downloaded dep mod
+			depMod.Manifest.Deps["std"] = stdModRef
 		}
+		mods[depModRef] = depMod
+	}
 
-		depMod, _, err := b.LoadModuleByPath(ctx, depWD)
-		if err != nil {
-			return compiler.RawBuild{}, "", &compiler.Error{
-				Message: "build dep mod: " + err.Error(),
-			}
-		}
+	// inject stdlib module to build
+	mods[stdModRef] = stdMod
+
+	return compiler.RawBuild{
+		EntryModRef: entryModRef,
+		Modules:     mods,
+	}, entryModRootPath, nil
+}
+
+// resolveDeps selects versions of the dependencies of the entry module,
+// verifies them against its lock file and updates the lock file.
+// Caller must hold the lock of the dependencies directory.
+func (b Builder) resolveDeps(
+	ctx context.Context,
+	entryModRef core.ModuleRef,
+	entryMod compiler.RawModule,
+	entryModRootPath string,
+) (resolution, error) {
+	lock, err := readLockfile(entryModRootPath)
+	if err != nil {
+		return resolution{}, fmt.Errorf("read lock file: %w", err)
+	}
+
+	resolved, err := newResolver(
+		ctx, b, lock, entryModRootPath, entryMod.Manifest.Replace,
+	).resolve(entryModRef, entryMod)
+	if err != nil {
+		return resolution{}, fmt.Errorf("resolve deps: %w", err)
+	}
+
+	// lock file is rewritten so it only contains dependencies of this build
+	newLock := lockfile{}
 
-		// inject stdlib dep into every downloaded dep mod
-		depMod.Manifest.Deps["std"] = core.ModuleRef{
-			Path:    "std",
-			Version: pkg.Version,
+	for depModRef := range resolved.dirs {
+		// local replacements are expected to change, so they are not locked
+		if resolved.local[depModRef] {
+			continue
+		}
+		if err := verifyAndLockDep(lock, &newLock, depModRef, resolved.dirs[depModRef]); err != nil {
+			return resolution{}, fmt.Errorf("verify dep: %w", err)
 		}
+	}
 
-		mods[depModRef] = depMod
+	if err := newLock.write(entryModRootPath); err != nil {
+		return resolution{}, fmt.Errorf("write lock file: %w", err)
+	}
 
-		q.enqueue(depMod.Manifest.Deps)
+	return resolved, nil
+}
+
+// ModulePath returns path where source code of the std or dependency module is stored on disk.
+// Entry module is not supported because its location depends on where the build was started.
+func (b Builder) ModulePath(ref core.ModuleRef) string {
+	if ref.Path == "std" {
+		return b.stdLibPath
 	}
+	return fmt.Sprintf("%s/%s_%s", b.thirdPartyPath, moduleDirName(ref.Path), ref.Version)
+}
 
-	return compiler.RawBuild{
-		EntryModRef: core.ModuleRef{Path: "@"},
-		Modules:     mods,
-	}, entryModRootPath, nil
+// DepPath returns path where source code of the dependency is stored on disk.
+// Unlike ModulePath it takes vendored dependencies and replacements
+// from the manifest of the entry module into account.
+func (b Builder) DepPath(
+	entryModRootPath string,
+	replace map[string]src.ModuleReplacement,
+	ref core.ModuleRef,
+) string {
+	repl, ok := replace[ref.Path]
+	if ok && repl.Dir != "" {
+		if filepath.IsAbs(repl.Dir) {
+			return repl.Dir
+		}
+		return filepath.Join(entryModRootPath, repl.Dir)
+	}
+	if vendored := vendorPath(entryModRootPath, ref); isDir(vendored) {
+		return vendored
+	}
+	if ok {
+		return b.ModulePath(replacementRef(repl))
+	}
+	return b.ModulePath(ref)
+}
+
+// Offline returns copy of the builder that fails instead of accessing the network.
+func (b Builder) Offline() Builder {
+	b.offline = true
+	return b
 }
 
 func getThirdPartyPath() (string, error) {
@@ -195,6 +259,7 @@ func New(parser ManifestParser) (Builder, error) {
 		manifestParser: parser,
 		stdLibPath:     stdlibPath,
 		thirdPartyPath: thirdParty,
+		sources:        sourcesFromEnv(),
 	}, nil
 }
 
@@ -1,49 +1,95 @@
 package builder
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
 )
 
+// Get downloads the dependency and adds it to the manifest of the module.
+// Version can be a plain version or a range, in which case the lowest matching version is downloaded.
+// Existing dependency of the same line is replaced, other lines are added under separate aliases.
 func (b Builder) Get(wd, path, version string) (string, error) {
-	ref := core.ModuleRef{
-		Path:    path,
-		Version: version,
-	}
-
 	release, err := acquireLockFile()
 	if err != nil {
 		return "", fmt.Errorf("failed to acquire lock file: %w", err)
 	}
 	defer release()
 
-	downloadPath, actualVersion, err := b.downloadDep(ref)
+	manifest, manifestPath, err := b.getNearestManifest(wd)
+	if err != nil {
+		return "", fmt.Errorf("Retrieve manifest: %w", err)
+	}
+
+	lock, err := readLockfile(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("read lock file: %w", err)
+	}
+
+	r := newResolver(context.Background(), b, lock, manifestPath, manifest.Replace)
+
+	constraint, err := r.constraint(core.ModuleRef{Path: path, Version: version})
 	if err != nil {
 		return "", err
 	}
 
-	manifest, _, err := b.getNearestManifest(wd)
+	line := moduleLine{path: path, line: constraint.line()}
+	r.requirements[line] = []requirement{{constraint: constraint}}
+
+	ref, err := r.selectVersion(line)
 	if err != nil {
-		return "", fmt.Errorf("Retrieve manifest: %w", err)
+		return "", err
 	}
 
-	existing, ok := manifest.Deps[path]
-	if ok && existing.Version != actualVersion {
-		return "", errors.New(
-			"Several versions of the same dependency not yet supported.",
-		)
+	downloadPath, err := r.download(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if version == "" {
+		version = ref.Version
 	}
 
-	manifest.Deps[path] = core.ModuleRef{
+	manifest.Deps[aliasForLine(manifest.Deps, line)] = core.ModuleRef{
 		Path:    path,
-		Version: actualVersion,
+		Version: version,
+	}
+
+	if manifest.Replace[path].Dir == "" {
+		if err := verifyAndLockDep(lock, &lock, ref, downloadPath); err != nil {
+			return "", err
+		}
 	}
 
-	if err := b.writeManifest(manifest, wd); err != nil {
+	if err := b.writeManifest(manifest, manifestPath); err != nil {
 		return "", err
 	}
 
+	if err := lock.write(manifestPath); err != nil {
+		return "", fmt.Errorf("write lock file: %w", err)
+	}
+
 	return downloadPath, nil
 }
+
+// aliasForLine returns alias of the existing dependency of the same line or a new one.
+// New alias is the module path, or the path with the line if the path is already taken.
+func aliasForLine(deps map[string]core.ModuleRef, line moduleLine) string {
+	for alias, dep := range deps {
+		if dep.Path != line.path {
+			continue
+		}
+		constraint, err := parseConstraint(dep.Version)
+		if err == nil && constraint.line() == line.line {
+			return alias
+		}
+	}
+
+	if _, ok := deps[line.path]; !ok {
+		return line.path
+	}
+
+	return line.path + "@" + strings.ReplaceAll(line.line, ".", "-")
+}
@@ -1,24 +1,24 @@
 package builder
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
 )
 
 // downloadDep returns path where it downloaded dependency
 // and its downloaded version in case version wasn't specified.
 func (p Builder) downloadDep(depModRef core.ModuleRef) (string, string, error) {
-	fsPath := fmt.Sprintf(
-		"%s/%s_%s",
-		p.thirdPartyPath,
-		depModRef.Path,
-		depModRef.Version,
-	)
+	fsPath := p.ModulePath(depModRef)
 
 	_, err := os.Stat(fsPath)
 	if err == nil {
@@ -29,13 +29,23 @@ func (p Builder) downloadDep(depModRef core.ModuleRef) (string, string, error) {
 		return "", "", fmt.Errorf("os stat: %w", err)
 	}
 
+	if p.offline {
+		return "", "", fmt.Errorf("%v is not downloaded, can't download it in offline mode", depModRef)
+	}
+
 	var ref plumbing.ReferenceName
 	if depModRef.Version != "" {
 		ref = plumbing.NewTagReferenceName(depModRef.Version)
 	}
 
+	url, auth, err := p.repository(depModRef.Path)
+	if err != nil {
+		return "", "", err
+	}
+
 	repo, err := git.PlainClone(fsPath, false, &git.CloneOptions{
-		URL:           "https://" + depModRef.Path,
+		URL:           url,
+		Auth:          auth,
 		ReferenceName: ref,
 	})
 	if err != nil {
@@ -43,7 +53,7 @@ func (p Builder) downloadDep(depModRef core.ModuleRef) (string, string, error) {
 	}
 
 	if ref != "" {
-		return fsPath, "", nil
+		return fsPath, depModRef.Version, nil
 	}
 
 	latestTagHash, tagName, err := getLatestTagHash(repo)
@@ -80,6 +90,8 @@ func (p Builder) downloadDep(depModRef core.ModuleRef) (string, string, error) {
 	return newFsPath, tagName, nil
 }
 
+// getLatestTagHash returns commit and name of the tag with the highest version.
+// Tags that are not versions are ignored.
 func getLatestTagHash(repository *git.Repository) (plumbing.Hash, string, error) {
 	tagRefs, err := repository.Tags()
 	if err != nil {
@@ -87,17 +99,95 @@ func getLatestTagHash(repository *git.Repository) (plumbing.Hash, string, error)
 	}
 
 	var (
-		hash plumbing.Hash
-		name string
+		hash   plumbing.Hash
+		name   string
+		latest *semver.Version
 	)
 	err = tagRefs.ForEach(func(tagRef *plumbing.Reference) error {
-		hash = tagRef.Hash()
-		name = tagRef.Name().String()
+		v, err := semver.NewVersion(tagRef.Name().Short())
+		if err != nil {
+			return nil
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+			hash = tagRef.Hash()
+			name = tagRef.Name().Short()
+		}
 		return nil
 	})
 	if err != nil {
 		return plumbing.Hash{}, "", err
 	}
 
-	return hash, strings.Split(name, "/")[2], nil
+	if latest == nil {
+		return plumbing.Hash{}, "", errors.New("repository has no version tags")
+	}
+
+	// annotated tags point to tag objects, not to commits
+	if tag, err := repository.TagObject(hash); err == nil {
+		hash = tag.Target
+	}
+
+	return hash, name, nil
+}
+
+// availableVersions returns versions of the dependency that can be used by the module.
+// Vendored versions are preferred over all the others. Otherwise versions are listed in the remote repository,
+// or taken from the ones that are already downloaded if it can't be reached or builder is offline.
+func (b Builder) availableVersions(modRootPath, path string) ([]*semver.Version, error) {
+	if vendored := globVersions(vendorPath(modRootPath, core.ModuleRef{Path: path, Version: "*"})); len(vendored) > 0 {
+		return vendored, nil
+	}
+
+	downloaded := globVersions(b.ModulePath(core.ModuleRef{Path: path, Version: "*"}))
+
+	if b.offline {
+		if len(downloaded) == 0 {
+			return nil, fmt.Errorf("%v is not downloaded, can't list its versions in offline mode", path)
+		}
+		return downloaded, nil
+	}
+
+	url, auth, err := b.repository(path)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		if len(downloaded) == 0 {
+			return nil, fmt.Errorf("list versions of %v: %w", path, err)
+		}
+		return downloaded, nil
+	}
+
+	tags := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Name().IsTag() {
+			tags = append(tags, ref.Name().Short())
+		}
+	}
+
+	return parseVersions(tags), nil
+}
+
+// globVersions returns versions of the module directories that match the "<path>_*" pattern.
+func globVersions(pattern string) []*semver.Version {
+	dirs, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil
+	}
+
+	prefix := strings.TrimSuffix(filepath.Base(pattern), "*")
+	tags := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		tags = append(tags, strings.TrimPrefix(filepath.Base(dir), prefix))
+	}
+
+	return parseVersions(tags)
 }
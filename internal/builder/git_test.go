@@ -0,0 +1,30 @@
+package builder
+
+import (
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLatestTagHash(t *testing.T) {
+	repo, err := git.PlainInit(t.TempDir(), false)
+	require.NoError(t, err)
+
+	tree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	sig := &object.Signature{Name: "neva", When: time.Now()}
+	for _, tag := range []string{"0.9.0", "0.10.0", "latest", "0.2.0"} {
+		hash, err := tree.Commit(tag, &git.CommitOptions{Author: sig, AllowEmptyCommits: true})
+		require.NoError(t, err)
+		_, err = repo.CreateTag(tag, hash, nil)
+		require.NoError(t, err)
+	}
+
+	_, name, err := getLatestTagHash(repo)
+	require.NoError(t, err)
+	require.Equal(t, "0.10.0", name)
+}
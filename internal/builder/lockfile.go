@@ -0,0 +1,238 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	git "github.com/go-git/go-git/v5"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+// lockfileName is the name of the file next to the manifest that pins dependencies.
+const lockfileName = "neva.lock"
+
+const lockfileHeader = "# Code generated by neva. DO NOT EDIT.\n"
+
+// lockfile pins every dependency of the module to the exact source code it was built with.
+type lockfile struct {
+	Deps []lockedDep `yaml:"deps"`
+}
+
+type lockedDep struct {
+	Path     string `yaml:"path"`
+	Version  string `yaml:"version"`
+	Commit   string `yaml:"commit,omitempty"`
+	Checksum string `yaml:"checksum"`
+}
+
+func (d lockedDep) ref() core.ModuleRef {
+	return core.ModuleRef{Path: d.Path, Version: d.Version}
+}
+
+// readLockfile reads lockfile from the module root. Missing lockfile is the same as empty one.
+func readLockfile(modRootPath string) (lockfile, error) {
+	raw, err := os.ReadFile(filepath.Join(modRootPath, lockfileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return lockfile{}, nil
+	}
+	if err != nil {
+		return lockfile{}, err
+	}
+
+	var lock lockfile
+	if err := yaml.Unmarshal(raw, &lock); err != nil {
+		return lockfile{}, fmt.Errorf("yaml unmarshal %v: %w", lockfileName, err)
+	}
+
+	return lock, nil
+}
+
+// write writes lockfile to the module root if it's different from what's already there.
+// Lockfile is not created for modules without dependencies.
+func (l lockfile) write(modRootPath string) error {
+	sort.Slice(l.Deps, func(i, j int) bool {
+		if l.Deps[i].Path != l.Deps[j].Path {
+			return l.Deps[i].Path < l.Deps[j].Path
+		}
+		return l.Deps[i].Version < l.Deps[j].Version
+	})
+
+	path := filepath.Join(modRootPath, lockfileName)
+
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && len(l.Deps) == 0 {
+		return nil
+	}
+
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("marshal lockfile: %w", err)
+	}
+	data = append([]byte(lockfileHeader), data...)
+
+	if bytes.Equal(existing, data) {
+		return nil
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// find returns locked version of the dependency.
+func (l lockfile) find(ref core.ModuleRef) (lockedDep, bool) {
+	for _, dep := range l.Deps {
+		if dep.ref() == ref {
+			return dep, true
+		}
+	}
+	return lockedDep{}, false
+}
+
+// latest returns the highest version of the module in the lockfile.
+func (l lockfile) latest(path string) (string, bool) {
+	var latest *semver.Version
+	for _, dep := range l.Deps {
+		if dep.Path != path {
+			continue
+		}
+		v, err := semver.NewVersion(dep.Version)
+		if err != nil {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return "", false
+	}
+	return latest.Original(), true
+}
+
+// add adds the dependency to the lockfile or verifies it if it's already there.
+func (l *lockfile) add(dep lockedDep) error {
+	for _, existing := range l.Deps {
+		if existing.ref() != dep.ref() {
+			continue
+		}
+		return existing.verify(dep)
+	}
+	l.Deps = append(l.Deps, dep)
+	return nil
+}
+
+// verify returns error if the downloaded dependency is not what's locked.
+// Commit is only compared if it's known for both, e.g. it's unknown for copies without git history.
+func (d lockedDep) verify(downloaded lockedDep) error {
+	if d.Checksum != downloaded.Checksum {
+		return fmt.Errorf(
+			"checksum mismatch for %v: %v has %v, downloaded module has %v",
+			d.ref(), lockfileName, d.Checksum, downloaded.Checksum,
+		)
+	}
+	if d.Commit != "" && downloaded.Commit != "" && d.Commit != downloaded.Commit {
+		return fmt.Errorf(
+			"commit mismatch for %v: %v has %v, downloaded module has %v",
+			d.ref(), lockfileName, d.Commit, downloaded.Commit,
+		)
+	}
+	return nil
+}
+
+// lockDep returns commit and checksum of the dependency downloaded to the given directory.
+func lockDep(ref core.ModuleRef, dir string) (lockedDep, error) {
+	checksum, err := sourceChecksum(dir)
+	if err != nil {
+		return lockedDep{}, fmt.Errorf("checksum of %v: %w", ref, err)
+	}
+
+	return lockedDep{
+		Path:     ref.Path,
+		Version:  ref.Version,
+		Commit:   headCommit(dir),
+		Checksum: checksum,
+	}, nil
+}
+
+// sourceChecksum returns hash of the paths and contents of all the .neva files of the module in the directory.
+func sourceChecksum(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			if skipModuleDir(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".neva" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	summary := sha256.New()
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%x  %s\n", sha256.Sum256(content), filepath.ToSlash(rel))
+	}
+
+	return "sha256:" + hex.EncodeToString(summary.Sum(nil)), nil
+}
+
+// headCommit returns hash of the checked out commit, or empty string if directory is not a git repository.
+func headCommit(dir string) string {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return ""
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return ""
+	}
+	return head.Hash().String()
+}
+
+// verifyAndLockDep checks that downloaded dependency matches the old lockfile and adds it to the new one.
+func verifyAndLockDep(old lockfile, next *lockfile, ref core.ModuleRef, dir string) error {
+	downloaded, err := lockDep(ref, dir)
+	if err != nil {
+		return err
+	}
+	if locked, ok := old.find(ref); ok {
+		if err := locked.verify(downloaded); err != nil {
+			return err
+		}
+		// vendored copies don't have git history
+		if downloaded.Commit == "" {
+			downloaded.Commit = locked.Commit
+		}
+	}
+	return next.add(downloaded)
+}
@@ -0,0 +1,40 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+func TestLockfile(t *testing.T) {
+	modDir := t.TempDir()
+	depDir := t.TempDir()
+	ref := core.ModuleRef{Path: "github.com/nevalang/x", Version: "0.0.1"}
+
+	require.NoError(t, os.WriteFile(filepath.Join(depDir, "main.neva"), []byte("def Main() () {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(depDir, "README.md"), []byte("readme"), 0644))
+
+	// lockfile is not created for modules without dependencies
+	require.NoError(t, lockfile{}.write(modDir))
+	_, err := os.Stat(filepath.Join(modDir, lockfileName))
+	require.True(t, os.IsNotExist(err))
+
+	var lock lockfile
+	require.NoError(t, verifyAndLockDep(lockfile{}, &lock, ref, depDir))
+	require.NoError(t, lock.write(modDir))
+
+	read, err := readLockfile(modDir)
+	require.NoError(t, err)
+	require.Equal(t, lock, read)
+
+	// only .neva files affect checksum
+	require.NoError(t, os.WriteFile(filepath.Join(depDir, "README.md"), []byte("changed"), 0644))
+	require.NoError(t, verifyAndLockDep(read, &lockfile{}, ref, depDir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(depDir, "main.neva"), []byte("def Main() () {}\n// changed\n"), 0644))
+	require.ErrorContains(t, verifyAndLockDep(read, &lockfile{}, ref, depDir), "checksum mismatch")
+}
@@ -41,6 +41,10 @@ func retrieveSourceCode(rootPath string, pkgs map[string]compiler.RawPackage) er
 		}
 
 		if d.IsDir() {
+			// dependencies are not part of the module
+			if filePath == vendorDir {
+				return fs.SkipDir
+			}
 			return nil
 		}
 
@@ -0,0 +1,28 @@
+package builder
+
+import (
+	"fmt"
+
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+// replacementRef returns reference to the git repository that replaces the module.
+func replacementRef(repl src.ModuleReplacement) core.ModuleRef {
+	return core.ModuleRef{
+		Path:    repl.URL,
+		Version: repl.Version,
+	}
+}
+
+func validateReplacements(replace map[string]src.ModuleReplacement) error {
+	for path, repl := range replace {
+		if (repl.Dir == "") == (repl.URL == "") {
+			return fmt.Errorf("replacement of %v must have either dir or url", path)
+		}
+		if repl.URL != "" && repl.Version == "" {
+			return fmt.Errorf("replacement of %v with %v must have version", path, repl.URL)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/nevalang/neva/internal/compiler"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+// moduleLine identifies compatible versions of the module.
+// Build contains at most one version of every line.
+type moduleLine struct {
+	path, line string
+}
+
+// requirement is a version constraint that some module in the build puts on its dependency.
+type requirement struct {
+	constraint versionConstraint
+	requiredBy []core.ModuleRef // path from the entry module to the module that requires
+}
+
+// resolver selects versions of dependencies with minimal version selection.
+// For every line of the module it selects the lowest version that satisfies
+// requirements of all the modules that were visited, directly or transitively.
+type resolver struct {
+	ctx     context.Context
+	builder Builder
+	lock    lockfile
+	// replace and entryModRootPath are taken from the entry module.
+	replace          map[string]src.ModuleReplacement
+	entryModRootPath string
+
+	requirements map[moduleLine][]requirement
+	// aliases maps dependencies of every visited module to their lines.
+	aliases map[core.ModuleRef]map[string]moduleLine
+	// visited contains every downloaded module, selected or not, and its directory.
+	visited  map[core.ModuleRef]compiler.RawModule
+	dirs     map[core.ModuleRef]string
+	versions map[string][]*semver.Version // cache of available versions by module path
+}
+
+// resolution is the result of the dependency resolution.
+type resolution struct {
+	mods map[core.ModuleRef]compiler.RawModule // selected modules with rewritten deps
+	dirs map[core.ModuleRef]string
+	// visited contains directories of all the modules needed to select versions, selected or not.
+	visited map[core.ModuleRef]string
+	// local contains modules replaced with local directories.
+	local map[core.ModuleRef]bool
+}
+
+func newResolver(
+	ctx context.Context,
+	b Builder,
+	lock lockfile,
+	entryModRootPath string,
+	replace map[string]src.ModuleReplacement,
+) *resolver {
+	return &resolver{
+		ctx:              ctx,
+		builder:          b,
+		lock:             lock,
+		replace:          replace,
+		entryModRootPath: entryModRootPath,
+		requirements:     map[moduleLine][]requirement{},
+		aliases:          map[core.ModuleRef]map[string]moduleLine{},
+		visited:          map[core.ModuleRef]compiler.RawModule{},
+		dirs:             map[core.ModuleRef]string{},
+		versions:         map[string][]*semver.Version{},
+	}
+}
+
+// resolve walks dependency graph of the entry module until selected versions stop changing.
+func (r *resolver) resolve(entryRef core.ModuleRef, entryMod compiler.RawModule) (resolution, error) {
+	if err := validateReplacements(r.replace); err != nil {
+		return resolution{}, err
+	}
+
+	if err := r.require(entryRef, entryMod.Manifest, []core.ModuleRef{entryRef}); err != nil {
+		return resolution{}, err
+	}
+
+	selected := map[moduleLine]core.ModuleRef{}
+	for changed := true; changed; {
+		changed = false
+
+		for _, line := range r.lines() {
+			ref, err := r.selectVersion(line)
+			if err != nil {
+				return resolution{}, err
+			}
+			selected[line] = ref
+
+			if _, ok := r.visited[ref]; ok {
+				continue
+			}
+
+			if err := r.visit(ref, r.requirements[line][0].requiredBy); err != nil {
+				return resolution{}, err
+			}
+			changed = true
+		}
+	}
+
+	res := resolution{
+		mods:    map[core.ModuleRef]compiler.RawModule{},
+		dirs:    map[core.ModuleRef]string{},
+		local:   map[core.ModuleRef]bool{},
+		visited: r.dirs,
+	}
+
+	entryMod.Manifest = r.pin(entryRef, entryMod.Manifest, selected)
+	res.mods[entryRef] = entryMod
+
+	for _, ref := range selected {
+		mod := r.visited[ref]
+		mod.Manifest = r.pin(ref, mod.Manifest, selected)
+		res.mods[ref] = mod
+		res.dirs[ref] = r.dirs[ref]
+		res.local[ref] = r.replace[ref.Path].Dir != ""
+	}
+
+	return res, nil
+}
+
+// visit downloads the module and adds its requirements.
+func (r *resolver) visit(ref core.ModuleRef, requiredBy []core.ModuleRef) error {
+	dir, err := r.download(ref)
+	if err != nil {
+		return fmt.Errorf("download dep %v: %w", ref, err)
+	}
+
+	mod, _, err := r.builder.LoadModuleByPath(r.ctx, dir)
+	if err != nil {
+		return fmt.Errorf("build dep mod %v: %w", ref, err)
+	}
+
+	r.visited[ref] = mod
+	r.dirs[ref] = dir
+
+	chain := append(append([]core.ModuleRef{}, requiredBy...), ref)
+
+	return r.require(ref, mod.Manifest, chain)
+}
+
+// download returns directory with source code of the module.
+// Vendored, replaced and already downloaded modules are not downloaded again.
+func (r *resolver) download(ref core.ModuleRef) (string, error) {
+	repl, ok := r.replace[ref.Path]
+	if dir := r.builder.DepPath(r.entryModRootPath, r.replace, ref); repl.Dir != "" || isDir(dir) {
+		return dir, nil
+	}
+	if ok {
+		ref = replacementRef(repl)
+	}
+	dir, _, err := r.builder.downloadDep(ref)
+	return dir, err
+}
+
+// require adds requirements of the module that is required through the given chain.
+func (r *resolver) require(ref core.ModuleRef, manifest src.ModuleManifest, chain []core.ModuleRef) error {
+	r.aliases[ref] = make(map[string]moduleLine, len(manifest.Deps))
+
+	aliases := make([]string, 0, len(manifest.Deps))
+	for alias := range manifest.Deps {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		dep := manifest.Deps[alias]
+		if dep.Path == "std" {
+			continue
+		}
+
+		constraint, err := r.constraint(dep)
+		if err != nil {
+			return fmt.Errorf("dependency %v of %v: %w", alias, ref, err)
+		}
+
+		line := moduleLine{path: dep.Path, line: constraint.line()}
+		r.aliases[ref][alias] = line
+		r.requirements[line] = append(r.requirements[line], requirement{
+			constraint: constraint,
+			requiredBy: chain,
+		})
+	}
+
+	return nil
+}
+
+// constraint parses version of the dependency.
+// Dependency without version is locked version or the latest one.
+func (r *resolver) constraint(dep core.ModuleRef) (versionConstraint, error) {
+	if dep.Version != "" {
+		return parseConstraint(dep.Version)
+	}
+
+	if locked, ok := r.lock.latest(dep.Path); ok {
+		return parseConstraint(locked)
+	}
+
+	versions, err := r.availableVersions(dep.Path)
+	if err != nil {
+		return versionConstraint{}, err
+	}
+	if len(versions) == 0 {
+		return versionConstraint{}, fmt.Errorf("%v has no versions", dep.Path)
+	}
+
+	return parseConstraint(versions[len(versions)-1].Original())
+}
+
+// selectVersion returns the lowest version of the line that satisfies all its requirements.
+func (r *resolver) selectVersion(line moduleLine) (core.ModuleRef, error) {
+	reqs := r.requirements[line]
+
+	lowest, highest := reqs[0].constraint, reqs[0].constraint
+	for _, req := range reqs[1:] {
+		if req.constraint.min.GreaterThan(lowest.min) {
+			lowest = req.constraint
+		}
+		if req.constraint.max.LessThan(highest.max) {
+			highest = req.constraint
+		}
+	}
+
+	if !lowest.min.LessThan(highest.max) {
+		return core.ModuleRef{}, conflictError(line, reqs)
+	}
+
+	satisfiesAll := func(v *semver.Version) bool {
+		for _, req := range reqs {
+			if !req.constraint.allows(v) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// replaced modules don't have tags of the original repository
+	if repl, ok := r.replace[line.path]; ok {
+		if repl.Dir != "" {
+			return core.ModuleRef{Path: line.path, Version: lowest.min.Original()}, nil
+		}
+		return core.ModuleRef{Path: line.path, Version: repl.Version}, nil
+	}
+
+	// plain versions are tags, there's no need to look for them
+	if lowest.exact && satisfiesAll(lowest.min) {
+		return core.ModuleRef{Path: line.path, Version: strings.TrimSpace(lowest.raw)}, nil
+	}
+
+	versions, err := r.availableVersions(line.path)
+	if err != nil {
+		return core.ModuleRef{}, err
+	}
+
+	for _, v := range versions {
+		if satisfiesAll(v) {
+			return core.ModuleRef{Path: line.path, Version: v.Original()}, nil
+		}
+	}
+
+	return core.ModuleRef{}, conflictError(line, reqs)
+}
+
+func (r *resolver) availableVersions(path string) ([]*semver.Version, error) {
+	if versions, ok := r.versions[path]; ok {
+		return versions, nil
+	}
+
+	versions, err := r.builder.availableVersions(r.entryModRootPath, path)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(semver.Collection(versions))
+
+	r.versions[path] = versions
+	return versions, nil
+}
+
+// pin replaces versions of the module's dependencies with the selected ones.
+func (r *resolver) pin(
+	ref core.ModuleRef,
+	manifest src.ModuleManifest,
+	selected map[moduleLine]core.ModuleRef,
+) src.ModuleManifest {
+	deps := make(map[string]core.ModuleRef, len(manifest.Deps))
+	for alias, dep := range manifest.Deps {
+		if line, ok := r.aliases[ref][alias]; ok {
+			dep = selected[line]
+		}
+		deps[alias] = dep
+	}
+	manifest.Deps = deps
+	return manifest
+}
+
+// lines returns lines of all required modules in deterministic order.
+func (r *resolver) lines() []moduleLine {
+	lines := make([]moduleLine, 0, len(r.requirements))
+	for line := range r.requirements {
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].path != lines[j].path {
+			return lines[i].path < lines[j].path
+		}
+		return lines[i].line < lines[j].line
+	})
+	return lines
+}
+
+// conflictError lists requirements that can't be satisfied at the same time
+// together with the modules that lead to them.
+func conflictError(line moduleLine, reqs []requirement) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "no version of %v satisfies all requirements:", line.path)
+	for _, req := range reqs {
+		chain := make([]string, 0, len(req.requiredBy))
+		for _, ref := range req.requiredBy {
+			chain = append(chain, ref.String())
+		}
+		fmt.Fprintf(&b, "\n\t%v required by %v", req.constraint, strings.Join(chain, " -> "))
+	}
+	return errors.New(b.String())
+}
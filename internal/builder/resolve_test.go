@@ -0,0 +1,153 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nevalang/neva/internal/compiler/parser"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+func TestResolver(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    map[string]string            // alias -> path@constraint
+		deps     map[string]map[string]string // downloaded module -> its deps
+		selected map[string]string            // alias of entry dep -> selected version
+		replace  string                       // replace section of the entry manifest
+		err      string
+	}{
+		{
+			name:  "highest of minimal versions",
+			entry: map[string]string{"x": "example.invalid/x@0.1.0", "a": "example.invalid/a@1.0.0"},
+			deps: map[string]map[string]string{
+				"example.invalid/x@0.1.0": {},
+				"example.invalid/x@0.1.2": {},
+				"example.invalid/a@1.0.0": {"x": "example.invalid/x@0.1.2"},
+			},
+			selected: map[string]string{"x": "0.1.2", "a": "1.0.0"},
+		},
+		{
+			name:  "lowest version of range",
+			entry: map[string]string{"x": "example.invalid/x@^0.1.1"},
+			deps: map[string]map[string]string{
+				"example.invalid/x@0.1.0": {},
+				"example.invalid/x@0.1.2": {},
+				"example.invalid/x@0.1.3": {},
+			},
+			selected: map[string]string{"x": "0.1.2"},
+		},
+		{
+			name:  "incompatible lines",
+			entry: map[string]string{"x": "example.invalid/x@0.1.0", "x2": "example.invalid/x@~0.2"},
+			deps: map[string]map[string]string{
+				"example.invalid/x@0.1.0": {},
+				"example.invalid/x@0.2.0": {},
+			},
+			selected: map[string]string{"x": "0.1.0", "x2": "0.2.0"},
+		},
+		{
+			name:  "local replacement",
+			entry: map[string]string{"x": "example.invalid/x@^0.1", "a": "example.invalid/a@1.0.0"},
+			deps: map[string]map[string]string{
+				"example.invalid/a@1.0.0": {"x": "example.invalid/x@0.1.2"},
+				"../x":                    {},
+			},
+			replace:  "  example.invalid/x:\n    dir: ../x\n",
+			selected: map[string]string{"x": "0.1.2", "a": "1.0.0"},
+		},
+		{
+			name:  "git replacement",
+			entry: map[string]string{"x": "example.invalid/x@0.1.0"},
+			deps: map[string]map[string]string{
+				"example.invalid/fork@0.1.5": {},
+			},
+			replace:  "  example.invalid/x:\n    url: https://example.invalid/fork\n    version: 0.1.5\n",
+			selected: map[string]string{"x": "0.1.5"},
+		},
+		{
+			name:  "conflict",
+			entry: map[string]string{"y": "example.invalid/y@~1.1.0", "a": "example.invalid/a@1.0.0"},
+			deps: map[string]map[string]string{
+				"example.invalid/y@1.1.0": {},
+				"example.invalid/y@1.2.0": {},
+				"example.invalid/a@1.0.0": {"y": "example.invalid/y@1.2.0"},
+			},
+			err: "no version of example.invalid/y satisfies all requirements:\n" +
+				"\t~1.1.0 required by @\n" +
+				"\t1.2.0 required by @ -> example.invalid/a@1.0.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Builder{
+				manifestParser: parser.New(),
+				thirdPartyPath: t.TempDir(),
+			}
+
+			entryDir := filepath.Join(t.TempDir(), "entry")
+			writeTestModule(t, entryDir, tt.entry)
+
+			if tt.replace != "" {
+				f, err := os.OpenFile(filepath.Join(entryDir, "neva.yml"), os.O_APPEND|os.O_WRONLY, 0644)
+				require.NoError(t, err)
+				_, err = f.WriteString("replace:\n" + tt.replace)
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			}
+
+			for dep, deps := range tt.deps {
+				if strings.HasPrefix(dep, ".") {
+					writeTestModule(t, filepath.Join(entryDir, dep), deps)
+					continue
+				}
+				path, version, _ := strings.Cut(dep, "@")
+				writeTestModule(t, b.ModulePath(core.ModuleRef{Path: path, Version: version}), deps)
+			}
+
+			entryMod, _, err := b.LoadModuleByPath(context.Background(), entryDir)
+			require.NoError(t, err)
+
+			entryRef := core.ModuleRef{Path: "@"}
+			res, err := newResolver(
+				context.Background(), b, lockfile{}, entryDir, entryMod.Manifest.Replace,
+			).resolve(entryRef, entryMod)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			entryDeps := res.mods[entryRef].Manifest.Deps
+			require.Len(t, entryDeps, len(tt.selected))
+			for alias, version := range tt.selected {
+				require.Equal(t, version, entryDeps[alias].Version, alias)
+				require.Contains(t, res.mods, entryDeps[alias])
+			}
+			require.Len(t, res.mods, len(tt.selected)+1)
+
+			if tt.replace != "" {
+				x := entryDeps["x"]
+				require.Equal(t, b.DepPath(entryDir, entryMod.Manifest.Replace, x), res.dirs[x])
+			}
+		})
+	}
+}
+
+func writeTestModule(t *testing.T, dir string, deps map[string]string) {
+	manifest := "neva: 0.32.0\ndeps:\n"
+	for alias, dep := range deps {
+		path, version, _ := strings.Cut(dep, "@")
+		manifest += fmt.Sprintf("  %s:\n    path: %s\n    version: %q\n", alias, path, version)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "neva.yml"), []byte(manifest), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "pkg.neva"), []byte("pub const c int = 1\n"), 0644))
+}
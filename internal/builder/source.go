@@ -0,0 +1,278 @@
+package builder
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// Environment variables that configure where and how dependencies are downloaded.
+const (
+	// proxyEnv is base URL of the mirror that has git repositories of the modules at "<base>/<module path>".
+	proxyEnv = "NEVA_PROXY"
+	// noProxyEnv is comma-separated prefixes of module paths that are not downloaded through the mirror.
+	noProxyEnv = "NEVA_NOPROXY"
+	// gitUsernameEnv and gitPasswordEnv are credentials for git repositories accessed over http(s).
+	// They are only sent to the hosts from gitHostsEnv and to the mirror.
+	gitUsernameEnv = "NEVA_GIT_USERNAME"
+	gitPasswordEnv = "NEVA_GIT_PASSWORD"
+	// gitHostsEnv is comma-separated hosts that credentials from the environment are sent to.
+	gitHostsEnv = "NEVA_GIT_HOSTS"
+	// sshKeyEnv and sshKeyPasswordEnv are private key for git repositories accessed over ssh.
+	sshKeyEnv         = "NEVA_SSH_KEY"
+	sshKeyPasswordEnv = "NEVA_SSH_KEY_PASSWORD"
+)
+
+// source is where modules are downloaded from.
+type source interface {
+	// repository returns URL of the git repository of the module, or false if the source doesn't have it.
+	repository(path string) (string, bool)
+}
+
+// urlSource is used for modules whose paths are git URLs like ssh://, git@host:repo or file://.
+type urlSource struct{}
+
+func (urlSource) repository(path string) (string, bool) {
+	return path, isGitURL(path)
+}
+
+// mirrorSource is a server that mirrors repositories of the modules.
+type mirrorSource struct {
+	base    string
+	exclude []string
+}
+
+func (m mirrorSource) repository(path string) (string, bool) {
+	for _, prefix := range m.exclude {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return "", false
+		}
+	}
+	return strings.TrimSuffix(m.base, "/") + "/" + path, true
+}
+
+// httpsSource is the default source that downloads module from the host in its path.
+type httpsSource struct{}
+
+func (httpsSource) repository(path string) (string, bool) {
+	return "https://" + path, true
+}
+
+// sourcesFromEnv returns sources configured by the environment.
+func sourcesFromEnv() []source {
+	proxy := os.Getenv(proxyEnv)
+	if proxy == "" {
+		return nil
+	}
+	return []source{
+		mirrorSource{
+			base:    proxy,
+			exclude: strings.Split(os.Getenv(noProxyEnv), ","),
+		},
+	}
+}
+
+// repository returns URL of the git repository of the module and credentials for it.
+// Git URLs are used as is, other paths are looked up in configured sources and then downloaded over https.
+func (b Builder) repository(path string) (string, transport.AuthMethod, error) {
+	sources := make([]source, 0, len(b.sources)+2)
+	sources = append(sources, urlSource{})
+	sources = append(sources, b.sources...)
+	sources = append(sources, httpsSource{})
+
+	var url string
+	for _, s := range sources {
+		if repo, ok := s.repository(path); ok {
+			url = repo
+			break
+		}
+	}
+
+	auth, err := authFor(url, credentialHostsFromEnv())
+	if err != nil {
+		return "", nil, err
+	}
+
+	return url, auth, nil
+}
+
+// credentialHostsFromEnv returns hosts that http(s) credentials from the environment can be sent to.
+// These are hosts listed explicitly and the host of the mirror.
+func credentialHostsFromEnv() []string {
+	var hosts []string
+	for _, host := range strings.Split(os.Getenv(gitHostsEnv), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if proxy := os.Getenv(proxyEnv); proxy != "" {
+		if endpoint, err := transport.NewEndpoint(proxy); err == nil {
+			hosts = append(hosts, endpoint.Host)
+		}
+	}
+	return hosts
+}
+
+// authFor returns credentials for the repository from the environment or netrc file.
+// Credentials from the environment are only used for the given hosts,
+// so tokens for private servers are not leaked to public ones.
+// Nil is returned if there are no credentials, e.g. for ssh that means using ssh agent.
+func authFor(url string, credentialHosts []string) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, err
+	}
+
+	switch endpoint.Protocol {
+	case "ssh":
+		key := os.Getenv(sshKeyEnv)
+		if key == "" {
+			return nil, nil
+		}
+		user := endpoint.User
+		if user == "" {
+			user = "git"
+		}
+		return gitssh.NewPublicKeysFromFile(user, key, os.Getenv(sshKeyPasswordEnv))
+	case "http", "https":
+		if password := os.Getenv(gitPasswordEnv); password != "" && slices.Contains(credentialHosts, endpoint.Host) {
+			username := os.Getenv(gitUsernameEnv)
+			if username == "" {
+				username = "git"
+			}
+			return &githttp.BasicAuth{Username: username, Password: password}, nil
+		}
+		if username, password, ok := netrcCredentials(endpoint.Host); ok {
+			return &githttp.BasicAuth{Username: username, Password: password}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+var (
+	// scpLikeURL matches git URLs like "git@github.com:nevalang/neva.git".
+	scpLikeURL = regexp.MustCompile(`^(?:[^@/\s]+@)?[^:/\s]+:[^/\\]`)
+	// hostWithPort matches paths like "git.corp.com:8080/team/mod" that look like scp-like URLs but aren't.
+	hostWithPort = regexp.MustCompile(`^(?:[^@/\s]+@)?[^:/\s]+:\d+(?:/|$)`)
+)
+
+// isGitURL reports whether module path is a git URL rather than a host with path.
+func isGitURL(path string) bool {
+	if strings.Contains(path, "://") {
+		return true
+	}
+	return scpLikeURL.MatchString(path) && !hostWithPort.MatchString(path)
+}
+
+// moduleDirName returns relative directory for the module on disk.
+// Git URLs are turned into host with path, so they can be used as directory names.
+func moduleDirName(path string) string {
+	if !isGitURL(path) {
+		return path
+	}
+
+	endpoint, err := transport.NewEndpoint(path)
+	if err != nil {
+		return path
+	}
+
+	name := endpoint.Host + "/" + strings.TrimPrefix(endpoint.Path, "/")
+	if endpoint.Protocol == "file" {
+		name = "file/" + strings.TrimPrefix(filepath.ToSlash(endpoint.Path), "/")
+	}
+
+	return strings.TrimSuffix(name, ".git")
+}
+
+// netrcCredentials returns login and password for the host from the netrc file.
+// File is taken from NETRC environment variable or from the home directory.
+func netrcCredentials(host string) (login, password string, ok bool) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", false
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", false
+	}
+	defer f.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, strings.Fields(line)...)
+	}
+
+	return parseNetrc(tokens, host)
+}
+
+// parseNetrc looks for the machine with the given name, or for the default one if it's not there.
+func parseNetrc(tokens []string, host string) (login, password string, ok bool) {
+	var (
+		machine    string
+		inDefault  bool
+		found      bool
+		defLogin   string
+		defPass    string
+		hasDefault bool
+	)
+
+	for i := 0; i < len(tokens); i++ {
+		value := ""
+		if i+1 < len(tokens) {
+			value = tokens[i+1]
+		}
+
+		switch tokens[i] {
+		case "machine":
+			if found {
+				return login, password, true
+			}
+			machine, inDefault = value, false
+			found = machine == host
+			i++
+		case "default":
+			if found {
+				return login, password, true
+			}
+			machine, inDefault, hasDefault = "", true, true
+		case "login":
+			if found {
+				login = value
+			} else if inDefault {
+				defLogin = value
+			}
+			i++
+		case "password":
+			if found {
+				password = value
+			} else if inDefault {
+				defPass = value
+			}
+			i++
+		}
+	}
+
+	if found {
+		return login, password, true
+	}
+
+	return defLogin, defPass, hasDefault
+}
@@ -0,0 +1,149 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+func TestModuleDirName(t *testing.T) {
+	tests := map[string]string{
+		"github.com/nevalang/x":                "github.com/nevalang/x",
+		"https://github.com/me/fork":           "github.com/me/fork",
+		"git@git.corp.com:team/mod.git":        "git.corp.com/team/mod",
+		"ssh://git@git.corp.com:2222/team/mod": "git.corp.com/team/mod",
+		"file:///srv/git/mod":                  "file/srv/git/mod",
+		"git.corp.com:8080/team/mod":           "git.corp.com:8080/team/mod",
+	}
+	for path, want := range tests {
+		require.Equal(t, want, moduleDirName(path), path)
+	}
+}
+
+func TestIsGitURL(t *testing.T) {
+	tests := map[string]bool{
+		"github.com/nevalang/x":         false,
+		"git.corp.com:8080/team/mod":    false,
+		"git.corp.com:8080":             false,
+		"git@git.corp.com:team/mod.git": true,
+		"git.corp.com:team/mod":         true,
+		"git.corp.com:2team/mod":        true,
+		"ssh://git.corp.com:22/mod":     true,
+	}
+	for path, want := range tests {
+		require.Equal(t, want, isGitURL(path), path)
+	}
+}
+
+func TestRepository(t *testing.T) {
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "netrc"))
+	t.Setenv(gitPasswordEnv, "")
+
+	b := Builder{
+		sources: []source{
+			mirrorSource{base: "https://mirror.corp/git/", exclude: []string{"git.corp.com/"}},
+		},
+	}
+
+	tests := map[string]string{
+		"github.com/nevalang/x":     "https://mirror.corp/git/github.com/nevalang/x",
+		"git.corp.com/team/mod":     "https://git.corp.com/team/mod",
+		"git@git.corp.com:team/mod": "git@git.corp.com:team/mod",
+		"file:///srv/git/mod":       "file:///srv/git/mod",
+		"git.corp.com:8080/mod":     "https://mirror.corp/git/git.corp.com:8080/mod",
+	}
+	for path, want := range tests {
+		url, auth, err := b.repository(path)
+		require.NoError(t, err)
+		require.Equal(t, want, url, path)
+		require.Nil(t, auth, path)
+	}
+}
+
+func TestAuthFor(t *testing.T) {
+	netrc := filepath.Join(t.TempDir(), "netrc")
+	require.NoError(t, os.WriteFile(netrc, []byte(
+		"# comment\nmachine github.com login bob password one\n"+
+			"machine git.corp.com\n\tlogin alice\n\tpassword two\n"+
+			"default login anonymous password three\n",
+	), 0600))
+	t.Setenv("NETRC", netrc)
+	t.Setenv(gitPasswordEnv, "")
+
+	hosts := []string{"git.corp.com"}
+
+	auth, err := authFor("https://git.corp.com/team/mod", hosts)
+	require.NoError(t, err)
+	require.Equal(t, &githttp.BasicAuth{Username: "alice", Password: "two"}, auth)
+
+	auth, err = authFor("https://example.com/mod", hosts)
+	require.NoError(t, err)
+	require.Equal(t, &githttp.BasicAuth{Username: "anonymous", Password: "three"}, auth)
+
+	t.Setenv(gitPasswordEnv, "token")
+	auth, err = authFor("https://git.corp.com/team/mod", hosts)
+	require.NoError(t, err)
+	require.Equal(t, &githttp.BasicAuth{Username: "git", Password: "token"}, auth)
+
+	// token is not sent to hosts it's not configured for
+	auth, err = authFor("https://github.com/nevalang/x", hosts)
+	require.NoError(t, err)
+	require.Equal(t, &githttp.BasicAuth{Username: "bob", Password: "one"}, auth)
+
+	auth, err = authFor("file:///srv/git/mod", hosts)
+	require.NoError(t, err)
+	require.Nil(t, auth)
+}
+
+func TestCredentialHostsFromEnv(t *testing.T) {
+	t.Setenv(gitHostsEnv, "git.corp.com, gitlab.corp.com,")
+	t.Setenv(proxyEnv, "https://mirror.corp:8443/git/")
+	require.Equal(t, []string{"git.corp.com", "gitlab.corp.com", "mirror.corp"}, credentialHostsFromEnv())
+
+	t.Setenv(gitHostsEnv, "")
+	t.Setenv(proxyEnv, "")
+	require.Empty(t, credentialHostsFromEnv())
+}
+
+func TestDownloadDep_FileRepository(t *testing.T) {
+	repoDir := t.TempDir()
+	repo, err := git.PlainInit(repoDir, false)
+	require.NoError(t, err)
+	tree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	sig := &object.Signature{Name: "neva", When: time.Now()}
+	for _, version := range []string{"0.1.0", "0.2.0"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, "neva.yml"), []byte("neva: "+version+"\n"), 0644))
+		_, err := tree.Add("neva.yml")
+		require.NoError(t, err)
+		hash, err := tree.Commit(version, &git.CommitOptions{Author: sig})
+		require.NoError(t, err)
+		_, err = repo.CreateTag(version, hash, nil)
+		require.NoError(t, err)
+	}
+
+	b := Builder{thirdPartyPath: t.TempDir()}
+	path := "file://" + filepath.ToSlash(repoDir)
+
+	versions, err := b.availableVersions(t.TempDir(), path)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	dir, version, err := b.downloadDep(core.ModuleRef{Path: path, Version: "0.1.0"})
+	require.NoError(t, err)
+	require.Equal(t, "0.1.0", version)
+	require.Equal(t, b.ModulePath(core.ModuleRef{Path: path, Version: "0.1.0"}), dir)
+
+	manifest, err := os.ReadFile(filepath.Join(dir, "neva.yml"))
+	require.NoError(t, err)
+	require.Equal(t, "neva: 0.1.0\n", string(manifest))
+}
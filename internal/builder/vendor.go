@@ -0,0 +1,121 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+// vendorDir is the directory in the module root where dependencies are vendored.
+// Vendored dependencies are used instead of downloaded ones.
+const vendorDir = "vendor"
+
+// vendorPath returns where the dependency is vendored in the module.
+func vendorPath(modRootPath string, ref core.ModuleRef) string {
+	return filepath.Join(modRootPath, vendorDir, vendoredName(ref))
+}
+
+func vendoredName(ref core.ModuleRef) string {
+	return fmt.Sprintf("%s_%s", moduleDirName(ref.Path), ref.Version)
+}
+
+// Vendor copies dependencies of the module into its vendor directory and returns path to it.
+// Besides selected versions it copies all the versions that were needed to select them,
+// so builds can resolve the same versions using only the vendor directory.
+// Modules replaced with local directories are not vendored.
+func (b Builder) Vendor(ctx context.Context, wd string) (string, error) {
+	entryMod, entryModRootPath, err := b.LoadModuleByPath(ctx, wd)
+	if err != nil {
+		return "", fmt.Errorf("build entry mod: %w", err)
+	}
+
+	release, err := acquireLockFile()
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire lock file: %w", err)
+	}
+	defer release()
+
+	resolved, err := b.resolveDeps(ctx, core.ModuleRef{Path: "@"}, entryMod, entryModRootPath)
+	if err != nil {
+		return "", err
+	}
+
+	// resolved modules can be vendored already, so new vendor directory is created next to the old one
+	tmpPath := filepath.Join(entryModRootPath, vendorDir+".tmp")
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return "", err
+	}
+
+	for ref, dir := range resolved.visited {
+		if entryMod.Manifest.Replace[ref.Path].Dir != "" {
+			continue
+		}
+		if err := copyModule(dir, filepath.Join(tmpPath, vendoredName(ref))); err != nil {
+			return "", fmt.Errorf("vendor %v: %w", ref, err)
+		}
+	}
+
+	path := filepath.Join(entryModRootPath, vendorDir)
+	if err := os.RemoveAll(path); err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(tmpPath); os.IsNotExist(err) {
+		return path, nil
+	}
+
+	return path, os.Rename(tmpPath, path)
+}
+
+// copyModule copies manifest and source code of the module, everything else is skipped.
+func copyModule(from, to string) error {
+	return filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if skipModuleDir(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(path) != ".neva" && rel != "neva.yaml" && rel != "neva.yml" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(to, rel)
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+
+		return os.WriteFile(target, content, 0644)
+	})
+}
+
+// skipModuleDir reports whether directory of the module doesn't contain its source code.
+// These are hidden directories like .git and vendored dependencies.
+func skipModuleDir(rel string) bool {
+	return rel == vendorDir || (rel != "." && strings.HasPrefix(filepath.Base(rel), "."))
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
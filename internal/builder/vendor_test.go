@@ -0,0 +1,49 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nevalang/neva/internal/compiler/parser"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+func TestVendor(t *testing.T) {
+	b := Builder{
+		manifestParser: parser.New(),
+		thirdPartyPath: t.TempDir(),
+	}
+
+	entryDir := filepath.Join(t.TempDir(), "entry")
+	writeTestModule(t, entryDir, map[string]string{"x": "example.invalid/x@^0.1", "a": "example.invalid/a@1.0.0"})
+	writeTestModule(t, b.ModulePath(core.ModuleRef{Path: "example.invalid/x", Version: "0.1.0"}), nil)
+	writeTestModule(t, b.ModulePath(core.ModuleRef{Path: "example.invalid/x", Version: "0.1.1"}), nil)
+	writeTestModule(t, b.ModulePath(core.ModuleRef{Path: "example.invalid/a", Version: "1.0.0"}), map[string]string{"x": "example.invalid/x@0.1.1"})
+
+	path, err := b.Vendor(context.Background(), entryDir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(entryDir, "vendor"), path)
+
+	// vendored modules are not part of the entry module
+	entryMod, _, err := b.LoadModuleByPath(context.Background(), entryDir)
+	require.NoError(t, err)
+	require.Len(t, entryMod.Packages, 1)
+
+	// offline builder doesn't need downloaded modules if they are vendored
+	require.NoError(t, os.RemoveAll(b.thirdPartyPath))
+	resolved, err := b.Offline().resolveDeps(context.Background(), core.ModuleRef{Path: "@"}, entryMod, entryDir)
+	require.NoError(t, err)
+
+	x := resolved.mods[core.ModuleRef{Path: "@"}].Manifest.Deps["x"]
+	require.Equal(t, "0.1.1", x.Version)
+	require.Equal(t, filepath.Join(path, "example.invalid", "x_0.1.1"), resolved.dirs[x])
+
+	// without vendored modules offline builder fails instead of downloading them
+	require.NoError(t, os.RemoveAll(path))
+	_, err = b.Offline().resolveDeps(context.Background(), core.ModuleRef{Path: "@"}, entryMod, entryDir)
+	require.ErrorContains(t, err, "offline mode")
+}
@@ -0,0 +1,91 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// versionConstraint is a range of versions [min, max) that satisfy a dependency.
+// Like in the rest of the compiler, minor is used as major for 0.x versions,
+// so every constraint stays within one line of compatible versions.
+type versionConstraint struct {
+	raw string
+	min *semver.Version
+	max *semver.Version
+	// exact is true if the constraint is a plain version, which must exist as a tag.
+	// Like every other constraint, it's still only a minimum during the selection.
+	exact bool
+}
+
+// parseConstraint parses plain version "1.2.3", caret range "^1.2" or tilde range "~0.3.1".
+// Plain version means the same as caret range - this version or the newer compatible one.
+func parseConstraint(raw string) (versionConstraint, error) {
+	trimmed := strings.TrimSpace(raw)
+
+	op := ""
+	if strings.HasPrefix(trimmed, "^") || strings.HasPrefix(trimmed, "~") {
+		op, trimmed = trimmed[:1], strings.TrimSpace(trimmed[1:])
+	}
+
+	min, err := semver.NewVersion(trimmed)
+	if err != nil {
+		return versionConstraint{}, fmt.Errorf("invalid version %q: %w", raw, err)
+	}
+
+	max := nextLine(min)
+	// tilde allows only patch updates if minor is specified
+	if op == "~" && strings.Count(strings.TrimPrefix(trimmed, "v"), ".") > 0 {
+		next := min.IncMinor()
+		max = &next
+	}
+
+	return versionConstraint{
+		raw:   raw,
+		min:   min,
+		max:   max,
+		exact: op == "",
+	}, nil
+}
+
+func (c versionConstraint) allows(v *semver.Version) bool {
+	return !v.LessThan(c.min) && v.LessThan(c.max)
+}
+
+func (c versionConstraint) line() string {
+	return versionLine(c.min)
+}
+
+func (c versionConstraint) String() string {
+	return c.raw
+}
+
+// versionLine returns part of the version that changes on breaking changes.
+func versionLine(v *semver.Version) string {
+	if v.Major() == 0 {
+		return fmt.Sprintf("0.%d", v.Minor())
+	}
+	return fmt.Sprint(v.Major())
+}
+
+// nextLine returns the first version that is incompatible with the given one.
+func nextLine(v *semver.Version) *semver.Version {
+	if v.Major() == 0 {
+		return semver.New(0, v.Minor()+1, 0, "", "")
+	}
+	return semver.New(v.Major()+1, 0, 0, "", "")
+}
+
+// parseVersions parses tag names and skips those that are not versions.
+func parseVersions(tags []string) []*semver.Version {
+	versions := make([]*semver.Version, 0, len(tags))
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/nevalang/neva/internal/builder"
 	"github.com/nevalang/neva/internal/compiler"
@@ -39,6 +40,23 @@ func newBuildCmd(
 				Name:  "emit-trace",
 				Usage: "Emit trace.log file when running the program",
 			},
+			&cli.StringFlag{
+				Name:  "emit-trace-format",
+				Usage: "Format for trace file - text or json (JSON Lines)",
+			},
+			&cli.BoolFlag{
+				Name:  "emit-metrics",
+				Usage: "Write metrics.json and metrics.prom files when the program exits",
+			},
+			&cli.BoolFlag{
+				Name:  "assert",
+				Usage: "Check runtime invariants on every message and fail the program if they are violated",
+			},
+			&cli.DurationFlag{
+				Name:  "drain-timeout",
+				Usage: "How long the program can finish in-flight work after SIGINT or SIGTERM",
+				Value: 5 * time.Second,
+			},
 			&cli.StringFlag{
 				Name:  "target",
 				Usage: "Target platform for build (options: go, wasm, native, json, dot). For 'native' target, 'target-os' and 'target-arch' flags can be used, but if used, they must be used together.",
@@ -62,6 +80,7 @@ func newBuildCmd(
 				Name:  "target-ir-format",
 				Usage: "Format for ir file - yaml or json",
 			},
+			offlineFlag,
 		},
 		ArgsUsage: "Provide path to main package",
 		Action: func(cliCtx *cli.Context) error {
@@ -110,6 +129,11 @@ func newBuildCmd(
 				return fmt.Errorf("unknown target-ir-format: %s", irTargetFormat)
 			}
 
+			traceFormat, err := traceFormatFromFlags(cliCtx)
+			if err != nil {
+				return err
+			}
+
 			mainPkgPath, err := mainPkgPathFromArgs(cliCtx)
 			if err != nil {
 				return err
@@ -144,6 +168,8 @@ func newBuildCmd(
 				}
 			}()
 
+			bldr := builderFromFlags(cliCtx, bldr)
+
 			golangBackend := golang.NewBackend()
 
 			var compilerToUse compiler.Compiler
@@ -195,14 +221,23 @@ func newBuildCmd(
 				)
 			}
 
-			if _, err := compilerToUse.Compile(cliCtx.Context, compiler.CompilerInput{
+			out, err := compilerToUse.Compile(cliCtx.Context, compiler.CompilerInput{
 				MainPkgPath:   mainPkgPath,
 				OutputPath:    outputDirPath,
 				EmitTraceFile: cliCtx.IsSet("emit-trace"),
-			}); err != nil {
+				TraceFormat:   traceFormat,
+				EmitMetrics:   cliCtx.IsSet("emit-metrics"),
+				Assertions:    cliCtx.IsSet("assert"),
+				DrainTimeout:  cliCtx.Duration("drain-timeout"),
+			})
+			if err != nil {
 				return fmt.Errorf("failed to compile: %w", err)
 			}
 
+			for _, warning := range out.MiddleEnd.Warnings {
+				fmt.Fprintln(cliCtx.App.ErrWriter, warning)
+			}
+
 			return nil
 		},
 	}
@@ -10,6 +10,7 @@ import (
 	cli "github.com/urfave/cli/v2"
 
 	"github.com/nevalang/neva/internal/builder"
+	"github.com/nevalang/neva/internal/compiler"
 	"github.com/nevalang/neva/internal/compiler/analyzer"
 	"github.com/nevalang/neva/internal/compiler/desugarer"
 	"github.com/nevalang/neva/internal/compiler/irgen"
@@ -33,9 +34,12 @@ func NewApp(
 			upgradeCmd,
 			newNewCmd(workdir),
 			newGetCmd(workdir, bldr),
+			newModCmd(workdir, bldr),
 			newRunCmd(workdir, bldr, prsr, &desugarer, analyzer, irgen),
 			newBuildCmd(workdir, bldr, prsr, &desugarer, analyzer, irgen),
+			newFmtCmd(workdir),
 			newOSArchCmd(),
+			newTraceCmd(),
 		},
 	}
 }
@@ -70,6 +74,7 @@ func newGetCmd(workdir string, bldr builder.Builder) *cli.Command {
 		Usage:     "Add dependency to current module",
 		Args:      true,
 		ArgsUsage: "Provide path to the module",
+		Flags:     []cli.Flag{offlineFlag},
 		Action: func(cliCtx *cli.Context) error {
 			if cliCtx.Args().Len() != 2 {
 				return fmt.Errorf(
@@ -81,7 +86,7 @@ func newGetCmd(workdir string, bldr builder.Builder) *cli.Command {
 			path := cliCtx.Args().Get(0)
 			version := cliCtx.Args().Get(1)
 
-			installedPath, err := bldr.Get(workdir, path, version)
+			installedPath, err := builderFromFlags(cliCtx, bldr).Get(workdir, path, version)
 			if err != nil {
 				return fmt.Errorf("failed to get dependency: %w", err)
 			}
@@ -96,6 +101,24 @@ func newGetCmd(workdir string, bldr builder.Builder) *cli.Command {
 	}
 }
 
+func traceFormatFromFlags(cliCtx *cli.Context) (compiler.TraceFormat, error) {
+	if !cliCtx.IsSet("emit-trace-format") {
+		return compiler.TraceFormatText, nil
+	}
+
+	if !cliCtx.IsSet("emit-trace") {
+		return "", errors.New("emit-trace-format cannot be used without emit-trace")
+	}
+
+	format := compiler.TraceFormat(cliCtx.String("emit-trace-format"))
+	switch format {
+	case compiler.TraceFormatText, compiler.TraceFormatJSON:
+		return format, nil
+	}
+
+	return "", fmt.Errorf("unknown emit-trace-format: %s", format)
+}
+
 func mainPkgPathFromArgs(cCtx *cli.Context) (string, error) {
 	arg := cCtx.Args().First()
 
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+
+	"github.com/nevalang/neva/internal/compiler/formatter"
+)
+
+func newFmtCmd(workdir string) *cli.Command {
+	return &cli.Command{
+		Name:      "fmt",
+		Usage:     "Format source code files",
+		Args:      true,
+		ArgsUsage: "Provide paths to files or directories, current directory is used by default",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "check",
+				Usage: "Don't write files, list the ones that are not formatted and fail if there are any",
+			},
+		},
+		Action: func(cliCtx *cli.Context) error {
+			paths := cliCtx.Args().Slice()
+			if len(paths) == 0 {
+				paths = []string{workdir}
+			}
+
+			files, err := nevaFiles(workdir, paths)
+			if err != nil {
+				return err
+			}
+
+			check := cliCtx.Bool("check")
+
+			var (
+				problems    []string
+				unformatted int
+			)
+			for _, file := range files {
+				changed, err := formatFile(file, relativePath(workdir, file), check)
+				if err != nil {
+					problems = append(problems, err.Error())
+					continue
+				}
+				if !changed {
+					continue
+				}
+				unformatted++
+				if check {
+					fmt.Fprintln(cliCtx.App.Writer, relativePath(workdir, file))
+				}
+			}
+
+			if len(problems) != 0 {
+				return errors.New(strings.Join(problems, "\n"))
+			}
+
+			if check && unformatted != 0 {
+				return fmt.Errorf("%d file(s) are not formatted, run 'neva fmt' to fix", unformatted)
+			}
+
+			return nil
+		},
+	}
+}
+
+// formatFile formats the file and reports whether its content changed.
+// If check is true the file is not written. Name is used to refer to the file in errors.
+func formatFile(path, name string, check bool) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	formatted, compilerErr := formatter.Format(content)
+	if compilerErr != nil {
+		if compilerErr.Meta != nil {
+			return false, fmt.Errorf("%v:%v: %v", name, compilerErr.Meta.Start, compilerErr.Message)
+		}
+		return false, fmt.Errorf("%v: %v", name, compilerErr.Message)
+	}
+
+	if bytes.Equal(content, formatted) {
+		return false, nil
+	}
+
+	if check {
+		return true, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	return true, os.WriteFile(path, formatted, info.Mode().Perm())
+}
+
+// nevaFiles returns source code files at given paths, directories are walked recursively.
+func nevaFiles(workdir string, paths []string) ([]string, error) {
+	var files []string
+
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workdir, path)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		root := path
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// hidden directories like .git and vendored dependencies
+			if d.IsDir() && path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && filepath.Ext(path) == ".neva" {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+func relativePath(workdir, path string) string {
+	if rel, err := filepath.Rel(workdir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
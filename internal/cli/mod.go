@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+
+	cli "github.com/urfave/cli/v2"
+
+	"github.com/nevalang/neva/internal/builder"
+)
+
+// offlineFlag makes commands fail instead of downloading dependencies.
+var offlineFlag = &cli.BoolFlag{
+	Name:  "offline",
+	Usage: "Only use vendored and already downloaded dependencies, fail instead of accessing the network",
+}
+
+// builderFromFlags returns builder configured by the flags of the command.
+func builderFromFlags(cliCtx *cli.Context, bldr builder.Builder) builder.Builder {
+	if cliCtx.Bool("offline") {
+		return bldr.Offline()
+	}
+	return bldr
+}
+
+func newModCmd(workdir string, bldr builder.Builder) *cli.Command {
+	return &cli.Command{
+		Name:  "mod",
+		Usage: "Manage dependencies of current module",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "vendor",
+				Usage: "Copy dependencies into vendor directory that builds use instead of downloading them",
+				Flags: []cli.Flag{offlineFlag},
+				Action: func(cliCtx *cli.Context) error {
+					path, err := builderFromFlags(cliCtx, bldr).Vendor(cliCtx.Context, workdir)
+					if err != nil {
+						return fmt.Errorf("failed to vendor dependencies: %w", err)
+					}
+					fmt.Printf("dependencies vendored to %s\n", path)
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"syscall"
+	"time"
 
 	cli "github.com/urfave/cli/v2"
 
@@ -34,6 +37,23 @@ func newRunCmd(
 				Name:  "emit-trace",
 				Usage: "Write trace information to file",
 			},
+			&cli.StringFlag{
+				Name:  "emit-trace-format",
+				Usage: "Format for trace file - text or json (JSON Lines)",
+			},
+			&cli.BoolFlag{
+				Name:  "emit-metrics",
+				Usage: "Write metrics.json and metrics.prom files when the program exits",
+			},
+			&cli.BoolFlag{
+				Name:  "assert",
+				Usage: "Check runtime invariants on every message and fail the program if they are violated",
+			},
+			&cli.DurationFlag{
+				Name:  "drain-timeout",
+				Usage: "How long the program can finish in-flight work after SIGINT or SIGTERM",
+				Value: 5 * time.Second,
+			},
 			&cli.BoolFlag{
 				Name:  "emit-ir",
 				Usage: "Emit intermediate representation to ir.yml file",
@@ -42,6 +62,7 @@ func newRunCmd(
 				Name:  "emit-ir-format",
 				Usage: "Format for ir file - yaml or json",
 			},
+			offlineFlag,
 		},
 		ArgsUsage: "Provide path to main package",
 		Action: func(cliCtx *cli.Context) error {
@@ -65,6 +86,11 @@ func newRunCmd(
 				return fmt.Errorf("unknown emit-ir-format: %s", emitIRFormat)
 			}
 
+			traceFormat, err := traceFormatFromFlags(cliCtx)
+			if err != nil {
+				return err
+			}
+
 			// we need to always set GOOS for compiler backend
 			prevGOOS := os.Getenv("GOOS")
 			if err := os.Setenv("GOOS", runtime.GOOS); err != nil {
@@ -81,10 +107,16 @@ func newRunCmd(
 				return err
 			}
 
+			bldr := builderFromFlags(cliCtx, bldr)
+
 			input := compiler.CompilerInput{
 				MainPkgPath:   mainPkg,
 				OutputPath:    workdir,
 				EmitTraceFile: cliCtx.IsSet("emit-trace"),
+				TraceFormat:   traceFormat,
+				EmitMetrics:   cliCtx.IsSet("emit-metrics"),
+				Assertions:    cliCtx.IsSet("assert"),
+				DrainTimeout:  cliCtx.Duration("drain-timeout"),
 			}
 
 			compilerToNative := compiler.New(
@@ -103,10 +135,14 @@ func newRunCmd(
 				return err
 			}
 
+			for _, warning := range out.MiddleEnd.Warnings {
+				fmt.Fprintln(cliCtx.App.ErrWriter, warning)
+			}
+
 			irBackend := ir_backend.NewBackend(emitIRFormat)
 			// TODO refactor - trace is only used by golang and golang/native backends
 			// it should not be part of the compiler.Backend interface.
-			if err := irBackend.Emit(workdir, out.MiddleEnd.IR, false); err != nil {
+			if err := irBackend.Emit(workdir, out.MiddleEnd.IR, compiler.EmitOptions{}); err != nil {
 				return err
 			}
 
@@ -128,7 +164,33 @@ func newRunCmd(
 			cmd.Stdout = os.Stdout
 			cmd.Stderr = os.Stderr
 
-			if err := cmd.Run(); err != nil {
+			// generated program shuts down gracefully on signals, so we must outlive it.
+			// SIGINT from terminal is delivered to the whole process group, so only SIGTERM is forwarded.
+			sigs := make(chan os.Signal, 2)
+			signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+			defer func() {
+				signal.Stop(sigs)
+				close(sigs)
+			}()
+
+			if err := cmd.Start(); err != nil {
+				return fmt.Errorf("failed to run generated executable: %w", err)
+			}
+
+			go func() {
+				for sig := range sigs {
+					if sig == syscall.SIGTERM {
+						_ = cmd.Process.Signal(sig)
+					}
+				}
+			}()
+
+			if err := cmd.Wait(); err != nil {
+				// program already reported the error to stderr, so we only propagate its exit code
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					return cli.Exit("", exitErr.ExitCode())
+				}
 				return fmt.Errorf("failed to run generated executable: %w", err)
 			}
 
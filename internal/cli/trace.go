@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	cli "github.com/urfave/cli/v2"
+
+	"github.com/nevalang/neva/internal/trace"
+)
+
+func newTraceCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "trace",
+		Usage: "Inspect trace files emitted by programs built with --emit-trace",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "diff",
+				Usage:     "Report where message flows of two traces first diverge, per port",
+				Args:      true,
+				ArgsUsage: "Provide paths to two trace files",
+				Action: func(cliCtx *cli.Context) error {
+					if cliCtx.Args().Len() != 2 {
+						return fmt.Errorf("expected 2 arguments, got %d", cliCtx.Args().Len())
+					}
+
+					leftPath := cliCtx.Args().Get(0)
+					rightPath := cliCtx.Args().Get(1)
+
+					left, err := trace.Load(leftPath)
+					if err != nil {
+						return err
+					}
+
+					right, err := trace.Load(rightPath)
+					if err != nil {
+						return err
+					}
+
+					divergences := trace.Diff(left, right)
+					if len(divergences) == 0 {
+						fmt.Println("traces are equal")
+						return nil
+					}
+
+					printDivergences(leftPath, rightPath, divergences)
+
+					return errors.New("traces diverge")
+				},
+			},
+			{
+				Name:      "summary",
+				Usage:     "Count messages per port and per node",
+				Args:      true,
+				ArgsUsage: "Provide path to trace file",
+				Action: func(cliCtx *cli.Context) error {
+					if cliCtx.Args().Len() != 1 {
+						return fmt.Errorf("expected 1 argument, got %d", cliCtx.Args().Len())
+					}
+
+					events, err := trace.Load(cliCtx.Args().First())
+					if err != nil {
+						return err
+					}
+
+					printSummary(trace.Summarize(events))
+
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func printDivergences(leftPath, rightPath string, divergences []trace.Divergence) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "DIR\tPORT\tMSG #\t%v\t%v\n", leftPath, rightPath)
+	for _, d := range divergences {
+		fmt.Fprintf(
+			w,
+			"%v\t%v\t%d\t%v\t%v\n",
+			d.Key.Dir,
+			d.Key.Addr,
+			d.Position,
+			formatDivergedEvent(d.Left),
+			formatDivergedEvent(d.Right),
+		)
+	}
+}
+
+func formatDivergedEvent(event *trace.Event) string {
+	if event == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("%v (line %d)", event.Msg, event.Line)
+}
+
+func printSummary(summary trace.Summary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "PORT\tSENT\tRECEIVED")
+	for _, port := range summary.Ports {
+		fmt.Fprintf(w, "%v\t%d\t%d\n", port.Addr, port.Sent, port.Received)
+	}
+
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "NODE\tPORTS\tSENT\tRECEIVED")
+	for _, node := range summary.Nodes {
+		fmt.Fprintf(w, "%v\t%d\t%d\t%d\n", node.Node, node.Ports, node.Sent, node.Received)
+	}
+}
@@ -23,7 +23,9 @@ type Analyzer struct {
 	resolver ts.Resolver
 }
 
-func (a Analyzer) AnalyzeExecutableBuild(build src.Build, mainPkgName string) (src.Build, *compiler.Error) {
+// AnalyzeExecutableBuild analyzes the build and checks that it has valid main package.
+// Returned errors can contain warnings even if analysis succeeded, use HasErrors to check.
+func (a Analyzer) AnalyzeExecutableBuild(build src.Build, mainPkgName string) (src.Build, compiler.Errors) {
 	meta := core.Meta{
 		Location: core.Location{
 			ModRef:  build.EntryModRef,
@@ -33,45 +35,52 @@ func (a Analyzer) AnalyzeExecutableBuild(build src.Build, mainPkgName string) (s
 
 	entryMod, ok := build.Modules[build.EntryModRef]
 	if !ok {
-		return src.Build{}, &compiler.Error{
+		return src.Build{}, compiler.Errors{{
 			Message: fmt.Sprintf("entry module not found: %s", build.EntryModRef),
 			Meta:    &meta,
-		}
+		}}
 	}
 
 	if _, ok := entryMod.Packages[mainPkgName]; !ok {
-		return src.Build{}, &compiler.Error{
+		return src.Build{}, compiler.Errors{{
 			Message: "main package not found",
 			Meta:    &meta,
-		}
+		}}
 	}
 
 	scope := src.NewScope(build, meta.Location)
 
+	// main package and the rest of the build are checked independently to report as many problems as possible
+	var errs compiler.Errors
 	if err := a.mainSpecificPkgValidation(mainPkgName, entryMod, scope); err != nil {
-		return src.Build{}, compiler.Error{Meta: &meta}.Wrap(err)
+		errs = append(errs, compiler.Error{Meta: &meta}.Wrap(err))
 	}
 
-	analyzedBuild, err := a.AnalyzeBuild(build)
-	if err != nil {
-		return src.Build{}, compiler.Error{Meta: &meta}.Wrap(err)
+	analyzedBuild, buildErrs := a.AnalyzeBuild(build)
+	errs = append(errs, buildErrs.Wrap(compiler.Error{Meta: &meta})...)
+	errs.Sort()
+
+	if errs.HasErrors() {
+		return src.Build{}, errs
 	}
 
-	return analyzedBuild, nil
+	return analyzedBuild, errs
 }
 
-func (a Analyzer) AnalyzeBuild(build src.Build) (src.Build, *compiler.Error) {
+// AnalyzeBuild analyzes every module of the build.
+// Returned errors can contain warnings even if analysis succeeded, use HasErrors to check.
+func (a Analyzer) AnalyzeBuild(build src.Build) (src.Build, compiler.Errors) {
 	analyzedMods := make(map[core.ModuleRef]src.Module, len(build.Modules))
 
+	var errs compiler.Errors
 	for modRef, mod := range build.Modules {
-		if err := a.semverCheck(mod, modRef); err != nil {
-			return src.Build{}, err
+		if err := a.AnalyzeModuleManifest(modRef, build); err != nil {
+			errs = append(errs, err)
+			continue
 		}
 
-		analyzedPkgs, err := a.analyzeModule(modRef, build)
-		if err != nil {
-			return src.Build{}, err
-		}
+		analyzedPkgs, modErrs := a.analyzeModule(modRef, build)
+		errs = append(errs, modErrs...)
 
 		analyzedMods[modRef] = src.Module{
 			Manifest: mod.Manifest,
@@ -79,15 +88,26 @@ func (a Analyzer) AnalyzeBuild(build src.Build) (src.Build, *compiler.Error) {
 		}
 	}
 
+	if errs.HasErrors() {
+		return src.Build{}, errs
+	}
+
 	return src.Build{
 		EntryModRef: build.EntryModRef,
 		Modules:     analyzedMods,
-	}, nil
+	}, errs
 }
 
-func (a Analyzer) analyzeModule(modRef core.ModuleRef, build src.Build) (map[string]src.Package, *compiler.Error) {
+// AnalyzeModuleManifest does checks of the module that don't depend on its source code.
+func (a Analyzer) AnalyzeModuleManifest(modRef core.ModuleRef, build src.Build) *compiler.Error {
+	mod := build.Modules[modRef]
+
+	if err := a.semverCheck(mod, modRef); err != nil {
+		return err
+	}
+
 	if modRef != build.EntryModRef && modRef.Version == "" {
-		return nil, &compiler.Error{
+		return &compiler.Error{
 			Message: "every dependency module must have version",
 			Meta: &core.Meta{
 				Location: core.Location{
@@ -97,52 +117,72 @@ func (a Analyzer) analyzeModule(modRef core.ModuleRef, build src.Build) (map[str
 		}
 	}
 
-	location := core.Location{ModRef: modRef}
-	mod := build.Modules[modRef]
-
 	if len(mod.Packages) == 0 {
-		return nil, &compiler.Error{
+		return &compiler.Error{
 			Message: "module must contain at least one package",
 			Meta: &core.Meta{
-				Location: location,
+				Location: core.Location{
+					ModRef: modRef,
+				},
 			},
 		}
 	}
 
+	return nil
+}
+
+func (a Analyzer) analyzeModule(modRef core.ModuleRef, build src.Build) (map[string]src.Package, compiler.Errors) {
+	mod := build.Modules[modRef]
+
 	pkgsCopy := make(map[string]src.Package, len(mod.Packages))
 	maps.Copy(pkgsCopy, mod.Packages)
 
-	for pkgName, pkg := range pkgsCopy {
-		scope := src.NewScope(build, core.Location{
-			ModRef:  modRef,
-			Package: pkgName,
-		})
+	var errs compiler.Errors
+	for pkgName := range pkgsCopy {
+		resolvedPkg, pkgErrs := a.AnalyzePackage(modRef, pkgName, build)
+		errs = append(errs, pkgErrs...)
+		pkgsCopy[pkgName] = resolvedPkg
+	}
 
-		resolvedPkg, err := a.analyzePkg(pkg, scope)
-		if err != nil {
-			return nil, compiler.Error{
-				Meta: &core.Meta{
-					Location: core.Location{
-						Package: pkgName,
-					},
-				},
-			}.Wrap(err)
-		}
+	return pkgsCopy, errs
+}
 
-		pkgsCopy[pkgName] = resolvedPkg
+// AnalyzePackage analyzes single package of the build.
+// Package analysis depends only on the parsed build and not on the analysis of other packages,
+// so it's possible to re-analyze only packages affected by a change.
+// Warnings are only reported for packages of the entry module.
+func (a Analyzer) AnalyzePackage(modRef core.ModuleRef, pkgName string, build src.Build) (src.Package, compiler.Errors) {
+	scope := src.NewScope(build, core.Location{
+		ModRef:  modRef,
+		Package: pkgName,
+	})
+
+	pkg := build.Modules[modRef].Packages[pkgName]
+
+	resolvedPkg, errs := a.analyzePkg(pkg, scope)
+
+	if modRef == build.EntryModRef {
+		errs = append(errs, a.unusedImports(pkg, scope)...)
 	}
 
-	return pkgsCopy, nil
+	return resolvedPkg, errs.Wrap(compiler.Error{
+		Meta: &core.Meta{
+			Location: core.Location{
+				Package: pkgName,
+			},
+		},
+	})
 }
 
-func (a Analyzer) analyzePkg(pkg src.Package, scope src.Scope) (src.Package, *compiler.Error) {
+// analyzePkg analyzes every entity of the package independently and returns errors of all of them.
+func (a Analyzer) analyzePkg(pkg src.Package, scope src.Scope) (src.Package, compiler.Errors) {
 	if len(pkg) == 0 {
-		return nil, &compiler.Error{
+		return nil, compiler.Errors{{
 			Message: "package must contain at least one file",
 			Meta: &core.Meta{
 				Location: *scope.Location(),
 			},
-		}
+		}}
 	}
 
 	// preallocate
@@ -154,6 +194,7 @@ func (a Analyzer) analyzePkg(pkg src.Package, scope src.Scope) (src.Package, *co
 		}
 	}
 
+	var errs compiler.Errors
 	for result := range pkg.Entities() {
 		relocatedScope := scope.Relocate(core.Location{
 			ModRef:   scope.Location().ModRef,
@@ -161,20 +202,25 @@ func (a Analyzer) analyzePkg(pkg src.Package, scope src.Scope) (src.Package, *co
 			Filename: result.FileName,
 		})
 
-		analyzedEntity, err := a.analyzeEntity(result.Entity, relocatedScope)
-		if err != nil {
-			return nil, compiler.Error{
+		analyzedEntity, entityErrs := a.analyzeEntity(result.Entity, relocatedScope)
+		if len(entityErrs) != 0 {
+			errs = append(errs, entityErrs.Wrap(compiler.Error{
 				Meta: result.Entity.Meta(),
-			}.Wrap(err)
+			})...)
+			continue
 		}
 
 		analyzedFiles[result.FileName].Entities[result.EntityName] = analyzedEntity
 	}
 
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
 	return analyzedFiles, nil
 }
 
-func (a Analyzer) analyzeEntity(entity src.Entity, scope src.Scope) (src.Entity, *compiler.Error) {
+func (a Analyzer) analyzeEntity(entity src.Entity, scope src.Scope) (src.Entity, compiler.Errors) {
 	resolvedEntity := src.Entity{
 		IsPublic: entity.IsPublic,
 		Kind:     entity.Kind,
@@ -187,18 +233,18 @@ func (a Analyzer) analyzeEntity(entity src.Entity, scope src.Scope) (src.Entity,
 		resolvedTypeDef, err := a.analyzeTypeDef(entity.Type, scope, analyzeTypeDefParams{allowEmptyBody: isStd})
 		if err != nil {
 			meta := entity.Type.Meta
-			return src.Entity{}, compiler.Error{
+			return src.Entity{}, compiler.Errors{compiler.Error{
 				Meta: &meta,
-			}.Wrap(err)
+			}.Wrap(err)}
 		}
 		resolvedEntity.Type = resolvedTypeDef
 	case src.ConstEntity:
 		resolvedConst, err := a.analyzeConst(entity.Const, scope)
 		if err != nil {
 			meta := entity.Const.Meta
-			return src.Entity{}, compiler.Error{
+			return src.Entity{}, compiler.Errors{compiler.Error{
 				Meta: &meta,
-			}.Wrap(err)
+			}.Wrap(err)}
 		}
 		resolvedEntity.Const = resolvedConst
 	case src.InterfaceEntity:
@@ -208,24 +254,24 @@ func (a Analyzer) analyzeEntity(entity src.Entity, scope src.Scope) (src.Entity,
 		})
 		if err != nil {
 			meta := entity.Interface.Meta
-			return src.Entity{}, compiler.Error{
+			return src.Entity{}, compiler.Errors{compiler.Error{
 				Meta: &meta,
-			}.Wrap(err)
+			}.Wrap(err)}
 		}
 		resolvedEntity.Interface = resolvedInterface
 	case src.ComponentEntity:
-		analyzedComponent, err := a.analyzeComponent(entity.Component, scope)
-		if err != nil {
-			return src.Entity{}, compiler.Error{
+		analyzedComponent, errs := a.analyzeComponent(entity.Component, scope)
+		if len(errs) != 0 {
+			return src.Entity{}, errs.Wrap(compiler.Error{
 				Meta: &entity.Component.Meta,
-			}.Wrap(err)
+			})
 		}
 		resolvedEntity.Component = analyzedComponent
 	default:
-		return src.Entity{}, &compiler.Error{
+		return src.Entity{}, compiler.Errors{{
 			Message: fmt.Sprintf("unknown entity kind: %v", entity.Kind),
 			Meta:    entity.Meta(),
-		}
+		}}
 	}
 
 	return resolvedEntity, nil
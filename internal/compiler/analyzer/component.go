@@ -1,37 +1,56 @@
 package analyzer
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/nevalang/neva/internal/compiler"
 	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+	"github.com/nevalang/neva/internal/runtime/funcs"
 )
 
+// runtimeFuncs is used to check that every #extern refers to existing runtime function,
+// otherwise program would compile but fail at startup.
+var runtimeFuncs = funcs.NewRegistry()
+
+// analyzeComponent returns errors of all the nodes or, if nodes are valid, of all the connections.
 func (a Analyzer) analyzeComponent(
 	component src.Component,
 	scope src.Scope,
-) (src.Component, *compiler.Error) {
+) (src.Component, compiler.Errors) {
 	runtimeFuncArgs, isRuntimeFunc := component.Directives[compiler.ExternDirective]
 
 	if isRuntimeFunc && len(runtimeFuncArgs) == 0 {
-		return src.Component{}, &compiler.Error{
+		return src.Component{}, compiler.Errors{{
 			Message: "Component that use #extern directive must provide at least one argument",
 			Meta:    &component.Meta,
-		}
+		}}
 	}
 
 	if len(runtimeFuncArgs) > 1 {
 		for _, runtimeFuncArg := range runtimeFuncArgs {
 			parts := strings.Split(runtimeFuncArg, " ")
 			if len(parts) != 2 {
-				return src.Component{}, &compiler.Error{
+				return src.Component{}, compiler.Errors{{
 					Message: "Component that use #extern with more than one argument must provide arguments in a form of <type, flow_ref> pairs",
 					Meta:    &component.Meta,
-				}
+				}}
 			}
 		}
 	}
 
+	for _, runtimeFuncArg := range runtimeFuncArgs {
+		parts := strings.Split(runtimeFuncArg, " ")
+		funcRef := parts[len(parts)-1]
+		if _, ok := runtimeFuncs[funcRef]; !ok {
+			return src.Component{}, compiler.Errors{{
+				Message: fmt.Sprintf("Runtime function not found: %v", funcRef),
+				Meta:    &component.Meta,
+			}}
+		}
+	}
+
 	resolvedInterface, err := a.analyzeInterface(
 		component.Interface,
 		scope,
@@ -41,40 +60,40 @@ func (a Analyzer) analyzeComponent(
 		},
 	)
 	if err != nil {
-		return src.Component{}, compiler.Error{
+		return src.Component{}, compiler.Errors{compiler.Error{
 			Meta: &component.Meta,
-		}.Wrap(err)
+		}.Wrap(err)}
 	}
 
 	if isRuntimeFunc {
 		if len(component.Nodes) != 0 || len(component.Net) != 0 {
-			return src.Component{}, &compiler.Error{
+			return src.Component{}, compiler.Errors{{
 				Message: "Component with nodes or network cannot use #extern directive",
 				Meta:    &component.Meta,
-			}
+			}}
 		}
 		return component, nil
 	}
 
-	resolvedNodes, nodesIfaces, hasGuard, err := a.analyzeNodes(
+	resolvedNodes, nodesIfaces, hasGuard, errs := a.analyzeNodes(
 		component.Interface,
 		component.Nodes,
 		scope,
 	)
-	if err != nil {
-		return src.Component{}, compiler.Error{
+	if len(errs) != 0 {
+		return src.Component{}, errs.Wrap(compiler.Error{
 			Meta: &component.Meta,
-		}.Wrap(err)
+		})
 	}
 
 	if len(component.Net) == 0 {
-		return src.Component{}, &compiler.Error{
+		return src.Component{}, compiler.Errors{{
 			Message: "Component must have network",
 			Meta:    &component.Meta,
-		}
+		}}
 	}
 
-	analyzedNet, err := a.analyzeNetwork(
+	analyzedNet, errs := a.analyzeNetwork(
 		component.Net,
 		resolvedInterface,
 		hasGuard,
@@ -82,10 +101,10 @@ func (a Analyzer) analyzeComponent(
 		nodesIfaces,
 		scope,
 	)
-	if err != nil {
-		return src.Component{}, compiler.Error{
+	if len(errs) != 0 {
+		return src.Component{}, errs.Wrap(compiler.Error{
 			Meta: &component.Meta,
-		}.Wrap(err)
+		})
 	}
 
 	return src.Component{
@@ -95,3 +114,28 @@ func (a Analyzer) analyzeComponent(
 		Meta:      component.Meta,
 	}, nil
 }
+
+// analyzeNodeExtern checks that runtime function of the node can be chosen by its first type argument,
+// if component's #extern directive maps types to functions.
+func (Analyzer) analyzeNodeExtern(
+	component src.Component,
+	node src.Node,
+	resolvedTypeArgs []ts.Expr,
+) *compiler.Error {
+	runtimeFuncArgs := component.Directives[compiler.ExternDirective]
+	if len(runtimeFuncArgs) < 2 || len(resolvedTypeArgs) == 0 || resolvedTypeArgs[0].Inst == nil {
+		return nil
+	}
+
+	typeArg := resolvedTypeArgs[0].Inst.Ref.String()
+	for _, runtimeFuncArg := range runtimeFuncArgs {
+		if strings.Split(runtimeFuncArg, " ")[0] == typeArg {
+			return nil
+		}
+	}
+
+	return &compiler.Error{
+		Message: fmt.Sprintf("%v has no runtime function for type argument %v", node.EntityRef, typeArg),
+		Meta:    &node.Meta,
+	}
+}
@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nevalang/neva/internal/compiler"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+)
+
+func TestAnalyzeComponentExtern(t *testing.T) {
+	tests := []struct {
+		name   string
+		extern []string
+		err    string
+	}{
+		{
+			name:   "unknown_func",
+			extern: []string{"nonexistent"},
+			err:    "Runtime function not found: nonexistent",
+		},
+		{
+			name:   "unknown_func_of_type",
+			extern: []string{"int int_inc", "float nonexistent"},
+			err:    "Runtime function not found: nonexistent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := Analyzer{}.analyzeComponent(src.Component{
+				Directives: map[src.Directive][]string{compiler.ExternDirective: tt.extern},
+			}, src.Scope{})
+			require.Len(t, errs, 1)
+			require.Equal(t, tt.err, errs[0].Message)
+		})
+	}
+}
+
+func TestAnalyzeNodeExtern(t *testing.T) {
+	component := src.Component{
+		Directives: map[src.Directive][]string{
+			compiler.ExternDirective: {"int int_inc", "float float_inc"},
+		},
+	}
+	node := src.Node{EntityRef: core.EntityRef{Name: "Inc"}}
+	inst := func(name string) []ts.Expr {
+		return []ts.Expr{{Inst: &ts.InstExpr{Ref: core.EntityRef{Name: name}}}}
+	}
+
+	require.Nil(t, Analyzer{}.analyzeNodeExtern(component, node, inst("int")))
+	require.Nil(t, Analyzer{}.analyzeNodeExtern(component, node, inst("float")))
+
+	err := Analyzer{}.analyzeNodeExtern(component, node, inst("string"))
+	require.NotNil(t, err)
+	require.Equal(t, "Inc has no runtime function for type argument string", err.Message)
+}
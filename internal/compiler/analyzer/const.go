@@ -51,10 +51,22 @@ func (a Analyzer) analyzeConst(
 	}
 
 	if resolvedType.Lit != nil && resolvedType.Lit.Union != nil {
-		return src.Const{}, &compiler.Error{
-			Message: "Constant cannot have type union",
-			Meta:    &constant.Meta,
+		if constant.Value.Message.Union != nil { // already analyzed
+			return src.Const{
+				TypeExpr: resolvedType,
+				Value:    constant.Value,
+				Meta:     constant.Meta,
+			}, nil
+		}
+		unionMsg, err := a.analyzeUnionLiteral(*constant.Value.Message, resolvedType.Lit.Union, scope)
+		if err != nil {
+			return src.Const{}, compiler.Error{Meta: &constant.Meta}.Wrap(err)
 		}
+		return src.Const{
+			TypeExpr: resolvedType,
+			Value:    src.ConstValue{Message: unionMsg},
+			Meta:     constant.Meta,
+		}, nil
 	}
 
 	var typeExprStrRepr string
@@ -142,7 +154,7 @@ func (a Analyzer) analyzeConst(
 				Meta: &constant.Meta,
 			}
 		}
-	case "string":
+	case "string", "bytes": // bytes are written as string literals
 		if constant.Value.Message.Str == nil {
 			return src.Const{}, &compiler.Error{
 				Message: fmt.Sprintf("String value is missing in string contant: %v", constant),
@@ -0,0 +1,211 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nevalang/neva/internal/compiler"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+)
+
+// unusedImports returns warnings for imports that are not referenced by the file.
+func (a Analyzer) unusedImports(pkg src.Package, scope src.Scope) compiler.Errors {
+	var warnings compiler.Errors
+
+	for fileName, file := range pkg {
+		if len(file.Imports) == 0 {
+			continue
+		}
+
+		used := importsUsage{}
+		for _, entity := range file.Entities {
+			used.entity(entity)
+		}
+
+		aliases := make([]string, 0, len(file.Imports))
+		for alias := range file.Imports {
+			if _, ok := used[alias]; !ok {
+				aliases = append(aliases, alias)
+			}
+		}
+		sort.Strings(aliases)
+
+		for _, alias := range aliases {
+			meta := file.Imports[alias].Meta
+			meta.Location = core.Location{
+				ModRef:   scope.Location().ModRef,
+				Package:  scope.Location().Package,
+				Filename: fileName,
+			}
+			warnings = append(warnings, &compiler.Error{
+				Message:  fmt.Sprintf("Unused import: %v", alias),
+				Meta:     &meta,
+				Severity: compiler.SeverityWarning,
+			})
+		}
+	}
+
+	return warnings
+}
+
+// importsUsage is a set of package aliases that entities of the file refer to.
+type importsUsage map[string]struct{}
+
+func (u importsUsage) entity(entity src.Entity) {
+	switch entity.Kind {
+	case src.TypeEntity:
+		u.typeParams(entity.Type.Params)
+		if entity.Type.BodyExpr != nil {
+			u.typeExpr(*entity.Type.BodyExpr)
+		}
+	case src.ConstEntity:
+		u.typeExpr(entity.Const.TypeExpr)
+		u.constValue(entity.Const.Value)
+	case src.InterfaceEntity:
+		u.iface(entity.Interface)
+	case src.ComponentEntity:
+		u.iface(entity.Component.Interface)
+		u.directives(entity.Component.Directives)
+		for _, node := range entity.Component.Nodes {
+			u.node(node)
+		}
+		for _, conn := range entity.Component.Net {
+			u.connection(conn)
+		}
+	}
+}
+
+func (u importsUsage) iface(iface src.Interface) {
+	u.typeParams(iface.TypeParams.Params)
+	for _, port := range iface.IO.In {
+		u.typeExpr(port.TypeExpr)
+	}
+	for _, port := range iface.IO.Out {
+		u.typeExpr(port.TypeExpr)
+	}
+}
+
+func (u importsUsage) node(node src.Node) {
+	u.ref(node.EntityRef)
+	for _, arg := range node.TypeArgs {
+		u.typeExpr(arg)
+	}
+	for _, dep := range node.DIArgs {
+		u.node(dep)
+	}
+	u.directives(node.Directives)
+}
+
+// directives marks imports referred by directive arguments, like `#bind(pkg.foo)` or `#buffer(pkg.size)`.
+func (u importsUsage) directives(directives map[src.Directive][]string) {
+	for _, args := range directives {
+		for _, arg := range args {
+			for _, field := range strings.Fields(arg) {
+				if alias, _, ok := strings.Cut(field, "."); ok {
+					u[alias] = struct{}{}
+				}
+			}
+		}
+	}
+}
+
+func (u importsUsage) connection(conn src.Connection) {
+	if conn.Normal == nil {
+		return
+	}
+	for _, sender := range conn.Normal.Senders {
+		u.sender(sender)
+	}
+	u.receivers(conn.Normal.Receivers)
+}
+
+func (u importsUsage) sender(sender src.ConnectionSender) {
+	switch {
+	case sender.PortAddr != nil, sender.Range != nil, len(sender.StructSelector) > 0:
+		// port addresses, ranges and selectors refer to local nodes and struct fields, not to entities
+	case sender.Const != nil:
+		u.typeExpr(sender.Const.TypeExpr)
+		u.constValue(sender.Const.Value)
+	case sender.Unary != nil:
+		u.sender(sender.Unary.Operand)
+	case sender.Binary != nil:
+		u.sender(sender.Binary.Left)
+		u.sender(sender.Binary.Right)
+	case sender.Ternary != nil:
+		u.sender(sender.Ternary.Condition)
+		u.sender(sender.Ternary.Left)
+		u.sender(sender.Ternary.Right)
+	}
+}
+
+func (u importsUsage) receivers(receivers []src.ConnectionReceiver) {
+	for _, receiver := range receivers {
+		switch {
+		case receiver.DeferredConnection != nil:
+			u.connection(*receiver.DeferredConnection)
+		case receiver.ChainedConnection != nil:
+			u.connection(*receiver.ChainedConnection)
+		case receiver.Switch != nil:
+			for _, switchCase := range receiver.Switch.Cases {
+				for _, sender := range switchCase.Senders {
+					u.sender(sender)
+				}
+				u.receivers(switchCase.Receivers)
+			}
+			u.receivers(receiver.Switch.Default)
+		}
+	}
+}
+
+func (u importsUsage) typeParams(params []ts.Param) {
+	for _, param := range params {
+		u.typeExpr(param.Constr)
+	}
+}
+
+func (u importsUsage) typeExpr(expr ts.Expr) {
+	if expr.Inst != nil {
+		u.ref(expr.Inst.Ref)
+		for _, arg := range expr.Inst.Args {
+			u.typeExpr(arg)
+		}
+	}
+	if expr.Lit != nil {
+		for _, field := range expr.Lit.Struct {
+			u.typeExpr(field)
+		}
+		for _, el := range expr.Lit.Union {
+			u.typeExpr(el)
+		}
+	}
+}
+
+func (u importsUsage) constValue(value src.ConstValue) {
+	if value.Ref != nil {
+		u.ref(*value.Ref)
+	}
+	if value.Message == nil {
+		return
+	}
+	if value.Message.Enum != nil {
+		u.ref(value.Message.Enum.EnumRef)
+	}
+	for _, item := range value.Message.List {
+		u.constValue(item)
+	}
+	for _, field := range value.Message.DictOrStruct {
+		u.constValue(field)
+	}
+	if value.Message.Union != nil && value.Message.Union.Data != nil {
+		u.constValue(*value.Message.Union.Data)
+	}
+}
+
+func (u importsUsage) ref(ref core.EntityRef) {
+	if ref.Pkg != "" {
+		u[ref.Pkg] = struct{}{}
+	}
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nevalang/neva/internal/compiler"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+)
+
+func TestImportsUsage(t *testing.T) {
+	component := src.Entity{
+		Kind: src.ComponentEntity,
+		Component: src.Component{
+			Nodes: map[string]src.Node{
+				"printer": {
+					Directives: map[src.Directive][]string{
+						compiler.BufferDirective: {"config.size"},
+						compiler.BindDirective:   {"greetings.hello"},
+					},
+					EntityRef: core.EntityRef{Pkg: "fmt", Name: "Println"},
+				},
+			},
+			Net: []src.Connection{
+				{
+					Normal: &src.NormalConnection{
+						Senders: []src.ConnectionSender{
+							{Range: &src.Range{From: 0, To: 10}},
+							{StructSelector: []string{"user", "name"}},
+							{PortAddr: &src.PortAddr{Node: "strings"}}, // node name, not import
+							{Const: &src.Const{Value: src.ConstValue{Ref: &core.EntityRef{Pkg: "consts", Name: "x"}}}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	used := importsUsage{}
+	used.entity(component)
+
+	require.Equal(t, importsUsage{
+		"config":    {},
+		"greetings": {},
+		"fmt":       {},
+		"consts":    {},
+	}, used)
+}
@@ -3,6 +3,8 @@ package analyzer
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/nevalang/neva/internal/compiler"
 	src "github.com/nevalang/neva/internal/compiler/sourcecode"
@@ -22,10 +24,10 @@ func (a Analyzer) analyzeNetwork(
 	nodes map[string]src.Node,
 	nodesIfaces map[string]foundInterface,
 	scope src.Scope,
-) ([]src.Connection, *compiler.Error) {
+) ([]src.Connection, compiler.Errors) {
 	nodesUsage := make(map[string]netNodeUsage, len(nodes))
 
-	analyzedConnections, err := a.analyzeConnections(
+	analyzedConnections, errs := a.analyzeConnections(
 		net,
 		compInterface,
 		nodes,
@@ -33,25 +35,26 @@ func (a Analyzer) analyzeNetwork(
 		nodesUsage,
 		scope,
 	)
-	if err != nil {
-		return nil, err
+	if len(errs) != 0 {
+		// usage of the ports is unknown if some connections are invalid
+		return nil, errs
 	}
 
-	if err := a.analyzeNetPortsUsage(
+	if errs := a.analyzeNetPortsUsage(
 		compInterface,
 		nodesIfaces,
 		hasGuard,
 		nodesUsage,
 		nodes,
-	); err != nil {
-		return nil, err
+	); len(errs) != 0 {
+		return nil, errs
 	}
 
 	return analyzedConnections, nil
 }
 
 // analyzeConnections does two things:
-// 1. Analyzes every connection and terminates with non-nil error if any of them is invalid.
+// 1. Analyzes every connection and returns errors of all the invalid ones.
 // 2. Updates nodesUsage (we mutate it in-place instead of returning to avoid merging across recursive calls).
 func (a Analyzer) analyzeConnections(
 	net []src.Connection,
@@ -60,9 +63,10 @@ func (a Analyzer) analyzeConnections(
 	nodesIfaces map[string]foundInterface,
 	nodesUsage map[string]netNodeUsage,
 	scope src.Scope,
-) ([]src.Connection, *compiler.Error) {
+) ([]src.Connection, compiler.Errors) {
 	analyzedConnections := make([]src.Connection, 0, len(net))
 
+	var errs compiler.Errors
 	for _, conn := range net {
 		resolvedConn, err := a.analyzeConnection(
 			conn,
@@ -74,11 +78,16 @@ func (a Analyzer) analyzeConnections(
 			nil,
 		)
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
+			continue
 		}
 		analyzedConnections = append(analyzedConnections, resolvedConn)
 	}
 
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
 	return analyzedConnections, nil
 }
 
@@ -197,6 +206,22 @@ func (a Analyzer) analyzeReceiverSide(
 		analyzedReceivers = append(analyzedReceivers, *analyzedReceiver)
 	}
 
+	// messages of different senders can only be wrapped separately
+	// when they are not sent to other receivers at the same time
+	if len(analyzedReceivers) > 1 {
+		for _, receiver := range analyzedReceivers {
+			if receiver.UnionWrap == nil {
+				continue
+			}
+			if _, ok := receiver.UnionWrap.Tag(); !ok {
+				return nil, &compiler.Error{
+					Message: "Senders of different union elements can't be connected to several receivers at once",
+					Meta:    &receiver.Meta,
+				}
+			}
+		}
+	}
+
 	return analyzedReceivers, nil
 }
 
@@ -212,7 +237,7 @@ func (a Analyzer) analyzeReceiver(
 ) (*src.ConnectionReceiver, *compiler.Error) {
 	switch {
 	case receiver.PortAddr != nil:
-		err := a.analyzePortAddrReceiver(
+		unionWrap, err := a.analyzePortAddrReceiver(
 			*receiver.PortAddr,
 			scope,
 			iface,
@@ -226,11 +251,12 @@ func (a Analyzer) analyzeReceiver(
 			return nil, err
 		}
 		return &src.ConnectionReceiver{
-			PortAddr: receiver.PortAddr, // no need to change anything
-			Meta:     receiver.Meta,
+			PortAddr:  receiver.PortAddr, // no need to change anything
+			Meta:      receiver.Meta,
+			UnionWrap: unionWrap,
 		}, nil
 	case receiver.ChainedConnection != nil:
-		analyzedChainedConn, err := a.analyzeChainedConnectionReceiver(
+		analyzedChainedConn, unionWrap, err := a.analyzeChainedConnectionReceiver(
 			*receiver.ChainedConnection,
 			scope,
 			iface,
@@ -245,6 +271,7 @@ func (a Analyzer) analyzeReceiver(
 		}
 		return &src.ConnectionReceiver{
 			ChainedConnection: &analyzedChainedConn,
+			UnionWrap:         unionWrap,
 		}, nil
 	case receiver.DeferredConnection != nil:
 		analyzedDeferredConn, err := a.analyzeConnection(
@@ -388,7 +415,7 @@ func (a Analyzer) analyzePortAddrReceiver(
 	nodesUsage map[string]netNodeUsage,
 	resolvedSenderTypes []*ts.Expr,
 	analyzedSenders []src.ConnectionSender,
-) *compiler.Error {
+) (*src.UnionWrap, *compiler.Error) {
 	resolvedPortAddr, typeExpr, isArrPort, err := a.getReceiverPortType(
 		portAddr,
 		iface,
@@ -397,20 +424,20 @@ func (a Analyzer) analyzePortAddrReceiver(
 		scope,
 	)
 	if err != nil {
-		return compiler.Error{
+		return nil, compiler.Error{
 			Meta: &portAddr.Meta,
 		}.Wrap(err)
 	}
 
 	if !isArrPort && portAddr.Idx != nil {
-		return &compiler.Error{
+		return nil, &compiler.Error{
 			Message: "Index for non-array port",
 			Meta:    &portAddr.Meta,
 		}
 	}
 
 	if isArrPort && portAddr.Idx == nil {
-		return &compiler.Error{
+		return nil, &compiler.Error{
 			Message: "Index needed for array inport",
 			Meta:    &portAddr.Meta,
 		}
@@ -418,7 +445,7 @@ func (a Analyzer) analyzePortAddrReceiver(
 
 	for i, resolvedSenderType := range resolvedSenderTypes {
 		if err := a.resolver.IsSubtypeOf(*resolvedSenderType, typeExpr, scope); err != nil {
-			return &compiler.Error{
+			return nil, &compiler.Error{
 				Message: fmt.Sprintf(
 					"Incompatible types: %v -> %v: %v",
 					analyzedSenders[i], portAddr, err.Error(),
@@ -428,17 +455,24 @@ func (a Analyzer) analyzePortAddrReceiver(
 		}
 	}
 
+	unionWrap, err := a.getUnionWrap(resolvedSenderTypes, typeExpr, iface.TypeParams.Params, scope)
+	if err != nil {
+		return nil, compiler.Error{
+			Meta: &portAddr.Meta,
+		}.Wrap(err)
+	}
+
 	// sometimes port name is omitted and we need to resolve it first
 	// but it's important not to return it, so syntax sugar remains untouched
 	// otherwise desugarer won't be able to properly desugar such port-addresses
 	if err := netNodesUsage(nodesUsage).trackInportUsage(resolvedPortAddr); err != nil {
-		return &compiler.Error{
+		return nil, &compiler.Error{
 			Message: err.Error(),
 			Meta:    &portAddr.Meta,
 		}
 	}
 
-	return nil
+	return unionWrap, nil
 }
 
 func (a Analyzer) analyzeChainedConnectionReceiver(
@@ -450,16 +484,16 @@ func (a Analyzer) analyzeChainedConnectionReceiver(
 	nodesUsage map[string]netNodeUsage,
 	resolvedSenderTypes []*ts.Expr,
 	analyzedSenders []src.ConnectionSender,
-) (src.Connection, *compiler.Error) {
+) (src.Connection, *src.UnionWrap, *compiler.Error) {
 	if chainedConn.Normal == nil {
-		return src.Connection{}, &compiler.Error{
+		return src.Connection{}, nil, &compiler.Error{
 			Message: "chained connection must be a normal connection",
 			Meta:    &chainedConn.Meta,
 		}
 	}
 
 	if len(chainedConn.Normal.Senders) != 1 {
-		return src.Connection{}, &compiler.Error{
+		return src.Connection{}, nil, &compiler.Error{
 			Message: "multiple senders are only allowed at the start of a connection",
 			Meta:    &chainedConn.Normal.Meta,
 		}
@@ -474,12 +508,12 @@ func (a Analyzer) analyzeChainedConnectionReceiver(
 		scope,
 	)
 	if err != nil {
-		return src.Connection{}, err
+		return src.Connection{}, nil, err
 	}
 
 	for i, resolvedSenderType := range resolvedSenderTypes {
 		if err := a.resolver.IsSubtypeOf(*resolvedSenderType, chainHeadType, scope); err != nil {
-			return src.Connection{}, &compiler.Error{
+			return src.Connection{}, nil, &compiler.Error{
 				Message: fmt.Sprintf(
 					"Incompatible types: %v -> %v: %v",
 					analyzedSenders[i], chainHead, err.Error(),
@@ -489,6 +523,13 @@ func (a Analyzer) analyzeChainedConnectionReceiver(
 		}
 	}
 
+	unionWrap, err := a.getUnionWrap(resolvedSenderTypes, chainHeadType, iface.TypeParams.Params, scope)
+	if err != nil {
+		return src.Connection{}, nil, compiler.Error{
+			Meta: &chainedConn.Meta,
+		}.Wrap(err)
+	}
+
 	analyzedChainedConn, err := a.analyzeConnection(
 		chainedConn,
 		iface,
@@ -499,19 +540,19 @@ func (a Analyzer) analyzeChainedConnectionReceiver(
 		analyzedSenders,
 	)
 	if err != nil {
-		return src.Connection{}, err
+		return src.Connection{}, nil, err
 	}
 
 	if chainHead.PortAddr != nil {
 		if err := netNodesUsage(nodesUsage).trackInportUsage(*chainHead.PortAddr); err != nil {
-			return src.Connection{}, &compiler.Error{
+			return src.Connection{}, nil, &compiler.Error{
 				Message: err.Error(),
 				Meta:    &chainedConn.Meta,
 			}
 		}
 	}
 
-	return analyzedChainedConn, nil
+	return analyzedChainedConn, unionWrap, nil
 }
 
 func (a Analyzer) analyzeSenders(
@@ -845,26 +886,29 @@ func (a Analyzer) analyzeArrayBypassConnection(
 	return nil
 }
 
+// analyzeNetPortsUsage returns errors of all the unused ports and nodes and incorrectly used array ports.
 func (a Analyzer) analyzeNetPortsUsage(
 	compInterface src.Interface,
 	nodesIfaces map[string]foundInterface,
 	hasGuard bool,
 	nodesUsage map[string]netNodeUsage,
 	nodes map[string]src.Node,
-) *compiler.Error {
+) compiler.Errors {
+	var errs compiler.Errors
+
 	// 1. every self inport must be used
 	inportsUsage, ok := nodesUsage["in"]
 	if !ok {
-		return &compiler.Error{
+		errs = append(errs, &compiler.Error{
 			Message: "Unused inports",
 			Meta:    &compInterface.Meta,
-		}
-	}
-
-	for inportName := range compInterface.IO.In {
-		if _, ok := inportsUsage.Out[inportName]; !ok { // note that self inports are outports for the network
-			return &compiler.Error{
-				Message: fmt.Sprintf("Unused inport: %v", inportName),
+		})
+	} else {
+		for _, inportName := range slices.Sorted(maps.Keys(compInterface.IO.In)) {
+			if _, ok := inportsUsage.Out[inportName]; !ok { // note that self inports are outports for the network
+				errs = append(errs, &compiler.Error{
+					Message: fmt.Sprintf("Unused inport: %v", inportName),
+				})
 			}
 		}
 	}
@@ -872,42 +916,45 @@ func (a Analyzer) analyzeNetPortsUsage(
 	// 2. every self-outport must be used
 	outportsUsage, ok := nodesUsage["out"]
 	if !ok {
-		return &compiler.Error{
+		errs = append(errs, &compiler.Error{
 			Message: "Component must use its outports",
 			Meta:    &compInterface.Meta,
-		}
-	}
-
-	for outportName := range compInterface.IO.Out {
-		if _, ok := outportsUsage.In[outportName]; ok { // self outports are inports in network
-			continue
-		}
+		})
+	} else {
+		for _, outportName := range slices.Sorted(maps.Keys(compInterface.IO.Out)) {
+			if _, ok := outportsUsage.In[outportName]; ok { // self outports are inports in network
+				continue
+			}
 
-		// err outport is allowed to be unused if parent uses guard
-		if outportName == "err" && hasGuard {
-			continue
-		}
+			// err outport is allowed to be unused if parent uses guard
+			if outportName == "err" && hasGuard {
+				continue
+			}
 
-		return &compiler.Error{
-			Message: fmt.Sprintf("Unused outport: %v", outportName),
+			errs = append(errs, &compiler.Error{
+				Message: fmt.Sprintf("Unused outport: %v", outportName),
+			})
 		}
 	}
 
 	// 3. check sub-nodes usage in network
-	for nodeName, nodeIface := range nodesIfaces {
+	for _, nodeName := range slices.Sorted(maps.Keys(nodesIfaces)) {
+		nodeIface := nodesIfaces[nodeName]
 		nodeMeta := nodes[nodeName].Meta
 
 		// every sub-node must be used
 		nodeUsage, ok := nodesUsage[nodeName]
 		if !ok {
-			return &compiler.Error{
+			errs = append(errs, &compiler.Error{
 				Message: fmt.Sprintf("Unused node found: %v", nodeName),
 				Meta:    &nodeMeta,
-			}
+				Code:    compiler.ErrorCodeUnusedNode,
+			})
+			continue
 		}
 
 		// every sub-node's inport must be used
-		for inportName := range nodeIface.iface.IO.In {
+		for _, inportName := range slices.Sorted(maps.Keys(nodeIface.iface.IO.In)) {
 			if _, ok := nodeUsage.In[inportName]; ok {
 				continue
 			}
@@ -917,14 +964,14 @@ func (a Analyzer) analyzeNetPortsUsage(
 				continue
 			}
 
-			return &compiler.Error{
+			errs = append(errs, &compiler.Error{
 				Message: fmt.Sprintf(
 					"Unused node inport: %v:%v",
 					nodeName,
 					inportName,
 				),
 				Meta: &nodeMeta,
-			}
+			})
 		}
 
 		if len(nodeIface.iface.IO.Out) == 0 { // e.g. Del
@@ -936,14 +983,17 @@ func (a Analyzer) analyzeNetPortsUsage(
 		for outportName := range nodeIface.iface.IO.Out {
 			if _, ok := nodeUsage.Out[outportName]; ok {
 				atLeastOneOutportIsUsed = true
-				continue
 			}
+		}
 
-			if outportName == "err" && !nodes[nodeName].ErrGuard {
-				return &compiler.Error{
+		if _, ok := nodeIface.iface.IO.Out["err"]; ok && !nodes[nodeName].ErrGuard {
+			if _, ok := nodeUsage.Out["err"]; !ok {
+				errs = append(errs, &compiler.Error{
 					Message: fmt.Sprintf("unhandled error: %v:err", nodeName),
 					Meta:    &nodeMeta,
-				}
+					Code:    compiler.ErrorCodeUnhandledError,
+				})
+				continue
 			}
 		}
 
@@ -951,73 +1001,72 @@ func (a Analyzer) analyzeNetPortsUsage(
 			if _, ok := nodeUsage.Out[""]; ok && len(nodeIface.iface.IO.Out) == 1 {
 				continue
 			}
-			return &compiler.Error{
+			errs = append(errs, &compiler.Error{
 				Message: fmt.Sprintf("All node's outports are unused: %v", nodeName),
 				Meta:    &nodeMeta,
-			}
+				Code:    compiler.ErrorCodeUnusedOutports,
+			})
 		}
 	}
 
 	// 4. check that array ports are used correctly (from 0 and without holes)
-	for nodeName, nodeUsage := range nodesUsage {
+	for _, nodeName := range slices.Sorted(maps.Keys(nodesUsage)) {
+		nodeUsage := nodesUsage[nodeName]
 		nodeMeta := nodes[nodeName].Meta
 
-		for portName, usedSlots := range nodeUsage.In {
-			if usedSlots == nil {
-				continue // skip non-array ports
+		for _, portName := range slices.Sorted(maps.Keys(nodeUsage.In)) {
+			if slot, ok := missingArraySlot(nodeUsage.In[portName]); ok {
+				errs = append(errs, &compiler.Error{
+					Message: fmt.Sprintf(
+						"array inport '%s:%s' is used incorrectly: slot %d is missing",
+						nodeName,
+						portName,
+						slot,
+					),
+					Meta: &nodeMeta,
+				})
 			}
+		}
 
-			maxSlot := uint8(0)
-			for slot := range usedSlots {
-				if slot > maxSlot {
-					maxSlot = slot
-				}
-			}
-
-			for i := uint8(0); i <= maxSlot; i++ {
-				if _, ok := usedSlots[i]; !ok {
-					return &compiler.Error{
-						Message: fmt.Sprintf(
-							"array inport '%s:%s' is used incorrectly: slot %d is missing",
-							nodeName,
-							portName,
-							i,
-						),
-						Meta: &nodeMeta,
-					}
-				}
+		for _, portName := range slices.Sorted(maps.Keys(nodeUsage.Out)) {
+			if slot, ok := missingArraySlot(nodeUsage.Out[portName]); ok {
+				errs = append(errs, &compiler.Error{
+					Message: fmt.Sprintf(
+						"array outport '%s:%s' is used incorrectly: slot %d is missing",
+						nodeName,
+						portName,
+						slot,
+					),
+					Meta: &nodeMeta,
+				})
 			}
 		}
+	}
 
-		for portName, usedSlots := range nodeUsage.Out {
-			if usedSlots == nil {
-				continue // skip non-array ports
-			}
+	return errs
+}
 
-			maxSlot := uint8(0)
-			for slot := range usedSlots {
-				if slot > maxSlot {
-					maxSlot = slot
-				}
-			}
+// missingArraySlot returns the first slot that is not used before the last used one.
+// Slots of non-array ports are nil.
+func missingArraySlot(usedSlots map[uint8]struct{}) (uint8, bool) {
+	if usedSlots == nil {
+		return 0, false
+	}
 
-			for i := uint8(0); i <= maxSlot; i++ {
-				if _, ok := usedSlots[i]; !ok {
-					return &compiler.Error{
-						Message: fmt.Sprintf(
-							"array outport '%s:%s' is used incorrectly: slot %d is missing",
-							nodeName,
-							portName,
-							i,
-						),
-						Meta: &nodeMeta,
-					}
-				}
-			}
+	maxSlot := uint8(0)
+	for slot := range usedSlots {
+		if slot > maxSlot {
+			maxSlot = slot
 		}
 	}
 
-	return nil
+	for i := uint8(0); i <= maxSlot; i++ {
+		if _, ok := usedSlots[i]; !ok {
+			return i, true
+		}
+	}
+
+	return 0, false
 }
 
 // getReceiverPortType returns resolved port-addr, type expr and isArray bool.
@@ -1446,6 +1495,18 @@ func (a Analyzer) getConstSenderType(
 		}
 	}
 
+	if resolvedExpr.Lit != nil && resolvedExpr.Lit.Union != nil {
+		unionMsg, err := a.analyzeUnionLiteral(*constSender.Value.Message, resolvedExpr.Lit.Union, scope)
+		if err != nil {
+			return src.Const{}, ts.Expr{}, err
+		}
+		return src.Const{
+			TypeExpr: resolvedExpr,
+			Value:    src.ConstValue{Message: unionMsg},
+			Meta:     constSender.Meta,
+		}, resolvedExpr, nil
+	}
+
 	if err := a.validateLiteralSender(resolvedExpr); err != nil {
 		return src.Const{}, ts.Expr{}, &compiler.Error{
 			Message: err.Error(),
@@ -1464,6 +1525,7 @@ func (a Analyzer) getConstSenderType(
 				List:         constSender.Value.Message.List,
 				DictOrStruct: constSender.Value.Message.DictOrStruct,
 				Enum:         constSender.Value.Message.Enum,
+				Union:        constSender.Value.Message.Union,
 				Meta:         constSender.Value.Message.Meta,
 			},
 		},
@@ -1474,7 +1536,7 @@ func (a Analyzer) getConstSenderType(
 func (a Analyzer) validateLiteralSender(resolvedExpr ts.Expr) error {
 	if resolvedExpr.Inst != nil {
 		switch resolvedExpr.Inst.Ref.String() {
-		case "bool", "int", "float", "string":
+		case "bool", "int", "float", "string", "bytes":
 			return nil
 		}
 		return ErrComplexLiteralSender
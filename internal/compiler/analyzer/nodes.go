@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/nevalang/neva/internal/compiler"
@@ -22,12 +23,13 @@ func (a Analyzer) analyzeNodes(
 	map[string]src.Node, // resolved nodes
 	map[string]foundInterface, // resolved nodes interfaces with locations
 	bool, // one of the nodes has error guard
-	*compiler.Error, // err
+	compiler.Errors, // errors of all invalid nodes
 ) {
 	analyzedNodes := make(map[string]src.Node, len(nodes))
 	nodesInterfaces := make(map[string]foundInterface, len(nodes))
 	hasErrGuard := false
 
+	var errs compiler.Errors
 	for nodeName, node := range nodes {
 		if node.ErrGuard {
 			hasErrGuard = true
@@ -39,15 +41,20 @@ func (a Analyzer) analyzeNodes(
 			scope,
 		)
 		if err != nil {
-			return nil, nil, false, compiler.Error{
+			errs = append(errs, compiler.Error{
 				Meta: &node.Meta,
-			}.Wrap(err)
+			}.Wrap(err))
+			continue
 		}
 
 		nodesInterfaces[nodeName] = nodeInterface
 		analyzedNodes[nodeName] = analyzedNode
 	}
 
+	if len(errs) != 0 {
+		return nil, nil, false, errs
+	}
+
 	return analyzedNodes, nodesInterfaces, hasErrGuard, nil
 }
 
@@ -60,9 +67,17 @@ func (a Analyzer) analyzeNode(
 
 	nodeEntity, location, err := scope.Entity(node.EntityRef)
 	if err != nil {
+		var code compiler.ErrorCode
+		switch {
+		case errors.Is(err, src.ErrImportNotFound):
+			code = compiler.ErrorCodeImportNotFound
+		case errors.Is(err, src.ErrEntityNotFound):
+			code = compiler.ErrorCodeEntityNotFound
+		}
 		return src.Node{}, foundInterface{}, &compiler.Error{
 			Message: err.Error(),
 			Meta:    &node.Meta,
+			Code:    code,
 		}
 	}
 
@@ -82,6 +97,12 @@ func (a Analyzer) analyzeNode(
 		}
 	}
 
+	if bufferDirectiveArgs, usesBufferDirective := node.Directives[compiler.BufferDirective]; usesBufferDirective {
+		if err := a.analyzeBufferDirective(bufferDirectiveArgs, node, scope); err != nil {
+			return src.Node{}, foundInterface{}, err
+		}
+	}
+
 	// We need to get resolved frame from parent type parameters
 	// in order to be able to resolve node's args
 	// since they can refer to type parameter of the parent (interface)
@@ -155,9 +176,20 @@ func (a Analyzer) analyzeNode(
 		nodeIface.TypeParams.Params,
 		scope,
 	); err != nil {
+		var code compiler.ErrorCode
+		if errors.Is(err, typesystem.ErrArgsParamsCount) {
+			code = compiler.ErrorCodeTypeArgsCount
+		}
 		return src.Node{}, foundInterface{}, &compiler.Error{
 			Message: err.Error(),
 			Meta:    &node.Meta,
+			Code:    code,
+		}
+	}
+
+	if nodeEntity.Kind == src.ComponentEntity {
+		if err := a.analyzeNodeExtern(nodeEntity.Component, node, resolvedNodeArgs); err != nil {
+			return src.Node{}, foundInterface{}, err
 		}
 	}
 
@@ -250,6 +282,10 @@ func (a Analyzer) getNodeInterface(
 
 	iface := entity.Component.Interface
 
+	if len(externArgs) == 1 && (externArgs[0] == "union_wrap" || externArgs[0] == "union_value") {
+		return a.getUnionNodeInterface(iface, externArgs[0], node, scope, resolvedNodeArgs)
+	}
+
 	_, hasAutoPortsDirective := entity.Component.Directives[compiler.AutoportsDirective]
 	if !hasAutoPortsDirective {
 		return iface, nil
@@ -333,3 +369,64 @@ func (a Analyzer) getNodeInterface(
 		Meta: iface.Meta,
 	}, nil
 }
+
+// maxBufferSize limits #buffer, because buffers are allocated when program starts.
+const maxBufferSize = 1 << 16
+
+// analyzeBufferDirective checks that #buffer refers to a positive integer constant not bigger than maxBufferSize.
+func (a Analyzer) analyzeBufferDirective(
+	args []string,
+	node src.Node,
+	scope src.Scope,
+) *compiler.Error {
+	if len(args) != 1 {
+		return &compiler.Error{
+			Message: "Node with #buffer directive must provide exactly one argument",
+			Meta:    &node.Meta,
+		}
+	}
+
+	entity, location, err := scope.Entity(compiler.ParseEntityRef(args[0]))
+	if err != nil {
+		return &compiler.Error{
+			Message: err.Error(),
+			Meta:    &node.Meta,
+		}
+	}
+
+	// constant can refer to another constant, so we follow references until literal
+	for entity.Kind == src.ConstEntity && entity.Const.Value.Ref != nil {
+		entity, location, err = scope.Relocate(location).Entity(*entity.Const.Value.Ref)
+		if err != nil {
+			return &compiler.Error{
+				Message: err.Error(),
+				Meta:    &node.Meta,
+			}
+		}
+	}
+
+	if entity.Kind != src.ConstEntity ||
+		entity.Const.Value.Message == nil ||
+		entity.Const.Value.Message.Int == nil {
+		return &compiler.Error{
+			Message: fmt.Sprintf("#buffer directive argument must be an integer constant: %v", args[0]),
+			Meta:    &node.Meta,
+		}
+	}
+
+	if *entity.Const.Value.Message.Int <= 0 {
+		return &compiler.Error{
+			Message: fmt.Sprintf("Buffer size must be positive, got %d", *entity.Const.Value.Message.Int),
+			Meta:    &node.Meta,
+		}
+	}
+
+	if *entity.Const.Value.Message.Int > maxBufferSize {
+		return &compiler.Error{
+			Message: fmt.Sprintf("Buffer size must not exceed %d, got %d", maxBufferSize, *entity.Const.Value.Message.Int),
+			Meta:    &node.Meta,
+		}
+	}
+
+	return nil
+}
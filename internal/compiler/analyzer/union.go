@@ -0,0 +1,460 @@
+package analyzer
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nevalang/neva/internal/compiler"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+)
+
+// analyzeUnionLiteral turns literal of union type into union message with resolved tag.
+// Tag-only literals are written like enum members `Shape::Circle`,
+// where `Shape` is a union type and `Circle` is a name of one of its elements.
+// Any other literal is wrapped into the only union element it is a value of.
+func (a Analyzer) analyzeUnionLiteral(
+	lit src.MsgLiteral,
+	resolvedUnion []ts.Expr,
+	scope src.Scope,
+) (*src.MsgLiteral, *compiler.Error) {
+	if lit.Enum != nil {
+		tag, isUnion, err := a.getUnionTagByName(*lit.Enum, scope)
+		if err != nil {
+			return nil, err
+		}
+		if isUnion {
+			return &src.MsgLiteral{
+				Union: &src.UnionMessage{Tag: tag},
+				Meta:  lit.Meta,
+			}, nil
+		}
+	}
+
+	tag, err := a.findUnionElement(lit, resolvedUnion, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := a.analyzeConst(
+		src.Const{
+			TypeExpr: resolvedUnion[tag],
+			Value:    src.ConstValue{Message: &lit},
+			Meta:     lit.Meta,
+		},
+		scope,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &src.MsgLiteral{
+		Union: &src.UnionMessage{
+			Tag:  uint8(tag),
+			Data: &data.Value,
+		},
+		Meta: lit.Meta,
+	}, nil
+}
+
+// findUnionElement returns index of the union element that literal is a value of.
+// Integer literal can also be a float, but only if it's not a value of any other element.
+func (a Analyzer) findUnionElement(
+	lit src.MsgLiteral,
+	resolvedUnion []ts.Expr,
+	scope src.Scope,
+) (int, *compiler.Error) {
+	tags := a.getMatchingUnionElements(lit, resolvedUnion, false, scope)
+	if len(tags) == 0 {
+		tags = a.getMatchingUnionElements(lit, resolvedUnion, true, scope)
+	}
+
+	switch len(tags) {
+	case 0:
+		return 0, &compiler.Error{
+			Message: fmt.Sprintf(
+				"Literal %v doesn't match any element of union %v",
+				lit.String(),
+				ts.Expr{Lit: &ts.LitExpr{Union: resolvedUnion}},
+			),
+			Meta: &lit.Meta,
+		}
+	case 1:
+		return tags[0], nil
+	}
+
+	matched := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		matched = append(matched, resolvedUnion[tag].String())
+	}
+
+	return 0, &compiler.Error{
+		Message: fmt.Sprintf(
+			"Literal %v matches several elements of union %v: %v",
+			lit.String(),
+			ts.Expr{Lit: &ts.LitExpr{Union: resolvedUnion}},
+			strings.Join(matched, ", "),
+		),
+		Meta: &lit.Meta,
+	}
+}
+
+func (a Analyzer) getMatchingUnionElements(
+	lit src.MsgLiteral,
+	resolvedUnion []ts.Expr,
+	intAsFloat bool,
+	scope src.Scope,
+) []int {
+	var tags []int
+	for i, el := range resolvedUnion {
+		if a.literalMatchesType(lit, el, intAsFloat, scope) {
+			tags = append(tags, i)
+		}
+	}
+	return tags
+}
+
+// getUnionTagByName returns index of the union element that is referenced like enum member.
+// It returns false if referenced type is not a union, which means literal is a real enum member.
+func (a Analyzer) getUnionTagByName(
+	enumMsg src.EnumMessage,
+	scope src.Scope,
+) (uint8, bool, *compiler.Error) {
+	entity, _, err := scope.Entity(enumMsg.EnumRef)
+	if err != nil {
+		return 0, false, &compiler.Error{
+			Message: err.Error(),
+			Meta:    &enumMsg.EnumRef.Meta,
+		}
+	}
+
+	if entity.Kind != src.TypeEntity {
+		return 0, false, &compiler.Error{
+			Message: fmt.Sprintf("Type expected, got %v", entity.Kind),
+			Meta:    &enumMsg.EnumRef.Meta,
+		}
+	}
+
+	body := entity.Type.BodyExpr
+	if body == nil || body.Lit == nil || body.Lit.Union == nil {
+		return 0, false, nil
+	}
+
+	// we look at unresolved definition because resolved elements lose their names
+	for i, el := range body.Lit.Union {
+		if el.Inst != nil && el.Inst.Ref.Name == enumMsg.MemberName {
+			return uint8(i), true, nil
+		}
+	}
+
+	return 0, false, &compiler.Error{
+		Message: fmt.Sprintf("Union %v has no element %v", enumMsg.EnumRef, enumMsg.MemberName),
+		Meta:    &enumMsg.EnumRef.Meta,
+	}
+}
+
+// literalMatchesType reports whether literal is a value of given resolved type.
+// Struct literal must have exactly the fields of the struct type.
+// Fields, list items and dict values are checked recursively.
+func (a Analyzer) literalMatchesType(
+	lit src.MsgLiteral,
+	expr ts.Expr,
+	intAsFloat bool,
+	scope src.Scope,
+) bool {
+	if expr.Lit != nil {
+		switch {
+		case expr.Lit.Struct != nil:
+			if lit.DictOrStruct == nil || len(lit.DictOrStruct) != len(expr.Lit.Struct) {
+				return false
+			}
+			for name, value := range lit.DictOrStruct {
+				field, ok := expr.Lit.Struct[name]
+				if !ok || !a.valueMatchesType(value, field, intAsFloat, scope) {
+					return false
+				}
+			}
+			return true
+		case expr.Lit.Enum != nil:
+			return lit.Enum != nil && slices.Contains(expr.Lit.Enum, lit.Enum.MemberName)
+		case expr.Lit.Union != nil:
+			return len(a.getMatchingUnionElements(lit, expr.Lit.Union, intAsFloat, scope)) > 0
+		}
+		return false
+	}
+
+	if expr.Inst == nil {
+		return false
+	}
+
+	switch expr.Inst.Ref.String() {
+	case "any":
+		return true
+	case "bool":
+		return lit.Bool != nil
+	case "int":
+		return lit.Int != nil
+	case "float":
+		return lit.Float != nil || (intAsFloat && lit.Int != nil)
+	case "string", "bytes":
+		return lit.Str != nil
+	case "list":
+		if lit.List == nil || len(expr.Inst.Args) != 1 {
+			return false
+		}
+		for _, item := range lit.List {
+			if !a.valueMatchesType(item, expr.Inst.Args[0], intAsFloat, scope) {
+				return false
+			}
+		}
+		return true
+	case "dict":
+		if lit.DictOrStruct == nil || len(expr.Inst.Args) != 1 {
+			return false
+		}
+		for _, value := range lit.DictOrStruct {
+			if !a.valueMatchesType(value, expr.Inst.Args[0], intAsFloat, scope) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// valueMatchesType is like literalMatchesType but value can also be a reference to another constant.
+func (a Analyzer) valueMatchesType(
+	value src.ConstValue,
+	expr ts.Expr,
+	intAsFloat bool,
+	scope src.Scope,
+) bool {
+	if value.Message != nil {
+		return a.literalMatchesType(*value.Message, expr, intAsFloat, scope)
+	}
+	if value.Ref == nil {
+		return false
+	}
+
+	entity, location, err := scope.Entity(*value.Ref)
+	if err != nil || entity.Kind != src.ConstEntity {
+		return false
+	}
+
+	return a.resolver.IsSubtypeOf(entity.Const.TypeExpr, expr, scope.Relocate(location)) == nil
+}
+
+// getUnionNodeInterface returns interface of UnionWrap or UnionValue node.
+// Node must bind tag-only literal of its union type argument,
+// type of its non-union port is narrowed to the union element with that tag.
+func (a Analyzer) getUnionNodeInterface(
+	iface src.Interface,
+	externName string,
+	node src.Node,
+	scope src.Scope,
+	resolvedNodeArgs []ts.Expr,
+) (src.Interface, *compiler.Error) {
+	if len(resolvedNodeArgs) != 1 {
+		return src.Interface{}, &compiler.Error{
+			Message: "Exactly one type argument expected",
+			Meta:    &node.Meta,
+		}
+	}
+
+	resolvedUnion := resolvedNodeArgs[0]
+	if resolvedUnion.Lit == nil || resolvedUnion.Lit.Union == nil {
+		return src.Interface{}, &compiler.Error{
+			Message: fmt.Sprintf("Union type argument expected, got %v", resolvedUnion),
+			Meta:    &node.Meta,
+		}
+	}
+
+	bindArgs := node.Directives[compiler.BindDirective]
+	if len(bindArgs) != 1 {
+		return src.Interface{}, &compiler.Error{
+			Message: "Node must bind tag-only union constant like `Shape::Circle` with #bind directive",
+			Meta:    &node.Meta,
+		}
+	}
+
+	tag, err := a.getBoundUnionTag(bindArgs[0], resolvedUnion.Lit.Union, node, scope)
+	if err != nil {
+		return src.Interface{}, err
+	}
+
+	element := resolvedUnion.Lit.Union[tag]
+
+	in := maps.Clone(iface.IO.In)
+	out := maps.Clone(iface.IO.Out)
+	if externName == "union_wrap" {
+		in["data"] = src.Port{TypeExpr: element, Meta: in["data"].Meta}
+	} else {
+		out["res"] = src.Port{TypeExpr: element, Meta: out["res"].Meta}
+	}
+
+	return src.Interface{
+		TypeParams: iface.TypeParams,
+		IO:         src.IO{In: in, Out: out},
+		Meta:       iface.Meta,
+	}, nil
+}
+
+// getBoundUnionTag returns tag of the tag-only union constant bound to the node.
+func (a Analyzer) getBoundUnionTag(
+	constName string,
+	resolvedUnion []ts.Expr,
+	node src.Node,
+	scope src.Scope,
+) (uint8, *compiler.Error) {
+	entity, location, err := scope.Entity(compiler.ParseEntityRef(constName))
+	if err != nil {
+		return 0, &compiler.Error{
+			Message: err.Error(),
+			Meta:    &node.Meta,
+		}
+	}
+
+	if entity.Kind != src.ConstEntity {
+		return 0, &compiler.Error{
+			Message: fmt.Sprintf("#bind directive argument must be a constant: %v", constName),
+			Meta:    &node.Meta,
+		}
+	}
+
+	constant, aerr := a.analyzeConst(entity.Const, scope.Relocate(location))
+	if aerr != nil {
+		return 0, compiler.Error{Meta: &node.Meta}.Wrap(aerr)
+	}
+
+	msg := constant.Value.Message
+	if msg == nil || msg.Union == nil || msg.Union.Data != nil {
+		return 0, &compiler.Error{
+			Message: fmt.Sprintf("#bind directive argument must be a tag-only union constant like `Shape::Circle`: %v", constName),
+			Meta:    &node.Meta,
+		}
+	}
+
+	if !a.isSameUnion(constant.TypeExpr.Lit.Union, resolvedUnion, scope) {
+		return 0, &compiler.Error{
+			Message: fmt.Sprintf(
+				"Type of constant %v is %v, but node expects %v",
+				constName,
+				constant.TypeExpr,
+				ts.Expr{Lit: &ts.LitExpr{Union: resolvedUnion}},
+			),
+			Meta: &node.Meta,
+		}
+	}
+
+	return msg.Union.Tag, nil
+}
+
+// isSameUnion reports whether unions have the same elements in the same order,
+// which means their messages have the same tags.
+func (a Analyzer) isSameUnion(u1, u2 []ts.Expr, scope src.Scope) bool {
+	if len(u1) != len(u2) {
+		return false
+	}
+	for i := range u1 {
+		if a.resolver.IsSubtypeOf(u1[i], u2[i], scope) != nil ||
+			a.resolver.IsSubtypeOf(u2[i], u1[i], scope) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// getUnionWrap returns how messages of senders must be wrapped into union type of the receiver.
+// It returns nil if receiver is not a union or all senders already send unions.
+func (a Analyzer) getUnionWrap(
+	resolvedSenderTypes []*ts.Expr,
+	resolvedReceiverType ts.Expr,
+	typeParams []ts.Param,
+	scope src.Scope,
+) (*src.UnionWrap, *compiler.Error) {
+	if resolvedReceiverType.Lit == nil || resolvedReceiverType.Lit.Union == nil {
+		return nil, nil
+	}
+
+	// type parameter like `T int | float` is resolved to its constraint,
+	// but actual type of such port is only known when component is instantiated
+	for _, param := range typeParams {
+		constr, err := a.resolver.ResolveExpr(param.Constr, scope)
+		if err != nil || constr.Lit == nil || constr.Lit.Union == nil {
+			continue
+		}
+		if a.isSameUnion(constr.Lit.Union, resolvedReceiverType.Lit.Union, scope) {
+			return nil, nil
+		}
+	}
+
+	tags := make([]*uint8, len(resolvedSenderTypes))
+	needsWrap := false
+	for i, senderType := range resolvedSenderTypes {
+		if senderType.Lit != nil && senderType.Lit.Union != nil {
+			continue
+		}
+		tag, err := a.getUnionElementByType(*senderType, resolvedReceiverType.Lit.Union, scope)
+		if err != nil {
+			return nil, err
+		}
+		tags[i] = &tag
+		needsWrap = true
+	}
+
+	if !needsWrap {
+		return nil, nil
+	}
+
+	return &src.UnionWrap{
+		Type: resolvedReceiverType,
+		Tags: tags,
+	}, nil
+}
+
+// getUnionElementByType returns index of the union element that messages of given type belong to.
+// Element of the same type is preferred over its super-types.
+func (a Analyzer) getUnionElementByType(
+	resolvedType ts.Expr,
+	resolvedUnion []ts.Expr,
+	scope src.Scope,
+) (uint8, *compiler.Error) {
+	var same, super []int
+	for i, el := range resolvedUnion {
+		if a.resolver.IsSubtypeOf(resolvedType, el, scope) != nil {
+			continue
+		}
+		if a.resolver.IsSubtypeOf(el, resolvedType, scope) == nil {
+			same = append(same, i)
+		} else {
+			super = append(super, i)
+		}
+	}
+
+	tags := same
+	if len(tags) == 0 {
+		tags = super
+	}
+
+	union := ts.Expr{Lit: &ts.LitExpr{Union: resolvedUnion}}
+
+	switch len(tags) {
+	case 0:
+		return 0, &compiler.Error{
+			Message: fmt.Sprintf("Type %v is not an element of union %v", resolvedType, union),
+		}
+	case 1:
+		return uint8(tags[0]), nil
+	}
+
+	return 0, &compiler.Error{
+		Message: fmt.Sprintf(
+			"Type %v matches several elements of union %v, use UnionWrap to choose one",
+			resolvedType,
+			union,
+		),
+	}
+}
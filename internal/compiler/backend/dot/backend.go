@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/nevalang/neva/internal/compiler"
 	"github.com/nevalang/neva/internal/compiler/ir"
 )
 
@@ -13,7 +14,7 @@ func NewBackend() Backend {
 	return Backend{}
 }
 
-func (b Backend) Emit(dst string, prog *ir.Program, trace bool) error {
+func (b Backend) Emit(dst string, prog *ir.Program, opts compiler.EmitOptions) error {
 	outFile := filepath.Join(dst, "program.dot")
 	f, err := os.OpenFile(outFile, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0755)
 	if err != nil {
@@ -22,11 +22,12 @@ var (
 	ErrUnknownMsgType = errors.New("unknown msg type")
 )
 
-func (b Backend) Emit(dst string, prog *ir.Program, trace bool) error {
+func (b Backend) Emit(dst string, prog *ir.Program, opts compiler.EmitOptions) error {
 	// graph must not contain intermediate connections to be supported by runtime
+	prog.Buffers = ir.BufferReduction(prog.Connections, prog.Buffers)
 	prog.Connections = ir.GraphReduction(prog.Connections)
 
-	addrToChanVar, chanVarNames := b.buildPortChanMap(prog.Connections)
+	addrToChanVar, chans := b.buildPortChanMap(prog.Connections, prog.Buffers)
 	funcCalls, err := b.buildFuncCalls(prog.Funcs, addrToChanVar)
 	if err != nil {
 		return err
@@ -50,9 +51,13 @@ func (b Backend) Emit(dst string, prog *ir.Program, trace bool) error {
 
 	tplData := templateData{
 		CompilerVersion: pkg.Version,
-		ChanVarNames:    chanVarNames,
+		Chans:           chans,
 		FuncCalls:       funcCalls,
-		Trace:           trace,
+		Trace:           opts.Trace,
+		TraceFormat:     string(opts.TraceFormat),
+		Metrics:         opts.Metrics,
+		Assertions:      opts.Assertions,
+		DrainTimeout:    int64(opts.DrainTimeout),
 	}
 
 	var buf bytes.Buffer
@@ -214,6 +219,8 @@ func (b Backend) getMessageString(msg *ir.Message) (string, error) {
 		return fmt.Sprintf("runtime.NewFloatMsg(%v)", msg.Float), nil
 	case ir.MsgTypeString:
 		return fmt.Sprintf(`runtime.NewStringMsg(%q)`, msg.String), nil
+	case ir.MsgTypeBytes:
+		return fmt.Sprintf(`runtime.NewBytesMsg([]byte(%q))`, msg.Bytes), nil
 	case ir.MsgTypeList:
 		elements := make([]string, len(msg.List))
 		for i, v := range msg.List {
@@ -248,6 +255,15 @@ func (b Backend) getMessageString(msg *ir.Message) (string, error) {
 		return fmt.Sprintf(`runtime.NewStructMsg([]string{%s}, []runtime.Msg{%s})`,
 			strings.Join(names, ", "),
 			strings.Join(values, ", ")), nil
+	case ir.MsgTypeUnion:
+		if msg.Value == nil {
+			return fmt.Sprintf("runtime.NewUnionMsg(%d, nil)", msg.Tag), nil
+		}
+		value, err := b.getMessageString(msg.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("runtime.NewUnionMsg(%d, %s)", msg.Tag, value), nil
 	}
 	return "", fmt.Errorf("%w: %v", ErrUnknownMsgType, msg.Type)
 }
@@ -261,7 +277,8 @@ func (b Backend) insertRuntimeFiles(files map[string][]byte) error {
 				return err
 			}
 
-			if dirEntry.IsDir() {
+			// tests of the runtime are not part of the generated program
+			if dirEntry.IsDir() || strings.HasSuffix(path, "_test.go") {
 				return nil
 			}
 
@@ -280,9 +297,12 @@ func (b Backend) insertRuntimeFiles(files map[string][]byte) error {
 	return nil
 }
 
-func (b Backend) buildPortChanMap(connections map[ir.PortAddr]ir.PortAddr) (map[ir.PortAddr]string, []string) {
+func (b Backend) buildPortChanMap(
+	connections map[ir.PortAddr]ir.PortAddr,
+	buffers map[ir.PortAddr]int,
+) (map[ir.PortAddr]string, []templateChan) {
 	portsCount := len(connections) * 2
-	varNames := make([]string, 0, portsCount)
+	chans := make([]templateChan, 0, len(connections))
 	addrToChanVar := make(map[ir.PortAddr]string, portsCount)
 
 	for senderAddr, receiverAddr := range connections {
@@ -293,10 +313,13 @@ func (b Backend) buildPortChanMap(connections map[ir.PortAddr]ir.PortAddr) (map[
 		)
 		addrToChanVar[senderAddr] = channelName
 		addrToChanVar[receiverAddr] = channelName
-		varNames = append(varNames, channelName)
+		chans = append(chans, templateChan{
+			Name:   channelName,
+			Buffer: buffers[receiverAddr],
+		})
 	}
 
-	return addrToChanVar, varNames
+	return addrToChanVar, chans
 }
 
 func (b Backend) chanVarNameFromPortAddr(addr ir.PortAddr) string {
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 
+	"github.com/nevalang/neva/internal/compiler"
 	"github.com/nevalang/neva/internal/compiler/backend/golang"
 	"github.com/nevalang/neva/internal/compiler/ir"
 )
@@ -14,10 +15,10 @@ type Backend struct {
 	golang golang.Backend
 }
 
-func (b Backend) Emit(output string, prog *ir.Program, trace bool) error {
+func (b Backend) Emit(output string, prog *ir.Program, opts compiler.EmitOptions) error {
 	tmpGoModuleDir := output + "/tmp"
 
-	if err := b.golang.Emit(tmpGoModuleDir, prog, trace); err != nil {
+	if err := b.golang.Emit(tmpGoModuleDir, prog, opts); err != nil {
 		return fmt.Errorf("emit: %w", err)
 	}
 
@@ -2,9 +2,18 @@ package golang
 
 type templateData struct {
 	CompilerVersion string
-	ChanVarNames    []string
+	Chans           []templateChan
 	FuncCalls       []templateFuncCall
 	Trace           bool
+	TraceFormat     string
+	Metrics         bool
+	Assertions      bool
+	DrainTimeout    int64 // nanoseconds
+}
+
+type templateChan struct {
+	Name   string
+	Buffer int // zero means unbuffered
 }
 
 type templateFuncCall struct {
@@ -22,6 +31,7 @@ var mainGoTemplate = `// Code generated by Neva v{{.CompilerVersion}}. DO NOT ED
 package main
 
 import (
+    "errors"
     "fmt"
     "os"
     "context"
@@ -31,30 +41,63 @@ import (
 )
 
 func main() {
+    if err := run(); err != nil {
+        fmt.Fprintln(os.Stderr, err.Error())
+        os.Exit(1)
+    }
+}
+
+// run is separated from main so deferred calls are executed before exit.
+func run() (err error) {
     var (
-        {{- range .ChanVarNames}}
-        {{.}} = make(chan runtime.OrderedMsg)
+        {{- range .Chans}}
+        {{.Name}} = make(chan runtime.OrderedMsg{{if .Buffer}}, {{.Buffer}}{{end}})
         {{- end}}
     )
+
+    interceptors := []runtime.Interceptor{}
     {{- if .Trace }}
 
-    interceptor := runtime.NewDebugInterceptor()
+    debugInterceptor := runtime.NewDebugInterceptor(runtime.TraceFormat({{printf "%q" .TraceFormat}}))
 
-    close, err := interceptor.Open("trace.log")
+    closeTrace, err := debugInterceptor.Open("trace.log")
     if err != nil {
-        fmt.Fprintln(os.Stderr, "can't open trace file:", err.Error())
-        os.Exit(1)
+        return fmt.Errorf("can't open trace file: %w", err)
     }
     defer func() {
-        if err := close(); err != nil {
-            fmt.Fprintln(os.Stderr, "can't close trace file:", err.Error())
-            os.Exit(1)
+        if closeErr := closeTrace(); closeErr != nil {
+            err = errors.Join(err, fmt.Errorf("can't close trace file: %w", closeErr))
+        }
+    }()
+
+    interceptors = append(interceptors, debugInterceptor)
+    {{- end }}
+    {{- if .Metrics }}
+
+    metrics := runtime.NewMetricsInterceptor()
+    defer func() {
+        if dumpErr := metrics.Dump("metrics.json", "metrics.prom"); dumpErr != nil {
+            err = errors.Join(err, fmt.Errorf("can't write metrics: %w", dumpErr))
         }
     }()
-    {{- else }}
 
-    interceptor := runtime.ProdInterceptor{}
+    interceptors = append(interceptors, metrics)
     {{- end }}
+    {{- if .Assertions }}
+
+    assertions := runtime.NewAssertionsInterceptor()
+    defer func() {
+        err = errors.Join(err, assertions.Err())
+    }()
+
+    interceptors = append(interceptors, assertions)
+    {{- end }}
+
+    {{- /* history sees every message, it doesn't allocate and costs ~60ns per message, see BenchmarkHistoryInterceptor */}}
+    history := runtime.NewHistoryInterceptor(256)
+    interceptors = append(interceptors, history)
+
+    interceptor := runtime.NewChainInterceptor(interceptors...)
 
     var (
         startPort = runtime.NewSingleOutport(
@@ -90,15 +133,29 @@ func main() {
         {{- end}}
     }
 
+    // first signal lets in-flight messages drain, second one terminates immediately
+    ctx, shutdown, releaseSignals := runtime.HandleSignals(context.Background(), {{.DrainTimeout}})
+    defer releaseSignals()
+
     rprog := runtime.Program{
         Start: startPort,
         Stop: stopPort,
         FuncCalls: funcCalls,
+        {{- if .Metrics }}
+        Metrics: metrics,
+        {{- end }}
+        History: history,
+        Shutdown: shutdown,
+    }
+
+    if err := runtime.Run(ctx, rprog, funcs.NewRegistry()); err != nil {
+        var panicErr *runtime.PanicError
+        if errors.As(err, &panicErr) {
+            return err
+        }
+        return fmt.Errorf("runtime error: %w", err)
     }
-    
-    if err := runtime.Run(context.Background(), rprog, funcs.NewRegistry()); err != nil {
-		fmt.Fprintln(os.Stderr, "runtime error:", err.Error())
-		os.Exit(1)
-	}
+
+    return nil
 }
 `
@@ -5,6 +5,7 @@ import (
 	"os/exec"
 	"path/filepath"
 
+	"github.com/nevalang/neva/internal/compiler"
 	"github.com/nevalang/neva/internal/compiler/backend/golang"
 	"github.com/nevalang/neva/internal/compiler/ir"
 )
@@ -13,9 +14,9 @@ type Backend struct {
 	golang golang.Backend
 }
 
-func (b Backend) Emit(dst string, prog *ir.Program, trace bool) error {
+func (b Backend) Emit(dst string, prog *ir.Program, opts compiler.EmitOptions) error {
 	tmpGoProj := dst + "/tmp"
-	if err := b.golang.Emit(tmpGoProj, prog, trace); err != nil {
+	if err := b.golang.Emit(tmpGoProj, prog, opts); err != nil {
 		return err
 	}
 	if err := buildWASM(tmpGoProj, dst); err != nil {
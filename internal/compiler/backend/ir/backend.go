@@ -7,6 +7,7 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/nevalang/neva/internal/compiler"
 	"github.com/nevalang/neva/internal/compiler/ir"
 )
 
@@ -21,7 +22,7 @@ const (
 	FormatYAML Format = "yaml"
 )
 
-func (b Backend) Emit(dst string, prog *ir.Program, trace bool) error {
+func (b Backend) Emit(dst string, prog *ir.Program, opts compiler.EmitOptions) error {
 	var encoder func(f *os.File, prog *ir.Program) error
 	fullFileName := filepath.Join(dst, "ir")
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/nevalang/neva/internal/compiler/ir"
 	"github.com/nevalang/neva/internal/compiler/sourcecode"
@@ -20,6 +21,10 @@ type CompilerInput struct {
 	MainPkgPath   string
 	OutputPath    string
 	EmitTraceFile bool
+	TraceFormat   TraceFormat
+	EmitMetrics   bool
+	Assertions    bool
+	DrainTimeout  time.Duration
 }
 
 // CompilerOutput is result of intermediate steps done before backend.
@@ -34,12 +39,20 @@ func (c Compiler) Compile(ctx context.Context, input CompilerInput) (*CompilerOu
 		return nil, errors.New(err.Error()) // to avoid non-nil interface go-issue
 	}
 
-	meResult, err := c.me.Process(feResult)
-	if err != nil {
-		return nil, err
+	meResult, errs := c.me.Process(feResult)
+	if len(errs) != 0 {
+		return nil, errs
 	}
 
-	if err := c.be.Emit(input.OutputPath, meResult.IR, input.EmitTraceFile); err != nil {
+	emitOpts := EmitOptions{
+		Trace:        input.EmitTraceFile,
+		TraceFormat:  input.TraceFormat,
+		Metrics:      input.EmitMetrics,
+		Assertions:   input.Assertions,
+		DrainTimeout: input.DrainTimeout,
+	}
+
+	if err := c.be.Emit(input.OutputPath, meResult.IR, emitOpts); err != nil {
 		return nil, err
 	}
 
@@ -105,38 +118,43 @@ type MiddleendResult struct {
 	AnalyzedBuild  sourcecode.Build
 	DesugaredBuild sourcecode.Build
 	IR             *ir.Program
+	Warnings       Errors // problems that didn't prevent compilation
 }
 
-func (m Middleend) Process(feResult FrontendResult) (MiddleendResult, *Error) {
-	analyzedBuild, err := m.analyzer.AnalyzeExecutableBuild(
+// Process returns all the problems found if program can't be compiled.
+func (m Middleend) Process(feResult FrontendResult) (MiddleendResult, Errors) {
+	analyzedBuild, errs := m.analyzer.AnalyzeExecutableBuild(
 		feResult.ParsedBuild,
 		feResult.MainPkg,
 	)
-	if err != nil {
-		return MiddleendResult{}, err
+	if errs.HasErrors() {
+		return MiddleendResult{}, errs
 	}
 
 	desugaredBuild, derr := m.desugarer.Desugar(analyzedBuild)
 	if derr != nil {
-		return MiddleendResult{}, err
+		return MiddleendResult{}, Errors{{
+			Message: derr.Error(),
+		}}
 	}
 
 	irProg, irerr := m.irgen.Generate(desugaredBuild, feResult.MainPkg)
 	if irerr != nil {
-		return MiddleendResult{}, &Error{
+		return MiddleendResult{}, Errors{{
 			Message: "internal error: unable to generate IR",
 			Meta: &core.Meta{
 				Location: core.Location{
 					ModRef: desugaredBuild.EntryModRef,
 				},
 			},
-		}
+		}}
 	}
 
 	return MiddleendResult{
 		AnalyzedBuild:  analyzedBuild,
 		DesugaredBuild: desugaredBuild,
 		IR:             irProg,
+		Warnings:       errs,
 	}, nil
 }
 
@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"context"
+	"time"
 
 	"github.com/nevalang/neva/internal/compiler/ir"
 	src "github.com/nevalang/neva/internal/compiler/sourcecode"
@@ -12,6 +13,7 @@ const (
 	ExternDirective    src.Directive = "extern"
 	BindDirective      src.Directive = "bind"
 	AutoportsDirective src.Directive = "autoports"
+	BufferDirective    src.Directive = "buffer"
 )
 
 type (
@@ -36,7 +38,7 @@ type (
 	RawPackage map[string][]byte
 
 	Analyzer interface {
-		AnalyzeExecutableBuild(mod src.Build, mainPkgName string) (src.Build, *Error)
+		AnalyzeExecutableBuild(mod src.Build, mainPkgName string) (src.Build, Errors)
 	}
 
 	Desugarer interface {
@@ -48,6 +50,23 @@ type (
 	}
 
 	Backend interface {
-		Emit(dst string, prog *ir.Program, trace bool) error
+		Emit(dst string, prog *ir.Program, opts EmitOptions) error
 	}
+
+	// EmitOptions are options that affect behaviour of the generated program.
+	EmitOptions struct {
+		Trace        bool          // Write trace file when program runs.
+		TraceFormat  TraceFormat   // Format of the trace file, only used if Trace is true.
+		Metrics      bool          // Write metrics.json and metrics.prom files when program exits.
+		Assertions   bool          // Check runtime invariants on every message and fail program if they are violated.
+		DrainTimeout time.Duration // How long program can finish in-flight work after SIGINT or SIGTERM.
+	}
+
+	// TraceFormat is a format of the trace file written by the generated program.
+	TraceFormat string
+)
+
+const (
+	TraceFormatText TraceFormat = "text"
+	TraceFormatJSON TraceFormat = "json"
 )
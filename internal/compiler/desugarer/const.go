@@ -6,10 +6,14 @@ import (
 )
 
 // handleConst handles case when constant has integer value and type is float.
+// Values wrapped into unions are handled the same way.
 func (d *Desugarer) handleConst(constant src.Const) (src.Const, error) {
 	if constant.Value.Message == nil {
 		return constant, nil
 	}
+	if constant.Value.Message.Union != nil {
+		return d.handleUnionConst(constant)
+	}
 	if constant.TypeExpr.String() != "float" {
 		return constant, nil
 	}
@@ -21,6 +21,7 @@ type Desugarer struct {
 	fanOutCounter         uint64
 	fanInCounter          uint64
 	rangeCounter          uint64
+	unionWrapCounter      uint64
 	// Arithmetic
 	addCounter uint64
 	subCounter uint64
@@ -130,6 +130,37 @@ func (d *Desugarer) desugarNormalConnection(
 	nodesToInsert map[string]src.Node,
 	constsToInsert map[string]src.Const,
 ) (desugarConnectionResult, error) {
+	if wrapped, wrapConns, ok := d.desugarUnionWraps(normConn, nodesToInsert, constsToInsert); ok {
+		result, err := d.desugarNormalConnection(
+			iface,
+			wrapped,
+			nodePortsUsed,
+			scope,
+			nodes,
+			nodesToInsert,
+			constsToInsert,
+		)
+		if err != nil {
+			return desugarConnectionResult{}, err
+		}
+		desugaredWrapConns, err := d.desugarConnections(
+			iface,
+			wrapConns,
+			nodePortsUsed,
+			scope,
+			nodes,
+			nodesToInsert,
+			constsToInsert,
+		)
+		if err != nil {
+			return desugarConnectionResult{}, fmt.Errorf("desugar union wraps: %w", err)
+		}
+		return desugarConnectionResult{
+			replace: result.replace,
+			insert:  append(result.insert, desugaredWrapConns...),
+		}, nil
+	}
+
 	if fanIn := len(normConn.Senders) > 1; fanIn {
 		result, err := d.desugarFanIn(
 			iface,
@@ -1,213 +1,200 @@
 package desugarer
 
-// import (
-// 	"fmt"
-
-// 	"github.com/nevalang/neva/internal/compiler"
-// 	src "github.com/nevalang/neva/internal/compiler/sourcecode"
-// 	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
-// )
-
-// type handleUnionSenderResult struct {
-// 	replace src.Connection
-// 	insert  []src.Connection
-// }
-
-// // desugarUnionSender handles the four cases of union senders:
-// // 1. Input::Int -> (non-chained, tag only)
-// // 2. -> Input::Int -> (chained, tag only)
-// // 3. Input::Int(foo) -> (non-chained, with value)
-// // 4. -> Input::Int(foo) -> (chained, with value)
-// func (d *Desugarer) desugarUnionSender(
-// 	union src.UnionSender,
-// 	normConn src.NormalConnection,
-// 	nodesToInsert map[string]src.Node,
-// 	constsToInsert map[string]src.Const,
-// ) (handleUnionSenderResult, error) {
-// 	if union.Data == nil {
-// 		// cases 1 & 2: tag only
-// 		return d.handleTagOnlyUnionSender(union, normConn, nodesToInsert, constsToInsert)
-// 	}
-// 	// cases 3 & 4: with value
-// 	return d.handleValueUnionSender(union, normConn, nodesToInsert, constsToInsert)
-// }
-
-// // handleTagOnlyUnionSender handles cases 1 & 2 (tag-only union senders)
-// func (d *Desugarer) handleTagOnlyUnionSender(
-// 	union src.UnionSender,
-// 	normConn src.NormalConnection,
-// 	nodesToInsert map[string]src.Node,
-// 	constsToInsert map[string]src.Const,
-// ) (handleUnionSenderResult, error) {
-// 	// create virtual const of type union with specified tag
-// 	d.virtualConstCount++
-// 	constName := fmt.Sprintf("__union_const__%d", d.virtualConstCount)
-
-// 	// create const with union type and tag
-// 	constsToInsert[constName] = src.Const{
-// 		Value: src.ConstValue{
-// 			Message: &src.MsgLiteral{
-// 				Union: &src.UnionLiteral{
-// 					EntityRef: union.EntityRef,
-// 					Tag:       union.Tag,
-// 				},
-// 			},
-// 		},
-// 		Meta: union.Meta,
-// 	}
-
-// 	// create new node and bind const to it
-// 	constNodeName := fmt.Sprintf("__new__%d", d.virtualConstCount)
-// 	nodesToInsert[constNodeName] = src.Node{
-// 		EntityRef: core.EntityRef{
-// 			Pkg:  "builtin",
-// 			Name: "New",
-// 		},
-// 		Directives: map[src.Directive][]string{
-// 			compiler.BindDirective: {constName},
-// 		},
-// 		Meta: union.Meta,
-// 	}
-
-// 	// create connection from new node to original receiver
-// 	replace := src.Connection{
-// 		Normal: &src.NormalConnection{
-// 			Senders: []src.ConnectionSender{{
-// 				PortAddr: &src.PortAddr{
-// 					Node: constNodeName,
-// 					Port: "res",
-// 				},
-// 				Meta: union.Meta,
-// 			}},
-// 			Receivers: normConn.Receivers,
-// 			Meta:      union.Meta,
-// 		},
-// 		Meta: union.Meta,
-// 	}
-
-// 	insert := []src.Connection{
-// 		{
-// 			Normal: &src.NormalConnection{
-// 				Senders: []src.ConnectionSender{{
-// 					Const: &src.Const{
-// 						Value: src.ConstValue{
-// 							Message: &src.MsgLiteral{
-// 								Union: &src.UnionLiteral{
-// 									EntityRef: union.EntityRef,
-// 									Tag:       union.Tag,
-// 								},
-// 							},
-// 						},
-// 					},
-// 					Meta: union.Meta,
-// 				}},
-// 				Receivers: []src.ConnectionReceiver{{
-// 					PortAddr: &src.PortAddr{
-// 						Node: constNodeName,
-// 						Port: "data",
-// 					},
-// 					Meta: union.Meta,
-// 				}},
-// 				Meta: union.Meta,
-// 			},
-// 			Meta: union.Meta,
-// 		},
-// 	}
-
-// 	return handleUnionSenderResult{
-// 		replace: replace,
-// 		insert:  insert,
-// 	}, nil
-// }
-
-// // handleValueUnionSender handles cases 3 & 4 (union senders with wrapped values)
-// func (d *Desugarer) handleValueUnionSender(
-// 	union src.UnionSender,
-// 	normConn src.NormalConnection,
-// 	nodesToInsert map[string]src.Node,
-// 	constsToInsert map[string]src.Const,
-// ) (handleUnionSenderResult, error) {
-// 	// create virtual const for tag
-// 	d.virtualConstCount++
-// 	constName := fmt.Sprintf("__union_tag__%d", d.virtualConstCount)
-
-// 	constsToInsert[constName] = src.Const{
-// 		Value: src.ConstValue{
-// 			Message: &src.MsgLiteral{
-// 				Str: &union.Tag,
-// 			},
-// 		},
-// 		Meta: union.Meta,
-// 	}
-
-// 	// create union wrapper node
-// 	nodeName := fmt.Sprintf("__union__%d", d.virtualConstCount)
-// 	nodesToInsert[nodeName] = src.Node{
-// 		EntityRef: core.EntityRef{
-// 			Pkg:  "builtin",
-// 			Name: "UnionWrap",
-// 		},
-// 		Meta: union.Meta,
-// 	}
-
-// 	// create connections for the union wrapper
-// 	replace := src.Connection{
-// 		Normal: &src.NormalConnection{
-// 			Senders: []src.ConnectionSender{{
-// 				PortAddr: &src.PortAddr{
-// 					Node: nodeName,
-// 					Port: "res",
-// 				},
-// 				Meta: union.Meta,
-// 			}},
-// 			Receivers: normConn.Receivers,
-// 			Meta:      union.Meta,
-// 		},
-// 		Meta: union.Meta,
-// 	}
-
-// 	insert := []src.Connection{
-// 		{
-// 			Normal: &src.NormalConnection{
-// 				Senders: []src.ConnectionSender{{
-// 					Const: &src.Const{
-// 						Value: src.ConstValue{
-// 							Message: &src.MsgLiteral{
-// 								Str: &union.Tag,
-// 							},
-// 						},
-// 					},
-// 					Meta: union.Meta,
-// 				}},
-// 				Receivers: []src.ConnectionReceiver{{
-// 					PortAddr: &src.PortAddr{
-// 						Node: nodeName,
-// 						Port: "tag",
-// 					},
-// 					Meta: union.Meta,
-// 				}},
-// 				Meta: union.Meta,
-// 			},
-// 			Meta: union.Meta,
-// 		},
-// 		{
-// 			Normal: &src.NormalConnection{
-// 				Senders: []src.ConnectionSender{*union.Data},
-// 				Receivers: []src.ConnectionReceiver{{
-// 					PortAddr: &src.PortAddr{
-// 						Node: nodeName,
-// 						Port: "data",
-// 					},
-// 					Meta: union.Meta,
-// 				}},
-// 				Meta: union.Meta,
-// 			},
-// 			Meta: union.Meta,
-// 		},
-// 	}
-
-// 	return handleUnionSenderResult{
-// 		replace: replace,
-// 		insert:  insert,
-// 	}, nil
-// }
+import (
+	"fmt"
+
+	"github.com/nevalang/neva/internal/compiler"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+)
+
+// handleUnionConst desugars value wrapped into union message
+// using type of the union element that is selected by tag.
+// E.g. integer literal wrapped into `int | float` by `float` tag becomes float literal.
+func (d *Desugarer) handleUnionConst(constant src.Const) (src.Const, error) {
+	union := constant.Value.Message.Union
+	if union.Data == nil || union.Data.Message == nil {
+		return constant, nil
+	}
+
+	if constant.TypeExpr.Lit == nil || int(union.Tag) >= len(constant.TypeExpr.Lit.Union) {
+		return constant, nil
+	}
+
+	desugaredData, err := d.handleConst(src.Const{
+		TypeExpr: constant.TypeExpr.Lit.Union[union.Tag],
+		Value:    *union.Data,
+		Meta:     constant.Meta,
+	})
+	if err != nil {
+		return src.Const{}, err
+	}
+
+	return src.Const{
+		TypeExpr: constant.TypeExpr,
+		Value: src.ConstValue{
+			Message: &src.MsgLiteral{
+				Union: &src.UnionMessage{
+					Tag:  union.Tag,
+					Data: &desugaredData.Value,
+				},
+				Meta: constant.Value.Message.Meta,
+			},
+		},
+		Meta: constant.Meta,
+	}, nil
+}
+
+var unionWrapComponentRef = core.EntityRef{
+	Pkg:  "builtin",
+	Name: "UnionWrap",
+}
+
+// desugarUnionWraps wraps messages that are sent to union receivers by senders of union elements.
+// Analyzer tells which receivers need it. If all senders send the same element,
+// receiver is replaced with UnionWrap node, otherwise each sender is wrapped separately.
+// It returns connection to use instead of the given one, connections to insert,
+// and false if there's nothing to wrap.
+func (d *Desugarer) desugarUnionWraps(
+	normConn src.NormalConnection,
+	nodesToInsert map[string]src.Node,
+	constsToInsert map[string]src.Const,
+) (src.NormalConnection, []src.Connection, bool) {
+	hasWraps := false
+	for _, receiver := range normConn.Receivers {
+		if receiver.UnionWrap != nil {
+			hasWraps = true
+			break
+		}
+	}
+	if !hasWraps {
+		return src.NormalConnection{}, nil, false
+	}
+
+	locOnlyMeta := core.Meta{Location: normConn.Senders[0].Meta.Location}
+
+	senders := normConn.Senders
+	receivers := make([]src.ConnectionReceiver, 0, len(normConn.Receivers))
+	insert := []src.Connection{}
+
+	for _, receiver := range normConn.Receivers {
+		wrap := receiver.UnionWrap
+		if wrap == nil {
+			receivers = append(receivers, receiver)
+			continue
+		}
+		receiver.UnionWrap = nil
+
+		if tag, ok := wrap.Tag(); ok {
+			// senders -> wrap:data; wrap:res -> receiver
+			wrapNodeName := d.insertUnionWrapNode(wrap.Type, tag, locOnlyMeta, nodesToInsert, constsToInsert)
+			receivers = append(receivers, src.ConnectionReceiver{
+				PortAddr: &src.PortAddr{
+					Node: wrapNodeName,
+					Port: "data",
+					Meta: locOnlyMeta,
+				},
+				Meta: locOnlyMeta,
+			})
+			insert = append(insert, src.Connection{
+				Normal: &src.NormalConnection{
+					Senders: []src.ConnectionSender{{
+						PortAddr: &src.PortAddr{
+							Node: wrapNodeName,
+							Port: "res",
+							Meta: locOnlyMeta,
+						},
+						Meta: locOnlyMeta,
+					}},
+					Receivers: []src.ConnectionReceiver{receiver},
+					Meta:      locOnlyMeta,
+				},
+				Meta: locOnlyMeta,
+			})
+			continue
+		}
+
+		// analyzer makes sure there's no other receivers in this case
+		senders = make([]src.ConnectionSender, 0, len(normConn.Senders))
+		for i, sender := range normConn.Senders {
+			if wrap.Tags[i] == nil {
+				senders = append(senders, sender)
+				continue
+			}
+			// sender -> wrap:data; [..., wrap:res, ...] -> receiver
+			wrapNodeName := d.insertUnionWrapNode(wrap.Type, *wrap.Tags[i], locOnlyMeta, nodesToInsert, constsToInsert)
+			insert = append(insert, src.Connection{
+				Normal: &src.NormalConnection{
+					Senders: []src.ConnectionSender{sender},
+					Receivers: []src.ConnectionReceiver{{
+						PortAddr: &src.PortAddr{
+							Node: wrapNodeName,
+							Port: "data",
+							Meta: locOnlyMeta,
+						},
+						Meta: locOnlyMeta,
+					}},
+					Meta: locOnlyMeta,
+				},
+				Meta: locOnlyMeta,
+			})
+			senders = append(senders, src.ConnectionSender{
+				PortAddr: &src.PortAddr{
+					Node: wrapNodeName,
+					Port: "res",
+					Meta: locOnlyMeta,
+				},
+				Meta: locOnlyMeta,
+			})
+		}
+		receivers = append(receivers, receiver)
+	}
+
+	return src.NormalConnection{
+		Senders:   senders,
+		Receivers: receivers,
+		Meta:      normConn.Meta,
+	}, insert, true
+}
+
+// insertUnionWrapNode inserts UnionWrap node with bound tag-only constant and returns its name.
+func (d *Desugarer) insertUnionWrapNode(
+	unionType ts.Expr,
+	tag uint8,
+	meta core.Meta,
+	nodesToInsert map[string]src.Node,
+	constsToInsert map[string]src.Const,
+) string {
+	d.virtualConstCount++
+	constName := fmt.Sprintf("__const__%d", d.virtualConstCount)
+
+	constsToInsert[constName] = src.Const{
+		TypeExpr: unionType,
+		Value: src.ConstValue{
+			Message: &src.MsgLiteral{
+				Union: &src.UnionMessage{Tag: tag},
+				Meta:  meta,
+			},
+		},
+		Meta: meta,
+	}
+
+	d.unionWrapCounter++
+	nodeName := fmt.Sprintf("__union_wrap__%d", d.unionWrapCounter)
+
+	nodesToInsert[nodeName] = src.Node{
+		Directives: map[src.Directive][]string{
+			compiler.BindDirective: {constName},
+		},
+		EntityRef: core.EntityRef{
+			Pkg:  unionWrapComponentRef.Pkg,
+			Name: unionWrapComponentRef.Name,
+			Meta: meta,
+		},
+		TypeArgs: []ts.Expr{unionType},
+		Meta:     meta,
+	}
+
+	return nodeName
+}
@@ -2,13 +2,44 @@ package compiler
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
 )
 
+// Severity tells whether the problem prevents program from being compiled.
+type Severity uint8
+
+const (
+	SeverityError   Severity = iota // zero value so errors don't have to set it explicitly
+	SeverityWarning                 // program can be compiled but probably has a mistake
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// ErrorCode identifies kind of the problem, so tools can handle it without parsing the message.
+type ErrorCode string
+
+const (
+	ErrorCodeImportNotFound ErrorCode = "import-not-found" // node refers to package that is not imported
+	ErrorCodeEntityNotFound ErrorCode = "entity-not-found" // node refers to entity that doesn't exist
+	ErrorCodeTypeArgsCount  ErrorCode = "type-args-count"  // node has wrong number of type arguments
+	ErrorCodeUnusedNode     ErrorCode = "unused-node"      // node is not used in the network
+	ErrorCodeUnusedOutports ErrorCode = "unused-outports"  // none of the node's outports are used
+	ErrorCodeUnhandledError ErrorCode = "unhandled-error"  // node's err outport is not used
+)
+
 type Error struct {
-	Message string
-	Meta    *core.Meta
+	Message  string
+	Meta     *core.Meta
+	Severity Severity
+	Code     ErrorCode // empty for problems that don't need special handling
 
 	child *Error
 }
@@ -18,10 +49,17 @@ func (e Error) Wrap(child *Error) *Error {
 	return &e
 }
 
+// Unwrap returns the deepest child error.
+// If the deepest child doesn't have meta, meta of its closest parent is used.
 func (e Error) Unwrap() *Error {
+	meta := e.Meta
 	for e.child != nil {
 		e = *e.child
+		if e.Meta != nil {
+			meta = e.Meta
+		}
 	}
+	e.Meta = meta
 	return &e
 }
 
@@ -37,5 +75,72 @@ func (e *Error) Error() string {
 		s = current.Message
 	}
 
+	if current.Severity == SeverityWarning {
+		s = "warning: " + s
+	}
+
 	return s
 }
+
+// Errors is a list of independent problems, e.g. errors of different entities.
+// It can contain warnings, use HasErrors to find out if compilation failed.
+type Errors []*Error
+
+// Wrap wraps every error of the list with a copy of the given parent.
+func (e Errors) Wrap(parent Error) Errors {
+	wrapped := make(Errors, 0, len(e))
+	for _, err := range e {
+		wrapped = append(wrapped, parent.Wrap(err))
+	}
+	return wrapped
+}
+
+// HasErrors reports whether there's at least one problem that is not a warning.
+func (e Errors) HasErrors() bool {
+	for _, err := range e {
+		if err.Unwrap().Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Warnings returns problems that don't prevent compilation.
+func (e Errors) Warnings() Errors {
+	var warnings Errors
+	for _, err := range e {
+		if err.Unwrap().Severity == SeverityWarning {
+			warnings = append(warnings, err)
+		}
+	}
+	return warnings
+}
+
+// Sort orders problems by their position in the source code, errors go before warnings.
+func (e Errors) Sort() {
+	sort.SliceStable(e, func(i, j int) bool {
+		if e[i].Unwrap().Severity != e[j].Unwrap().Severity {
+			return e[i].Unwrap().Severity < e[j].Unwrap().Severity
+		}
+		a, b := e[i].Unwrap().Meta, e[j].Unwrap().Meta
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if a.Location != b.Location {
+			return a.Location.String() < b.Location.String()
+		}
+		if a.Start.Line != b.Start.Line {
+			return a.Start.Line < b.Start.Line
+		}
+		return a.Start.Column < b.Start.Column
+	})
+}
+
+// Error returns every problem on its own line.
+func (e Errors) Error() string {
+	ss := make([]string, 0, len(e))
+	for _, err := range e {
+		ss = append(ss, err.Error())
+	}
+	return strings.Join(ss, "\n")
+}
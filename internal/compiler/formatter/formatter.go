@@ -0,0 +1,155 @@
+// Package formatter implements canonical formatting of the source code.
+// It works on tokens of the parse tree built by the ANTLR parser
+// and only changes whitespace between them, so comments are always preserved.
+package formatter
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/nevalang/neva/internal/compiler"
+	"github.com/nevalang/neva/internal/compiler/parser"
+	generated "github.com/nevalang/neva/internal/compiler/parser/generated"
+)
+
+// indent is a single level of indentation.
+const indent = "\t"
+
+// Format returns formatted content of the file.
+// Files with syntax errors are not formatted.
+func Format(content []byte) ([]byte, *compiler.Error) {
+	tokens, err := tokenize(content)
+	if err != nil {
+		return nil, err
+	}
+
+	p := printer{tokens: tokens}
+	formatted := p.print()
+
+	// formatting must never change the program, so the result is checked to consist of the same tokens
+	check, err := tokenize(formatted)
+	if err != nil || !sameTokens(tokens, check) {
+		return nil, &compiler.Error{
+			Message: "formatter produced invalid code, please report this bug",
+		}
+	}
+
+	return formatted, nil
+}
+
+// token is a terminal of the parse tree.
+type token struct {
+	text string
+	typ  string // symbolic name like IDENTIFIER or COMMENT, empty for literals like '{'
+	rule string // name of the grammar rule the token belongs to
+	next string // type of the next token, used to find out if bracket is followed by line break
+}
+
+func (t token) isNewline() bool { return t.typ == "NEWLINE" }
+
+func (t token) isComment() bool { return t.typ == "COMMENT" }
+
+// isOpen reports whether token is opening bracket.
+// Angle brackets are only brackets in type parameters and type arguments, otherwise they are operators.
+func (t token) isOpen() bool {
+	switch t.text {
+	case "{", "(", "[":
+		return true
+	case "<":
+		return t.rule == "typeParams" || t.rule == "typeArgs"
+	}
+	return false
+}
+
+// isClose reports whether token is closing bracket.
+func (t token) isClose() bool {
+	switch t.text {
+	case "}", ")", "]":
+		return true
+	case ">":
+		return t.rule == "typeParams" || t.rule == "typeArgs"
+	}
+	return false
+}
+
+// tokenize parses content and returns tokens of the parse tree in the order they are written.
+func tokenize(content []byte) ([]token, *compiler.Error) {
+	input := antlr.NewInputStream(string(content))
+	lexer := generated.NewnevaLexer(input)
+	lexerErrors := &parser.CustomErrorListener{}
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(lexerErrors)
+	tokenStream := antlr.NewCommonTokenStream(lexer, 0)
+
+	parserErrors := &parser.CustomErrorListener{}
+	prsr := generated.NewnevaParser(tokenStream)
+	prsr.RemoveErrorListeners()
+	prsr.AddErrorListener(parserErrors)
+	prsr.BuildParseTrees = true
+
+	tree := prsr.Prog()
+
+	if len(lexerErrors.Errors) > 0 {
+		return nil, lexerErrors.Errors[0]
+	}
+
+	if len(parserErrors.Errors) > 0 {
+		return nil, parserErrors.Errors[0]
+	}
+
+	var tokens []token
+	collectTokens(tree, "", prsr.RuleNames, prsr.SymbolicNames, &tokens)
+
+	for i := range tokens {
+		if i+1 < len(tokens) {
+			tokens[i].next = tokens[i+1].typ
+		}
+	}
+
+	return tokens, nil
+}
+
+func collectTokens(tree antlr.Tree, rule string, ruleNames, symbolicNames []string, tokens *[]token) {
+	switch node := tree.(type) {
+	case antlr.TerminalNode:
+		symbol := node.GetSymbol()
+		if symbol.GetTokenType() == antlr.TokenEOF {
+			return
+		}
+		*tokens = append(*tokens, token{
+			text: symbol.GetText(),
+			typ:  symbolicNames[symbol.GetTokenType()],
+			rule: rule,
+		})
+	case antlr.ParserRuleContext:
+		name := ruleNames[node.GetRuleIndex()]
+		for _, child := range node.GetChildren() {
+			collectTokens(child, name, ruleNames, symbolicNames, tokens)
+		}
+	}
+}
+
+// sameTokens reports whether both lists consist of the same tokens, not counting line breaks.
+func sameTokens(a, b []token) bool {
+	a, b = withoutNewlines(a), withoutNewlines(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if strings.TrimRight(a[i].text, " \t") != strings.TrimRight(b[i].text, " \t") {
+			return false
+		}
+	}
+	return true
+}
+
+func withoutNewlines(tokens []token) []token {
+	result := make([]token, 0, len(tokens))
+	for _, t := range tokens {
+		if !t.isNewline() {
+			result = append(result, t)
+		}
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "imports",
+			in:   "import   {fmt,strings}\n\n\n\nimport\n{\n\n  fmt\n    @:foo/bar\n\n}",
+			out:  "import { fmt, strings }\n\nimport {\n\tfmt\n\t@:foo/bar\n}\n",
+		},
+		{
+			name: "types",
+			in: `pub type   Point   struct{
+x int
+y   list< int >}
+type  U int|
+string
+type E enum{A,B}`,
+			out: `pub type Point struct {
+	x int
+	y list<int>
+}
+type U int |
+	string
+type E enum { A, B }
+`,
+		},
+		{
+			name: "const",
+			in:   "const p  Point={x:1,y:[ -1,2 ]}\nconst d Day=Day::Friday",
+			out:  "const p Point = { x: 1, y: [-1, 2] }\nconst d Day = Day::Friday\n",
+		},
+		{
+			name: "component",
+			in: `// Main is the entry point.
+#extern(int int_add,float float_add)
+pub def Main <T> (start any ,[args] T)(stop any){
+
+
+	println fmt.Println<T> ? // prints
+	For<int>{ handler Next },Panic
+	wrapper  Wrapper {
+	printer fmt.Println
+	}
+
+
+	---
+
+
+	:start->{ $p->println:data }
+	println:res   ->.foo.bar-> :stop
+	(a+ -1)->p3:args [0]
+	(!flag ? x :fan_in[1])-> y
+	1..10 -> :stop
+	a:x=>b:y
+}
+`,
+			out: `// Main is the entry point.
+#extern(int int_add, float float_add)
+pub def Main<T>(start any, [args] T) (stop any) {
+	println fmt.Println<T>? // prints
+	For<int>{handler Next}, Panic
+	wrapper Wrapper {
+		printer fmt.Println
+	}
+	---
+	:start -> { $p -> println:data }
+	println:res -> .foo.bar -> :stop
+	(a + -1) -> p3:args[0]
+	(!flag ? x : fan_in[1]) -> y
+	1..10 -> :stop
+	a:x => b:y
+}
+`,
+		},
+		{
+			name: "multiple senders and receivers",
+			in: `def Main() () {
+    [a:x,b:y] -> [c, d]
+    :data -> [
+      handler -> :res,  e,
+          false -> switch
+          {
+         true -> '' -> new -> :err
+            _ -> del
+    } ]
+}`,
+			out: `def Main() () {
+	[a:x, b:y] -> [c, d]
+	:data -> [
+		handler -> :res,
+		e,
+		false -> switch {
+			true -> '' -> new -> :err
+			_ -> del
+		}
+	]
+}
+`,
+		},
+		{
+			name: "comments",
+			in: `// first
+
+// second
+def Main() () {  // trailing
+	// before nodes
+	a A
+	// between nodes
+	---
+	// before connections
+	a -> b // after connection
+	// at the end
+}
+// last`,
+			out: `// first
+
+// second
+def Main() () { // trailing
+	// before nodes
+	a A
+	// between nodes
+	---
+	// before connections
+	a -> b // after connection
+	// at the end
+}
+// last
+`,
+		},
+		{
+			name: "empty",
+			in:   "\n\n",
+			out:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format([]byte(tt.in))
+			require.Nil(t, err)
+			require.Equal(t, tt.out, string(got))
+
+			again, err := Format(got)
+			require.Nil(t, err)
+			require.Equal(t, string(got), string(again))
+		})
+	}
+}
+
+func TestFormat_SyntaxError(t *testing.T) {
+	_, err := Format([]byte("def Main() () {\n    a ->\n}\n"))
+	require.NotNil(t, err)
+	require.Equal(t, 2, err.Meta.Start.Line)
+}
@@ -0,0 +1,251 @@
+package formatter
+
+import (
+	"strings"
+)
+
+// printer writes tokens with canonical whitespace between them.
+// Line breaks written by the user are kept, except for the extra empty lines,
+// and the lines are indented by the nesting level of the brackets.
+type printer struct {
+	tokens []token
+
+	out           strings.Builder
+	prev          *token    // last written token, nil at the start of the file
+	breaks        int       // number of line breaks after the last written token
+	forceBreak    bool      // next token must start a new line
+	lineIndent    int       // indentation level of the current line
+	continuesPrev bool      // current line continues union type from the previous line
+	brackets      []bracket // brackets that are opened but not closed yet
+}
+
+type bracket struct {
+	rule     string
+	indent   int  // indentation level of the line where bracket is opened
+	expanded bool // content starts on a new line
+	hasComma bool
+}
+
+func (p *printer) print() []byte {
+	for i := range p.tokens {
+		tok := p.tokens[i]
+		if tok.isNewline() {
+			p.breaks++
+			continue
+		}
+		p.write(&p.tokens[i])
+	}
+
+	if p.prev == nil {
+		return nil
+	}
+
+	p.out.WriteByte('\n')
+
+	return []byte(p.out.String())
+}
+
+func (p *printer) write(tok *token) {
+	breaks := p.lineBreaks(*tok)
+	p.breaks = 0
+	p.forceBreak = false
+
+	if breaks > 0 {
+		p.out.WriteString(strings.Repeat("\n", breaks))
+		continuesPrev := p.prev.rule == "unionTypeExpr" && p.prev.text == "|" || tok.rule == "unionTypeExpr"
+		p.lineIndent = p.indentOf(*tok, continuesPrev)
+		p.continuesPrev = continuesPrev
+		p.out.WriteString(strings.Repeat(indent, p.lineIndent))
+	} else if p.prev != nil && needSpace(*p.prev, *tok) {
+		p.out.WriteByte(' ')
+	}
+
+	text := tok.text
+	if tok.isComment() {
+		text = strings.TrimRight(text, " \t")
+	}
+	p.out.WriteString(text)
+
+	switch {
+	case tok.isOpen():
+		p.brackets = append(p.brackets, bracket{
+			rule:     tok.rule,
+			indent:   p.lineIndent,
+			expanded: tok.next == "NEWLINE",
+		})
+	case tok.isClose() && len(p.brackets) > 0:
+		p.brackets = p.brackets[:len(p.brackets)-1]
+	case tok.text == "," && len(p.brackets) > 0:
+		top := &p.brackets[len(p.brackets)-1]
+		top.hasComma = true
+		// items of expanded lists are written one per line,
+		// except for nodes that are often grouped like `fmt.Println, Panic`
+		if top.expanded && top.rule != "compBody" {
+			p.forceBreak = true
+		}
+	case tok.text == "---":
+		p.forceBreak = true
+	}
+
+	p.prev = tok
+}
+
+// lineBreaks returns how many line breaks must be written before the token.
+// There's at most one empty line between lines, and no empty lines
+// right after opening bracket, before closing bracket, around `---` and after compiler directives.
+func (p *printer) lineBreaks(tok token) int {
+	if p.prev == nil {
+		return 0
+	}
+
+	// empty brackets like `{}` and keywords followed by `{` are always on the same line
+	if p.prev.isOpen() && tok.isClose() || tok.text == "{" && isKeyword(p.prev.text) {
+		return 0
+	}
+
+	breaks := p.breaks
+
+	maxBreaks := 2
+	if p.prev.isOpen() ||
+		tok.isClose() ||
+		p.prev.text == "---" ||
+		tok.text == "---" ||
+		strings.HasPrefix(p.prev.rule, "compilerDirective") {
+		maxBreaks = 1
+	}
+	breaks = min(breaks, maxBreaks)
+
+	if p.forceBreak || tok.text == "---" || tok.isClose() && p.closesOnNewLine() {
+		breaks = max(breaks, 1)
+	}
+
+	return breaks
+}
+
+// closesOnNewLine reports whether the innermost bracket must be closed on a separate line.
+// Grammar doesn't allow line break before the closing bracket of the lists with single item.
+func (p *printer) closesOnNewLine() bool {
+	if len(p.brackets) == 0 {
+		return false
+	}
+	top := p.brackets[len(p.brackets)-1]
+	if !top.expanded {
+		return false
+	}
+	switch top.rule {
+	case "listLit", "multipleSenderSide", "multipleReceiverSide":
+		return top.hasComma
+	}
+	return true
+}
+
+// indentOf returns indentation level of the line that starts with the token.
+// Union type written on multiple lines is indented after its first line.
+func (p *printer) indentOf(tok token, continuesPrev bool) int {
+	level := 0
+	if len(p.brackets) > 0 {
+		top := p.brackets[len(p.brackets)-1]
+		if tok.isClose() {
+			return top.indent
+		}
+		level = top.indent + 1
+	}
+
+	if continuesPrev {
+		if p.continuesPrev {
+			return p.lineIndent
+		}
+		return max(level, p.lineIndent+1)
+	}
+
+	return level
+}
+
+func isKeyword(text string) bool {
+	switch text {
+	case "import", "struct", "enum", "switch":
+		return true
+	}
+	return false
+}
+
+// needSpace reports whether tokens written on the same line must be separated by space.
+func needSpace(prev, cur token) bool {
+	switch {
+	case cur.isComment():
+		return true
+	case cur.text == ",":
+		return false
+	}
+
+	// brackets
+	switch {
+	case cur.text == "}":
+		return cur.rule != "nodeDIArgs" && prev.text != "{"
+	case cur.isClose():
+		return false
+	case prev.text == "{":
+		return prev.rule != "nodeDIArgs"
+	case prev.isOpen():
+		return false
+	case prev.text == ",":
+		return true
+	}
+
+	// prefixes and infixes that are written without spaces, like `#bind`, `$foo`, `-1`, `1..10` and `Day::Friday`
+	switch {
+	case prev.text == "#", prev.text == "$", prev.rule == "unaryOp":
+		return false
+	case prev.text == "-" && prev.rule != "binaryOp":
+		return false
+	case prev.text == "::", cur.text == "::", prev.text == "..", cur.text == "..":
+		return false
+	}
+
+	switch cur.text {
+	case "(":
+		switch cur.rule {
+		case "compilerDirectivesArgs":
+			return false
+		case "portsDef":
+			// `def Foo(data any) (res any)`
+			return prev.text == ")"
+		}
+	case "[":
+		return cur.rule != "portAddrIdx"
+	case "{":
+		// `Foo{Bar}` but `Foo {` if dependencies are written on separate lines
+		return cur.rule != "nodeDIArgs" || cur.next == "NEWLINE"
+	case "<":
+		return cur.rule != "typeParams" && cur.rule != "typeArgs"
+	case "?":
+		return cur.rule != "errGuard"
+	case ":":
+		switch cur.rule {
+		case "ternaryExpr":
+			return true
+		case "singlePortAddr", "arrPortAddr":
+			// `node:port` but `-> :port`
+			return prev.rule != "portAddrNode"
+		}
+		return false // `@:pkg` and `field: value`
+	case ".", "/":
+		switch cur.rule {
+		case "binaryOp":
+			return true
+		case "structSelectors":
+			// `-> .foo.bar`
+			return prev.rule != "structSelectors"
+		}
+		return false // `pkg.Entity` and `github.com/foo/bar`
+	}
+
+	switch prev.text {
+	case ":":
+		return prev.rule == "ternaryExpr" || prev.rule == "structValueField"
+	case ".", "/":
+		return prev.rule == "binaryOp"
+	}
+
+	return true
+}
@@ -53,3 +53,37 @@ func getFinalReceiver(
 		current = next
 	}
 }
+
+// BufferReduction moves buffer sizes from intermediate receivers to final ones,
+// so they match connections returned by GraphReduction.
+// It must be called with connections before reduction.
+// If several buffered receivers are on the way, the biggest buffer wins.
+func BufferReduction(
+	connections map[PortAddr]PortAddr,
+	buffers map[PortAddr]int,
+) map[PortAddr]int {
+	result := make(map[PortAddr]int, len(buffers))
+
+	for _, receiver := range connections {
+		size := buffers[receiver]
+		visited := map[PortAddr]struct{}{receiver: {}}
+		final := receiver
+		for {
+			next, exists := connections[final]
+			if !exists {
+				break
+			}
+			if _, alreadyVisited := visited[next]; alreadyVisited {
+				break
+			}
+			visited[next] = struct{}{}
+			final = next
+			size = max(size, buffers[final])
+		}
+		if size > result[final] {
+			result[final] = size
+		}
+	}
+
+	return result
+}
@@ -90,3 +90,71 @@ func Test_GraphReduction(t *testing.T) {
 		})
 	}
 }
+
+func Test_BufferReduction(t *testing.T) {
+	tests := []struct {
+		name        string
+		connections map[PortAddr]PortAddr
+		buffers     map[PortAddr]int
+		expected    map[PortAddr]int
+	}{
+		{
+			name: "final_receiver_keeps_its_buffer",
+			// a:foo -> b:bar
+			connections: map[PortAddr]PortAddr{
+				{Path: "a", Port: "foo"}: {Path: "b", Port: "bar"},
+			},
+			buffers: map[PortAddr]int{
+				{Path: "b", Port: "bar"}: 8,
+			},
+			expected: map[PortAddr]int{
+				{Path: "b", Port: "bar"}: 8,
+			},
+		},
+		{
+			name: "intermediate_buffer_moves_to_final_receiver",
+			// a:foo -> b:bar; b:bar -> c:baz
+			connections: map[PortAddr]PortAddr{
+				{Path: "a", Port: "foo"}: {Path: "b", Port: "bar"},
+				{Path: "b", Port: "bar"}: {Path: "c", Port: "baz"},
+			},
+			buffers: map[PortAddr]int{
+				{Path: "b", Port: "bar"}: 16,
+			},
+			expected: map[PortAddr]int{
+				{Path: "c", Port: "baz"}: 16,
+			},
+		},
+		{
+			name: "biggest_buffer_wins",
+			// a:foo -> b:bar; b:bar -> c:baz; c:baz -> d:qux
+			connections: map[PortAddr]PortAddr{
+				{Path: "a", Port: "foo"}: {Path: "b", Port: "bar"},
+				{Path: "b", Port: "bar"}: {Path: "c", Port: "baz"},
+				{Path: "c", Port: "baz"}: {Path: "d", Port: "qux"},
+			},
+			buffers: map[PortAddr]int{
+				{Path: "b", Port: "bar"}: 4,
+				{Path: "d", Port: "qux"}: 2,
+			},
+			expected: map[PortAddr]int{
+				{Path: "d", Port: "qux"}: 4,
+			},
+		},
+		{
+			name: "unbuffered",
+			connections: map[PortAddr]PortAddr{
+				{Path: "a", Port: "foo"}: {Path: "b", Port: "bar"},
+			},
+			buffers:  map[PortAddr]int{},
+			expected: map[PortAddr]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := BufferReduction(tt.connections, tt.buffers)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 type Program struct {
 	Connections map[PortAddr]PortAddr `json:"-" yaml:"-"` // Hide from default marshaling
 	Funcs       []FuncCall            `json:"funcs,omitempty" yaml:"funcs,omitempty"`
+	Buffers     map[PortAddr]int      `json:"-" yaml:"-"` // Buffer sizes of connections by receiver, missing means unbuffered.
 }
 
 // MarshalJSON implements custom JSON marshaling for Program
@@ -20,12 +21,19 @@ func (p Program) MarshalJSON() ([]byte, error) {
 		connections[from.String()] = to.String()
 	}
 
+	buffers := make(map[string]int, len(p.Buffers))
+	for receiver, size := range p.Buffers {
+		buffers[receiver.String()] = size
+	}
+
 	return json.Marshal(struct {
 		programAlias
 		Connections map[string]string `json:"connections,omitempty"`
+		Buffers     map[string]int    `json:"buffers,omitempty"`
 	}{
 		programAlias: programAlias(p),
 		Connections:  connections,
+		Buffers:      buffers,
 	})
 }
 
@@ -91,8 +99,11 @@ type Message struct {
 	Int          int64              `json:"int,omitempty" yaml:"int,omitempty"`
 	Float        float64            `json:"float,omitempty" yaml:"float,omitempty"`
 	String       string             `json:"str,omitempty" yaml:"str,omitempty"`
+	Bytes        []byte             `json:"bytes,omitempty" yaml:"bytes,omitempty"`
 	List         []Message          `json:"list,omitempty" yaml:"list,omitempty"`
 	DictOrStruct map[string]Message `json:"map,omitempty" yaml:"map,omitempty"`
+	Tag          uint8              `json:"tag,omitempty" yaml:"tag,omitempty"`     // Union tag.
+	Value        *Message           `json:"value,omitempty" yaml:"value,omitempty"` // Union value, nil for tag-only union.
 }
 
 // MsgType is an enumeration of message types.
@@ -103,7 +114,9 @@ const (
 	MsgTypeInt    MsgType = "int"
 	MsgTypeFloat  MsgType = "float"
 	MsgTypeString MsgType = "string"
+	MsgTypeBytes  MsgType = "bytes"
 	MsgTypeList   MsgType = "list"
 	MsgTypeDict   MsgType = "dict"
 	MsgTypeStruct MsgType = "struct"
+	MsgTypeUnion  MsgType = "union"
 )
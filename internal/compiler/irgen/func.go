@@ -37,6 +37,30 @@ func (Generator) getFuncRef(component src.Component, nodeTypeArgs []ts.Expr) (st
 	return "", errors.New("type argument mismatches runtime func directive")
 }
 
+// getBufferSize returns buffer size for channels of node's inports or zero if they are unbuffered.
+func getBufferSize(node src.Node, scope src.Scope) (int, error) {
+	args, ok := node.Directives[compiler.BufferDirective]
+	if !ok {
+		return 0, nil
+	}
+
+	entity, location, err := scope.Entity(compiler.ParseEntityRef(args[0]))
+	if err != nil {
+		return 0, err
+	}
+
+	msg, err := getIRMsgBySrcRef(
+		entity.Const.Value,
+		scope.Relocate(location),
+		entity.Const.TypeExpr,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(msg.Int), nil
+}
+
 func getConfigMsg(node src.Node, scope src.Scope) (*ir.Message, error) {
 	args, ok := node.Directives[compiler.BindDirective]
 	if !ok {
@@ -60,6 +60,7 @@ func (g Generator) Generate(
 	result := &ir.Program{
 		Connections: map[ir.PortAddr]ir.PortAddr{},
 		Funcs:       []ir.FuncCall{},
+		Buffers:     map[ir.PortAddr]int{},
 	}
 
 	g.processNode(
@@ -71,6 +72,7 @@ func (g Generator) Generate(
 	return &ir.Program{
 		Connections: result.Connections,
 		Funcs:       result.Funcs,
+		Buffers:     result.Buffers,
 	}, nil
 }
 
@@ -90,6 +92,17 @@ func (g Generator) processNode(
 	inportAddrs := g.insertAndReturnInports(nodeCtx)   // for inports we only use parent context because all inports are used
 	outportAddrs := g.insertAndReturnOutports(nodeCtx) //  for outports we use both parent context and component's interface
 
+	bufferSize, err := getBufferSize(nodeCtx.node, scope)
+	if err != nil {
+		panic(err)
+	}
+	if bufferSize > 0 {
+		// for composite nodes inports are intermediate, backend moves buffers to final receivers
+		for _, addr := range inportAddrs {
+			result.Buffers[addr] = bufferSize
+		}
+	}
+
 	runtimeFuncRef, err := g.getFuncRef(component, nodeCtx.node.TypeArgs)
 	if err != nil {
 		panic(err)
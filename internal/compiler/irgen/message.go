@@ -39,6 +39,13 @@ func getIRMsgBySrcRef(
 			Float: *constant.Message.Float,
 		}, nil
 	case constant.Message.Str != nil:
+		// bytes have no literal of their own, they are written as strings
+		if isBytesType(typeExpr, scope) {
+			return &ir.Message{
+				Type:  ir.MsgTypeBytes,
+				Bytes: []byte(*constant.Message.Str),
+			}, nil
+		}
 		return &ir.Message{
 			Type:   ir.MsgTypeString,
 			String: *constant.Message.Str,
@@ -64,6 +71,27 @@ func getIRMsgBySrcRef(
 			Type: ir.MsgTypeList,
 			List: listMsg,
 		}, nil
+	case constant.Message.Union != nil:
+		union := &ir.Message{
+			Type: ir.MsgTypeUnion,
+			Tag:  constant.Message.Union.Tag,
+		}
+		if constant.Message.Union.Data == nil {
+			return union, nil
+		}
+
+		var elType ts.Expr
+		if typeExpr.Lit != nil && int(union.Tag) < len(typeExpr.Lit.Union) {
+			elType = typeExpr.Lit.Union[union.Tag]
+		}
+
+		value, err := getIRMsgBySrcRef(*constant.Message.Union.Data, scope, elType)
+		if err != nil {
+			return nil, err
+		}
+		union.Value = value
+
+		return union, nil
 	case constant.Message.DictOrStruct != nil:
 		m := make(map[string]ir.Message, len(constant.Message.DictOrStruct))
 
@@ -100,3 +128,22 @@ func getIRMsgBySrcRef(
 
 	return nil, errors.New("unknown msg type")
 }
+
+// isBytesType reports whether type expression refers to builtin bytes type,
+// directly or through type definitions like `type blob bytes`.
+func isBytesType(typeExpr ts.Expr, scope src.Scope) bool {
+	for typeExpr.Inst != nil {
+		entity, location, err := scope.Entity(typeExpr.Inst.Ref)
+		if err != nil || entity.Kind != src.TypeEntity {
+			return false
+		}
+		if entity.Type.BodyExpr == nil { // base type
+			return location.ModRef.Path == "std" &&
+				location.Package == "builtin" &&
+				typeExpr.Inst.Ref.Name == "bytes"
+		}
+		typeExpr = *entity.Type.BodyExpr
+		scope = scope.Relocate(location)
+	}
+	return false
+}
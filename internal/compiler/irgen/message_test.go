@@ -0,0 +1,100 @@
+package irgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nevalang/neva/internal/compiler"
+	"github.com/nevalang/neva/internal/compiler/ir"
+	src "github.com/nevalang/neva/internal/compiler/sourcecode"
+	"github.com/nevalang/neva/internal/compiler/sourcecode/core"
+	ts "github.com/nevalang/neva/internal/compiler/sourcecode/typesystem"
+	"github.com/nevalang/neva/pkg"
+)
+
+func TestGetIRMsgBySrcRefBytes(t *testing.T) {
+	typeEntity := func(body *ts.Expr) src.Entity {
+		return src.Entity{Kind: src.TypeEntity, Type: ts.Def{BodyExpr: body}}
+	}
+	inst := func(name string) ts.Expr {
+		return ts.Expr{Inst: &ts.InstExpr{Ref: core.EntityRef{Name: name}}}
+	}
+
+	stdModRef := core.ModuleRef{Path: "std", Version: pkg.Version}
+	entryModRef := core.ModuleRef{Path: "@"}
+	build := src.Build{
+		EntryModRef: entryModRef,
+		Modules: map[core.ModuleRef]src.Module{
+			stdModRef: {
+				Packages: map[string]src.Package{
+					"builtin": {"types": {Entities: map[string]src.Entity{
+						"bytes":  typeEntity(nil),
+						"string": typeEntity(nil),
+					}}},
+				},
+			},
+			entryModRef: {
+				Manifest: src.ModuleManifest{Deps: map[string]core.ModuleRef{"std": stdModRef}},
+				Packages: map[string]src.Package{
+					"blob": {"blob": {Entities: map[string]src.Entity{
+						"blob": typeEntity(compiler.Pointer(inst("bytes"))),
+					}}},
+					"shadow": {"shadow": {Entities: map[string]src.Entity{
+						"bytes": typeEntity(compiler.Pointer(inst("string"))),
+					}}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		pkg      string
+		typeExpr ts.Expr
+		expected ir.MsgType
+	}{
+		{
+			name:     "builtin_bytes",
+			pkg:      "blob",
+			typeExpr: inst("bytes"),
+			expected: ir.MsgTypeBytes,
+		},
+		{
+			name:     "type_defined_as_bytes",
+			pkg:      "blob",
+			typeExpr: inst("blob"),
+			expected: ir.MsgTypeBytes,
+		},
+		{
+			name:     "user_type_named_bytes",
+			pkg:      "shadow",
+			typeExpr: inst("bytes"),
+			expected: ir.MsgTypeString,
+		},
+		{
+			name:     "string",
+			pkg:      "blob",
+			typeExpr: inst("string"),
+			expected: ir.MsgTypeString,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := src.NewScope(build, core.Location{ModRef: entryModRef, Package: tt.pkg, Filename: tt.pkg})
+			msg, err := getIRMsgBySrcRef(
+				src.ConstValue{Message: &src.MsgLiteral{Str: compiler.Pointer("hi")}},
+				scope,
+				tt.typeExpr,
+			)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, msg.Type)
+			if tt.expected == ir.MsgTypeBytes {
+				require.Equal(t, []byte("hi"), msg.Bytes)
+			} else {
+				require.Equal(t, "hi", msg.String)
+			}
+		})
+	}
+}
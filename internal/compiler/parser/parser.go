@@ -66,6 +66,9 @@ func (p Parser) ParseFiles(
 	for fileName, fileBytes := range files {
 		parsedFile, err := p.parseFile(modRef, pkgName, fileName, fileBytes)
 		if err != nil {
+			if err.Meta == nil { // e.g. panic during tree walking
+				err.Meta = &core.Meta{}
+			}
 			err.Meta.Location = core.Location{
 				ModRef:   modRef,
 				Package:  pkgName,
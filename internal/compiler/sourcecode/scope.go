@@ -9,6 +9,11 @@ import (
 	"github.com/nevalang/neva/pkg"
 )
 
+var (
+	ErrImportNotFound = errors.New("import not found")
+	ErrEntityNotFound = errors.New("entity not found")
+)
+
 // NewScope returns a new scope with a given location
 func NewScope(build Build, location core.Location) Scope {
 	return Scope{
@@ -127,7 +132,7 @@ func (s Scope) entity(entityRef core.EntityRef) (Entity, core.Location, error) {
 
 	pkgImport, ok := curFile.Imports[entityRef.Pkg]
 	if !ok {
-		return Entity{}, core.Location{}, fmt.Errorf("import not found: %v", entityRef.Pkg)
+		return Entity{}, core.Location{}, fmt.Errorf("%w: %v", ErrImportNotFound, entityRef.Pkg)
 	}
 
 	var (
@@ -30,15 +30,25 @@ func (mod Module) Entity(entityRef core.EntityRef) (entity Entity, filename stri
 
 	entity, filename, ok = pkg.Entity(entityRef.Name)
 	if !ok {
-		return Entity{}, "", fmt.Errorf("entity not found: %v", entityRef.Name)
+		return Entity{}, "", fmt.Errorf("%w: %v", ErrEntityNotFound, entityRef.Name)
 	}
 
 	return entity, filename, nil
 }
 
 type ModuleManifest struct {
-	LanguageVersion string                    `json:"neva,omitempty" yaml:"neva,omitempty"`
-	Deps            map[string]core.ModuleRef `json:"deps,omitempty" yaml:"deps,omitempty"`
+	LanguageVersion string                       `json:"neva,omitempty" yaml:"neva,omitempty"`
+	Deps            map[string]core.ModuleRef    `json:"deps,omitempty" yaml:"deps,omitempty"`
+	Replace         map[string]ModuleReplacement `json:"replace,omitempty" yaml:"replace,omitempty"`
+}
+
+// ModuleReplacement is the source code used instead of the module with the given path.
+// It's either a local directory or a tag of another git repository.
+// Only replacements of the entry module are applied.
+type ModuleReplacement struct {
+	Dir     string `json:"dir,omitempty" yaml:"dir,omitempty"` // relative to the module root
+	URL     string `json:"url,omitempty" yaml:"url,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
 }
 
 type Package map[string]File
@@ -220,6 +230,7 @@ type MsgLiteral struct {
 	List         []ConstValue          `json:"vec,omitempty"`
 	DictOrStruct map[string]ConstValue `json:"dict,omitempty"` // TODO separate map and struct
 	Enum         *EnumMessage          `json:"enum,omitempty"`
+	Union        *UnionMessage         `json:"union,omitempty"`
 	Meta         core.Meta             `json:"meta,omitempty"`
 }
 
@@ -228,6 +239,13 @@ type EnumMessage struct {
 	MemberName string
 }
 
+// UnionMessage is a value of union type. It's not written by user directly,
+// analyzer infers it from literals of constants and senders with union type.
+type UnionMessage struct {
+	Tag  uint8       `json:"tag"`            // Index of union element.
+	Data *ConstValue `json:"data,omitempty"` // Nil for tag-only messages like `Shape::Circle`.
+}
+
 func (m MsgLiteral) String() string {
 	switch {
 	case m.Bool != nil:
@@ -253,6 +271,11 @@ func (m MsgLiteral) String() string {
 			s += fmt.Sprintf("%q: %v", key, value.String())
 		}
 		return s + "}"
+	case m.Union != nil:
+		if m.Union.Data == nil {
+			return fmt.Sprintf("union(%d)", m.Union.Tag)
+		}
+		return fmt.Sprintf("union(%d, %v)", m.Union.Tag, m.Union.Data.String())
 	}
 	return "message"
 }
@@ -292,6 +315,28 @@ type ConnectionReceiver struct { // TODO rename to Receiver
 	ChainedConnection  *Connection `json:"chainedConnection,omitempty"`  // TODO rename to Chain
 	Switch             *Switch     `json:"switch,omitempty"`
 	Meta               core.Meta   `json:"meta,omitempty"`
+	// This field is result of semantic analysis and is unknown at parsing time.
+	// It's used by desugarer to wrap messages of union elements sent to union receiver.
+	UnionWrap *UnionWrap `json:"unionWrap,omitempty"`
+}
+
+// UnionWrap describes how messages of connection senders must be wrapped into union type of the receiver.
+type UnionWrap struct {
+	Type ts.Expr  `json:"type"` // Union type of the receiver.
+	Tags []*uint8 `json:"tags"` // Tag for each sender of the connection, nil if sender already sends union.
+}
+
+// Tag returns tag if messages of all senders must be wrapped with the same tag.
+func (w UnionWrap) Tag() (uint8, bool) {
+	if len(w.Tags) == 0 || w.Tags[0] == nil {
+		return 0, false
+	}
+	for _, tag := range w.Tags[1:] {
+		if tag == nil || *tag != *w.Tags[0] {
+			return 0, false
+		}
+	}
+	return *w.Tags[0], true
 }
 
 type Switch struct {
@@ -20,6 +20,7 @@ var (
 	ErrRecFieldUnresolved = errors.New("can't resolve struct field")
 	ErrInvalidDef         = errors.New("invalid definition")
 	ErrTerminator         = errors.New("recursion terminator")
+	ErrArgsParamsCount    = errors.New("count of arguments mismatch count of parameters")
 )
 
 // Resolver transforms expression it into a form where all references it contains points to resolved expressions.
@@ -126,7 +127,8 @@ func (r Resolver) IsSubtypeOf(sub, sup Expr, scope Scope) error {
 func (r Resolver) CheckArgsCompatibility(args []Expr, params []Param, scope Scope) error {
 	if len(args) != len(params) {
 		return fmt.Errorf(
-			"count of arguments mismatch count of parameters, want %d got %d",
+			"%w, want %d got %d",
+			ErrArgsParamsCount,
 			len(params),
 			len(args),
 		)
@@ -0,0 +1,98 @@
+package funcs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nevalang/neva/internal/runtime"
+)
+
+// startFunc runs function with single ports of given names and returns channels connected to them.
+func startFunc(
+	t *testing.T,
+	creator runtime.FuncCreator,
+	inports, outports []string,
+) (map[string]chan<- runtime.OrderedMsg, map[string]<-chan runtime.OrderedMsg) {
+	t.Helper()
+
+	ins := map[string]chan<- runtime.OrderedMsg{}
+	inportsMap := map[string]runtime.Inport{}
+	for _, name := range inports {
+		ch := make(chan runtime.OrderedMsg)
+		ins[name] = ch
+		addr := runtime.PortAddr{Path: "f/in", Port: name}
+		inportsMap[name] = runtime.NewInport(nil, runtime.NewSingleInport(ch, addr, runtime.ProdInterceptor{}))
+	}
+
+	outs := map[string]<-chan runtime.OrderedMsg{}
+	outportsMap := map[string]runtime.Outport{}
+	for _, name := range outports {
+		ch := make(chan runtime.OrderedMsg)
+		outs[name] = ch
+		addr := runtime.PortAddr{Path: "f/out", Port: name}
+		outportsMap[name] = runtime.NewOutport(runtime.NewSingleOutport(addr, runtime.ProdInterceptor{}, ch), nil)
+	}
+
+	handler, err := creator.Create(runtime.IO{
+		In:  runtime.NewInports(inportsMap),
+		Out: runtime.NewOutports(outportsMap),
+	}, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go handler(ctx)
+
+	return ins, outs
+}
+
+func TestStringsBytesConversion(t *testing.T) {
+	toIns, toOuts := startFunc(t, stringsToBytes{}, []string{"data"}, []string{"res"})
+	fromIns, fromOuts := startFunc(t, stringsFromBytes{}, []string{"data"}, []string{"res"})
+
+	toIns["data"] <- runtime.OrderedMsg{Msg: runtime.NewStringMsg("héllo")}
+	b := (<-toOuts["res"]).Msg
+	require.Equal(t, runtime.NewBytesMsg([]byte("héllo")), b)
+
+	fromIns["data"] <- runtime.OrderedMsg{Msg: b}
+	require.Equal(t, "héllo", (<-fromOuts["res"]).Msg.Str())
+}
+
+func TestWriteAndReadAllBytes(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "data.bin")
+	data := []byte{0x00, 0xff, 0x10, 'h', 'i'} // not valid UTF-8
+
+	writeIns, writeOuts := startFunc(t, writeAll{bytes: true}, []string{"filename", "data"}, []string{"res", "err"})
+	writeIns["filename"] <- runtime.OrderedMsg{Msg: runtime.NewStringMsg(filename)}
+	writeIns["data"] <- runtime.OrderedMsg{Msg: runtime.NewBytesMsg(data)}
+	select {
+	case <-writeOuts["res"]:
+	case msg := <-writeOuts["err"]:
+		t.Fatalf("unexpected error: %v", msg.Msg)
+	}
+
+	written, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, data, written)
+
+	readIns, readOuts := startFunc(t, fileReadAll{bytes: true}, []string{"filename"}, []string{"res", "err"})
+	readIns["filename"] <- runtime.OrderedMsg{Msg: runtime.NewStringMsg(filename)}
+	select {
+	case msg := <-readOuts["res"]:
+		require.Equal(t, data, msg.Msg.Bytes())
+	case msg := <-readOuts["err"]:
+		t.Fatalf("unexpected error: %v", msg.Msg)
+	}
+
+	// missing file is reported through err port
+	readIns["filename"] <- runtime.OrderedMsg{Msg: runtime.NewStringMsg(filepath.Join(t.TempDir(), "missing"))}
+	select {
+	case msg := <-readOuts["res"]:
+		t.Fatalf("unexpected result: %v", msg.Msg)
+	case <-readOuts["err"]:
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/nevalang/neva/internal/runtime"
 )
 
-type fileReadAll struct{}
+type fileReadAll struct {
+	bytes bool // send content as bytes instead of string
+}
 
 func (c fileReadAll) Create(rio runtime.IO, _ runtime.Msg) (func(ctx context.Context), error) {
 	filenameIn, err := rio.In.Single("filename")
@@ -56,7 +58,12 @@ func (c fileReadAll) Create(rio runtime.IO, _ runtime.Msg) (func(ctx context.Con
 				continue
 			}
 
-			if !resOut.Send(ctx, runtime.NewStringMsg(string(data))) {
+			var res runtime.Msg = runtime.NewStringMsg(string(data))
+			if c.bytes {
+				res = runtime.NewBytesMsg(data)
+			}
+
+			if !resOut.Send(ctx, res) {
 				return
 			}
 		}
@@ -0,0 +1,51 @@
+package funcs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/nevalang/neva/internal/runtime"
+)
+
+type parseFloat struct{}
+
+func (p parseFloat) Create(io runtime.IO, _ runtime.Msg) (func(ctx context.Context), error) {
+	dataIn, err := io.In.Single("data")
+	if err != nil {
+		return nil, err
+	}
+
+	resOut, err := io.Out.Single("res")
+	if err != nil {
+		return nil, err
+	}
+
+	errOut, err := io.Out.Single("err")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) {
+		for {
+			str, ok := dataIn.Receive(ctx)
+			if !ok {
+				return
+			}
+
+			parsedNum, err := strconv.ParseFloat(str.Str(), 64)
+			if err != nil {
+				err = errors.New(strings.TrimPrefix(err.Error(), "strconv.ParseFloat: "))
+				if !errOut.Send(ctx, errFromErr(err)) {
+					return
+				}
+				continue
+			}
+
+			if !resOut.Send(ctx, runtime.NewFloatMsg(parsedNum)) {
+				return
+			}
+		}
+	}, nil
+}
@@ -1,9 +1,9 @@
 package funcs
 
 import (
+	"bytes"
 	"context"
 	"image/png"
-	"strings"
 
 	"github.com/nevalang/neva/internal/runtime"
 )
@@ -42,9 +42,9 @@ func (imageEncode) Create(io runtime.IO, _ runtime.Msg) (func(ctx context.Contex
 
 			im := b.createImage()
 
-			// Encode the image in the desired format to sb.
-			var sb strings.Builder // for encoded output.
-			if err := png.Encode(&sb, im); err != nil {
+			// Encode the image as PNG to buf.
+			var buf bytes.Buffer // for encoded output.
+			if err := png.Encode(&buf, im); err != nil {
 				if !errOut.Send(ctx, errFromErr(err)) {
 					return
 				}
@@ -52,7 +52,7 @@ func (imageEncode) Create(io runtime.IO, _ runtime.Msg) (func(ctx context.Contex
 
 			if !dataOut.Send(
 				ctx,
-				runtime.NewStringMsg(sb.String()),
+				runtime.NewBytesMsg(buf.Bytes()),
 			) {
 				return
 			}
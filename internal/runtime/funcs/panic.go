@@ -2,8 +2,7 @@ package funcs
 
 import (
 	"context"
-	"fmt"
-	"os"
+	"strings"
 
 	"github.com/nevalang/neva/internal/runtime"
 )
@@ -19,17 +18,15 @@ func (p panicker) Create(
 		return nil, err
 	}
 
+	path := strings.TrimSuffix(msgIn.Addr().Path, "/in")
+
 	return func(ctx context.Context) {
 		panicMsg, ok := msgIn.Receive(ctx)
 		if !ok {
 			return
 		}
 
-		cancel := ctx.Value("cancel").(context.CancelFunc)
-		cancel()
-
-		if _, err := fmt.Fprintln(os.Stderr, "panic:", panicMsg); err != nil {
-			panic(err)
-		}
+		// runtime will report the panic and terminate with non-zero exit code
+		runtime.Panic(ctx, path, panicMsg)
 	}, nil
 }
@@ -8,13 +8,15 @@ import (
 
 func NewRegistry() map[string]runtime.FuncCreator {
 	return map[string]runtime.FuncCreator{
-		"new":     new{},
-		"new_v2":  newV2{},
-		"del":     del{},
-		"lock":    lock{},
-		"unwrap":  unwrap{},
-		"fan_out": fanOut{},
-		"fan_in":  fanIn{},
+		"new":         new{},
+		"new_v2":      newV2{},
+		"del":         del{},
+		"lock":        lock{},
+		"unwrap":      unwrap{},
+		"union_wrap":  unionWrap{},
+		"union_value": unionValue{},
+		"fan_out":     fanOut{},
+		"fan_in":      fanIn{},
 
 		"panic": panicker{},
 
@@ -35,11 +37,15 @@ func NewRegistry() map[string]runtime.FuncCreator {
 		"int_is_lesser":          intIsLesser{},
 		"int_is_lesser_or_equal": intIsLesserOrEqual{},
 
-		"string_is_greater": strIsGreater{},
-		"string_is_lesser":  strIsLesser{},
+		"string_is_greater":          strIsGreater{},
+		"string_is_lesser":           strIsLesser{},
+		"string_is_greater_or_equal": strIsGreaterOrEqual{},
+		"string_is_lesser_or_equal":  strIsLesserOrEqual{},
 
-		"float_is_greater": floatIsGreater{},
-		"float_is_lesser":  floatIsLesser{},
+		"float_is_greater":          floatIsGreater{},
+		"float_is_lesser":           floatIsLesser{},
+		"float_is_greater_or_equal": floatIsGreaterOrEqual{},
+		"float_is_lesser_or_equal":  floatIsLesserOrEqual{},
 
 		"array_port_to_stream": arrayPortToStream{},
 		"list_to_stream":       listToStream{},
@@ -65,26 +71,36 @@ func NewRegistry() map[string]runtime.FuncCreator {
 		"float_div":  floatDiv{},
 		"string_add": stringAdd{},
 
-		"int_inc": intInc{},
-		"int_dec": intDec{},
-		"int_mod": intMod{},
+		"int_inc":   intInc{},
+		"int_dec":   intDec{},
+		"int_neg":   intNeg{},
+		"float_inc": floatInc{},
+		"float_dec": floatDec{},
+		"float_neg": floatNeg{},
+		"int_mod":   intMod{},
 
-		"parse_int": parseInt{},
+		"parse_int":   parseInt{},
+		"parse_float": parseFloat{},
 
 		"regexp_submatch": regexpSubmatch{},
 
-		"list_at":   listAt{},
-		"list_len":  listlen{},
-		"list_push": listPush{},
+		"list_at":    listAt{},
+		"list_len":   listlen{},
+		"list_push":  listPush{},
+		"map_len":    mapLen{},
+		"string_len": stringLen{},
+		"slice":      slice{},
 
 		"time_delay": timeDelay{},
 		"time_after": timeAfter{},
 
-		"string_at":        stringAt{},
-		"strings_join":     stringJoin{},
-		"strings_split":    stringsSplit{},
-		"strings_to_upper": stringsToUpper{},
-		"strings_to_lower": stringsToLower{},
+		"string_at":          stringAt{},
+		"strings_join":       stringJoin{},
+		"strings_split":      stringsSplit{},
+		"strings_to_upper":   stringsToUpper{},
+		"strings_to_lower":   stringsToLower{},
+		"strings_from_bytes": stringsFromBytes{},
+		"strings_to_bytes":   stringsToBytes{},
 
 		"scanln":  scanln{},
 		"args":    args{},
@@ -92,11 +108,13 @@ func NewRegistry() map[string]runtime.FuncCreator {
 		"printf":  printf{},
 		"print":   print{},
 
-		"read_all":     fileReadAll{},
-		"write_all":    writeAll{},
-		"http_get":     httpGet{},
-		"image_encode": imageEncode{},
-		"image_new":    imageNew{},
+		"read_all":        fileReadAll{},
+		"read_all_bytes":  fileReadAll{bytes: true},
+		"write_all":       writeAll{},
+		"write_all_bytes": writeAll{bytes: true},
+		"http_get":        httpGet{},
+		"image_encode":    imageEncode{},
+		"image_new":       imageNew{},
 
 		"wait_group": waitGroup{},
 
@@ -0,0 +1,91 @@
+package funcs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nevalang/neva/internal/runtime"
+)
+
+type slice struct{}
+
+func (slice) Create(io runtime.IO, _ runtime.Msg) (func(ctx context.Context), error) {
+	dataIn, err := io.In.Single("data")
+	if err != nil {
+		return nil, err
+	}
+
+	fromIn, err := io.In.Single("from")
+	if err != nil {
+		return nil, err
+	}
+
+	toIn, err := io.In.Single("to")
+	if err != nil {
+		return nil, err
+	}
+
+	resOut, err := io.Out.Single("res")
+	if err != nil {
+		return nil, err
+	}
+
+	errOut, err := io.Out.Single("err")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) {
+		for {
+			dataMsg, ok := dataIn.Receive(ctx)
+			if !ok {
+				return
+			}
+
+			fromMsg, ok := fromIn.Receive(ctx)
+			if !ok {
+				return
+			}
+
+			toMsg, ok := toIn.Receive(ctx)
+			if !ok {
+				return
+			}
+
+			res, err := sliceMsg(dataMsg, fromMsg.Int(), toMsg.Int())
+			if err != nil {
+				if !errOut.Send(ctx, errFromErr(err)) {
+					return
+				}
+				continue
+			}
+
+			if !resOut.Send(ctx, res) {
+				return
+			}
+		}
+	}, nil
+}
+
+// sliceMsg slices list by elements and string by utf-8 characters.
+func sliceMsg(msg runtime.Msg, from, to int64) (runtime.Msg, error) {
+	if list, ok := msg.(runtime.ListMsg); ok {
+		if err := checkSliceBounds(from, to, len(list.List())); err != nil {
+			return nil, err
+		}
+		return runtime.NewListMsg(list.List()[from:to]), nil
+	}
+
+	runes := []rune(msg.Str())
+	if err := checkSliceBounds(from, to, len(runes)); err != nil {
+		return nil, err
+	}
+	return runtime.NewStringMsg(string(runes[from:to])), nil
+}
+
+func checkSliceBounds(from, to int64, length int) error {
+	if from < 0 || to < from || to > int64(length) {
+		return fmt.Errorf("slice bounds out of range [%d:%d] with length %d", from, to, length)
+	}
+	return nil
+}
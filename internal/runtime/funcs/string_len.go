@@ -0,0 +1,35 @@
+package funcs
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/nevalang/neva/internal/runtime"
+)
+
+type stringLen struct{}
+
+func (i stringLen) Create(io runtime.IO, _ runtime.Msg) (func(context.Context), error) {
+	dataIn, err := io.In.Single("data")
+	if err != nil {
+		return nil, err
+	}
+
+	resOut, err := io.Out.Single("res")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) {
+		for {
+			dataMsg, ok := dataIn.Receive(ctx)
+			if !ok {
+				return
+			}
+
+			if !resOut.Send(ctx, runtime.NewIntMsg(int64(utf8.RuneCountInString(dataMsg.Str())))) {
+				return
+			}
+		}
+	}, nil
+}
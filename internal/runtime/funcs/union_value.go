@@ -0,0 +1,58 @@
+package funcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nevalang/neva/internal/runtime"
+)
+
+type unionValue struct{}
+
+func (unionValue) Create(io runtime.IO, cfg runtime.Msg) (func(ctx context.Context), error) {
+	tagMsg, ok := cfg.(runtime.UnionMsg)
+	if !ok {
+		return nil, errors.New("union value expects tag-only union message to be bound")
+	}
+	tag := tagMsg.Tag()
+
+	dataIn, err := io.In.Single("data")
+	if err != nil {
+		return nil, err
+	}
+
+	resOut, err := io.Out.Single("res")
+	if err != nil {
+		return nil, err
+	}
+
+	path := strings.TrimSuffix(dataIn.Addr().Path, "/in")
+
+	return func(ctx context.Context) {
+		for {
+			dataMsg, ok := dataIn.Receive(ctx)
+			if !ok {
+				return
+			}
+
+			union := dataMsg.Union()
+			if union.Tag() != tag {
+				runtime.Panic(ctx, path, runtime.NewStringMsg(
+					fmt.Sprintf("union value: expected tag %d, got %v", tag, union),
+				))
+				return
+			}
+
+			value := union.Value()
+			if value == nil { // tag-only union
+				value = emptyStruct()
+			}
+
+			if !resOut.Send(ctx, value) {
+				return
+			}
+		}
+	}, nil
+}
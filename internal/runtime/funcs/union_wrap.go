@@ -0,0 +1,41 @@
+package funcs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nevalang/neva/internal/runtime"
+)
+
+type unionWrap struct{}
+
+func (unionWrap) Create(io runtime.IO, cfg runtime.Msg) (func(ctx context.Context), error) {
+	tagMsg, ok := cfg.(runtime.UnionMsg)
+	if !ok {
+		return nil, errors.New("union wrap expects tag-only union message to be bound")
+	}
+	tag := tagMsg.Tag()
+
+	dataIn, err := io.In.Single("data")
+	if err != nil {
+		return nil, err
+	}
+
+	resOut, err := io.Out.Single("res")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) {
+		for {
+			dataMsg, ok := dataIn.Receive(ctx)
+			if !ok {
+				return
+			}
+
+			if !resOut.Send(ctx, runtime.NewUnionMsg(tag, dataMsg)) {
+				return
+			}
+		}
+	}, nil
+}
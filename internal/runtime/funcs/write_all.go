@@ -7,7 +7,9 @@ import (
 	"github.com/nevalang/neva/internal/runtime"
 )
 
-type writeAll struct{}
+type writeAll struct {
+	bytes bool // receive content as bytes instead of string
+}
 
 func (c writeAll) Create(rio runtime.IO, _ runtime.Msg) (func(ctx context.Context), error) {
 	filenameIn, err := rio.In.Single("filename")
@@ -42,7 +44,14 @@ func (c writeAll) Create(rio runtime.IO, _ runtime.Msg) (func(ctx context.Contex
 				return
 			}
 
-			err := os.WriteFile(filenameMsg.Str(), []byte(dataMsg.Str()), 0755)
+			var data []byte
+			if c.bytes {
+				data = dataMsg.Bytes()
+			} else {
+				data = []byte(dataMsg.Str())
+			}
+
+			err := os.WriteFile(filenameMsg.Str(), data, 0755)
 			if err != nil {
 				if !errOut.Send(ctx, errFromErr(err)) {
 					return
@@ -0,0 +1,93 @@
+package runtime
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// HistoryInterceptor remembers the most recent messages of the program
+// so they can be reported as a flowtrace when program panics.
+// It sees every message of the program, so events are written into preallocated slots
+// to avoid allocations, and every slot has its own lock so writers rarely wait for each other.
+type HistoryInterceptor struct {
+	slots []historySlot // ring buffer
+	next  atomic.Uint64 // total number of added events
+}
+
+// historySlot holds the event with the given sequence number.
+type historySlot struct {
+	mu    sync.Mutex
+	seq   uint64 // 1-based number of the event, 0 if slot is not written yet
+	event HistoryEvent
+}
+
+// HistoryEvent is a message remembered by HistoryInterceptor.
+type HistoryEvent struct {
+	Dir  TraceDirection
+	Addr PortSlotAddr
+	Msg  Msg
+}
+
+func (h *HistoryInterceptor) Sent(sender PortSlotAddr, msg OrderedMsg) Msg {
+	h.add(TraceDirectionSent, sender, msg.Msg)
+	return msg.Msg
+}
+
+func (h *HistoryInterceptor) Received(receiver PortSlotAddr, msg OrderedMsg) Msg {
+	h.add(TraceDirectionReceived, receiver, msg.Msg)
+	return msg.Msg
+}
+
+func (h *HistoryInterceptor) add(dir TraceDirection, addr PortSlotAddr, msg Msg) {
+	seq := h.next.Add(1)
+	slot := &h.slots[(seq-1)%uint64(len(h.slots))]
+
+	slot.mu.Lock()
+	// writer of the newer event could get the lock first
+	if slot.seq < seq {
+		slot.seq = seq
+		slot.event = HistoryEvent{Dir: dir, Addr: addr, Msg: msg}
+	}
+	slot.mu.Unlock()
+}
+
+// Path returns up to limit most recent events on ports of the nodes
+// that are inside component with given path (empty path means root component).
+// Events are ordered from oldest to newest.
+// Events added while Path is running may or may not be included.
+func (h *HistoryInterceptor) Path(path string, limit int) []HistoryEvent {
+	prefix := path + "/"
+	size := uint64(len(h.slots))
+	next := h.next.Load()
+
+	var oldest uint64
+	if next > size {
+		oldest = next - size
+	}
+
+	result := make([]HistoryEvent, 0, limit)
+	for seq := next; seq > oldest && len(result) < limit; seq-- {
+		slot := &h.slots[(seq-1)%size]
+		slot.mu.Lock()
+		event, ok := slot.event, slot.seq == seq
+		slot.mu.Unlock()
+		if !ok {
+			continue // event is not written yet or already overwritten
+		}
+		if path == "" || strings.HasPrefix(event.Addr.Path, prefix) {
+			result = append(result, event)
+		}
+	}
+
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result
+}
+
+// NewHistoryInterceptor creates interceptor that remembers up to size most recent messages.
+func NewHistoryInterceptor(size int) *HistoryInterceptor {
+	return &HistoryInterceptor{slots: make([]historySlot, size)}
+}
@@ -0,0 +1,89 @@
+package runtime
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryInterceptor(t *testing.T) {
+	h := NewHistoryInterceptor(4)
+	for i := range 6 {
+		addr := PortSlotAddr{PortAddr: PortAddr{Path: fmt.Sprintf("main/n%d/in", i%2), Port: "data"}}
+		h.Sent(addr, OrderedMsg{Msg: NewIntMsg(int64(i))})
+	}
+
+	msgs := func(events []HistoryEvent) []int64 {
+		result := []int64{}
+		for _, event := range events {
+			result = append(result, event.Msg.Int())
+		}
+		return result
+	}
+
+	// only the most recent events are remembered
+	require.Equal(t, []int64{2, 3, 4, 5}, msgs(h.Path("", 10)))
+	require.Equal(t, []int64{4, 5}, msgs(h.Path("", 2)))
+
+	// events of the nodes inside the component
+	require.Equal(t, []int64{2, 3, 4, 5}, msgs(h.Path("main", 10)))
+	require.Equal(t, []int64{3, 5}, msgs(h.Path("main/n1", 10)))
+	require.Empty(t, h.Path("other", 10))
+}
+
+func TestHistoryInterceptorConcurrentAdd(t *testing.T) {
+	h := NewHistoryInterceptor(8)
+	addr := PortSlotAddr{PortAddr: PortAddr{Path: "main/n/in", Port: "data"}}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				h.Received(addr, OrderedMsg{Msg: NewIntMsg(int64(i))})
+				h.Path("main", 4)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, h.Path("", 100), 8)
+}
+
+func TestHistoryInterceptorDoesNotAllocate(t *testing.T) {
+	h := NewHistoryInterceptor(256)
+	addr := PortSlotAddr{PortAddr: PortAddr{Path: "main/n/in", Port: "data"}}
+	msg := OrderedMsg{Msg: NewIntMsg(42)}
+
+	require.Zero(t, testing.AllocsPerRun(1000, func() {
+		h.Sent(addr, msg)
+		h.Received(addr, msg)
+	}))
+}
+
+// BenchmarkHistoryInterceptor measures the cost that history adds to every message of the program.
+func BenchmarkHistoryInterceptor(b *testing.B) {
+	addr := PortSlotAddr{PortAddr: PortAddr{Path: "main/n/in", Port: "data"}}
+	msg := OrderedMsg{Msg: NewIntMsg(42)}
+
+	b.Run("sequential", func(b *testing.B) {
+		h := NewHistoryInterceptor(256)
+		b.ReportAllocs()
+		for b.Loop() {
+			h.Sent(addr, msg)
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		h := NewHistoryInterceptor(256)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				h.Sent(addr, msg)
+			}
+		})
+	})
+}
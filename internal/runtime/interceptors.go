@@ -1,57 +1,209 @@
 package runtime
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 )
 
 type ProdInterceptor struct{}
 
 func (ProdInterceptor) Prepare() error { return nil }
 
-func (ProdInterceptor) Sent(sender PortSlotAddr, msg Msg) Msg { return msg }
+func (ProdInterceptor) Sent(sender PortSlotAddr, msg OrderedMsg) Msg { return msg.Msg }
 
-func (ProdInterceptor) Received(receiver PortSlotAddr, msg Msg) Msg { return msg }
+func (ProdInterceptor) Received(receiver PortSlotAddr, msg OrderedMsg) Msg { return msg.Msg }
 
-type DebugInterceptor struct{ file *os.File }
+// ChainInterceptor calls interceptors one by one in the order they were given.
+// Message returned by each interceptor is passed to the next one.
+type ChainInterceptor struct {
+	interceptors []Interceptor
+}
+
+func (c ChainInterceptor) Sent(sender PortSlotAddr, msg OrderedMsg) Msg {
+	for _, interceptor := range c.interceptors {
+		msg.Msg = interceptor.Sent(sender, msg)
+	}
+	return msg.Msg
+}
+
+func (c ChainInterceptor) Received(receiver PortSlotAddr, msg OrderedMsg) Msg {
+	for _, interceptor := range c.interceptors {
+		msg.Msg = interceptor.Received(receiver, msg)
+	}
+	return msg.Msg
+}
+
+// NewChainInterceptor combines interceptors into one.
+// It avoids the indirection when there's nothing to combine.
+func NewChainInterceptor(interceptors ...Interceptor) Interceptor {
+	switch len(interceptors) {
+	case 0:
+		return ProdInterceptor{}
+	case 1:
+		return interceptors[0]
+	}
+	return ChainInterceptor{interceptors: interceptors}
+}
+
+// AssertionsInterceptor checks invariants of the runtime on every message.
+// Violations don't stop the program, they are collected and reported by Err.
+type AssertionsInterceptor struct {
+	mu         sync.Mutex
+	pending    map[uint64]struct{} // sent but not yet received messages by index
+	violations []error
+}
+
+// maxViolations limits how many violations are remembered, they are usually caused by the same bug.
+const maxViolations = 10
+
+func (a *AssertionsInterceptor) Sent(sender PortSlotAddr, msg OrderedMsg) Msg {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if msg.Msg == nil {
+		a.violate(fmt.Errorf("%v: nil message sent", sender))
+	}
+	a.pending[msg.index] = struct{}{}
+
+	return msg.Msg
+}
+
+func (a *AssertionsInterceptor) Received(receiver PortSlotAddr, msg OrderedMsg) Msg {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if msg.Msg == nil {
+		a.violate(fmt.Errorf("%v: nil message received", receiver))
+	}
+	// every message is sent once and received once
+	if _, ok := a.pending[msg.index]; !ok {
+		a.violate(fmt.Errorf("%v: message #%d received but not sent or received twice", receiver, msg.index))
+	}
+	delete(a.pending, msg.index)
+
+	return msg.Msg
+}
+
+// violate must be called with a.mu locked.
+func (a *AssertionsInterceptor) violate(err error) {
+	if len(a.violations) < maxViolations {
+		a.violations = append(a.violations, err)
+	}
+}
 
+// Err returns violations found so far or nil if there are none.
+func (a *AssertionsInterceptor) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.violations) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("runtime assertions failed: %w", errors.Join(a.violations...))
+}
+
+func NewAssertionsInterceptor() *AssertionsInterceptor {
+	return &AssertionsInterceptor{pending: map[uint64]struct{}{}}
+}
+
+// TraceFormat is a format of the trace file written by DebugInterceptor.
+type TraceFormat string
+
+const (
+	TraceFormatText TraceFormat = "text" // human-readable `sent | addr | msg` lines
+	TraceFormatJSON TraceFormat = "json" // JSON Lines, one TraceEvent per line
+)
+
+type DebugInterceptor struct {
+	file   *os.File
+	format TraceFormat
+	start  time.Time
+	errs   errorOnce // first error of writing the trace, reported on close
+}
+
+// Open opens trace file. Returned function closes it and reports errors that happened while tracing.
 func (d *DebugInterceptor) Open(filepath string) (func() error, error) {
 	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0644)
 	if err != nil {
 		return nil, err
 	}
 	d.file = file
-	return file.Close, nil
+	d.start = time.Now()
+	return func() error {
+		return errors.Join(d.errs.get(), file.Close())
+	}, nil
 }
 
-func (d *DebugInterceptor) Sent(sender PortSlotAddr, msg Msg) Msg {
-	fmt.Fprintf(
-		d.file,
-		"sent | %v | %v\n",
-		d.formatPortSlotAddr(sender), d.formatMsg(msg),
-	)
-	return msg
+func (d *DebugInterceptor) Sent(sender PortSlotAddr, msg OrderedMsg) Msg {
+	d.write(TraceDirectionSent, sender, msg)
+	return msg.Msg
 }
 
-func (d *DebugInterceptor) Received(receiver PortSlotAddr, msg Msg) Msg {
-	fmt.Fprintf(
+func (d *DebugInterceptor) Received(receiver PortSlotAddr, msg OrderedMsg) Msg {
+	d.write(TraceDirectionReceived, receiver, msg)
+	return msg.Msg
+}
+
+func (d *DebugInterceptor) write(dir TraceDirection, addr PortSlotAddr, msg OrderedMsg) {
+	if d.format == TraceFormatJSON {
+		// time.Since uses monotonic clock reading so timestamps never go backwards
+		event := NewTraceEvent(time.Since(d.start), dir, addr, msg)
+		bb, err := json.Marshal(event)
+		if err != nil {
+			// interceptor must not break the program, so the event is skipped
+			d.errs.set(fmt.Errorf("marshal trace event: %w", err))
+			return
+		}
+		// single write per event so concurrent lines don't interleave
+		if _, err := d.file.Write(append(bb, '\n')); err != nil {
+			d.errs.set(err)
+		}
+		return
+	}
+
+	if _, err := fmt.Fprintf(
 		d.file,
-		"recv | %v | %v\n",
-		d.formatPortSlotAddr(receiver),
-		d.formatMsg(msg),
-	)
-	return msg
+		"%v | %v | %v\n",
+		dir, d.formatPortSlotAddr(addr), d.formatMsg(msg.Msg),
+	); err != nil {
+		d.errs.set(err)
+	}
+}
+
+// errorOnce remembers the first error reported from concurrent goroutines.
+type errorOnce struct {
+	mu  sync.Mutex
+	err error
 }
 
-func (d DebugInterceptor) formatMsg(msg Msg) string {
+func (e *errorOnce) set(err error) {
+	e.mu.Lock()
+	if e.err == nil {
+		e.err = err
+	}
+	e.mu.Unlock()
+}
+
+func (e *errorOnce) get() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+func (d *DebugInterceptor) formatMsg(msg Msg) string {
 	if strMsg, ok := msg.(StringMsg); ok {
 		return fmt.Sprintf("%q", strMsg.Str())
 	}
 	return fmt.Sprint(msg)
 }
 
-func (d DebugInterceptor) formatPortSlotAddr(slotAddr PortSlotAddr) string {
+func (d *DebugInterceptor) formatPortSlotAddr(slotAddr PortSlotAddr) string {
 	parts := strings.Split(slotAddr.Path, "/")
 	lastPart := parts[len(parts)-1]
 	if lastPart == "in" || lastPart == "out" {
@@ -67,6 +219,11 @@ func (d DebugInterceptor) formatPortSlotAddr(slotAddr PortSlotAddr) string {
 	return s
 }
 
-func NewDebugInterceptor() *DebugInterceptor {
-	return &DebugInterceptor{}
+// NewDebugInterceptor creates interceptor that writes trace in given format.
+// Empty format means TraceFormatText.
+func NewDebugInterceptor(format TraceFormat) *DebugInterceptor {
+	if format == "" {
+		format = TraceFormatText
+	}
+	return &DebugInterceptor{format: format}
 }
@@ -0,0 +1,84 @@
+package runtime
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingInterceptor remembers messages it sees and replaces them using rewrite.
+type recordingInterceptor struct {
+	name    string
+	calls   *[]string
+	rewrite func(Msg) Msg
+}
+
+func (r recordingInterceptor) Sent(sender PortSlotAddr, msg OrderedMsg) Msg {
+	*r.calls = append(*r.calls, r.name+" sent "+fmt.Sprint(msg.Msg))
+	return r.rewrite(msg.Msg)
+}
+
+func (r recordingInterceptor) Received(receiver PortSlotAddr, msg OrderedMsg) Msg {
+	*r.calls = append(*r.calls, r.name+" recv "+fmt.Sprint(msg.Msg))
+	return r.rewrite(msg.Msg)
+}
+
+func TestChainInterceptor(t *testing.T) {
+	var calls []string
+
+	double := recordingInterceptor{"double", &calls, func(msg Msg) Msg {
+		return NewIntMsg(msg.Int() * 2)
+	}}
+	inc := recordingInterceptor{"inc", &calls, func(msg Msg) Msg {
+		return NewIntMsg(msg.Int() + 1)
+	}}
+
+	chain := NewChainInterceptor(double, inc)
+	addr := PortSlotAddr{PortAddr: PortAddr{Path: "n/out", Port: "res"}}
+
+	// each interceptor sees message returned by the previous one
+	require.Equal(t, NewIntMsg(7), chain.Sent(addr, OrderedMsg{Msg: NewIntMsg(3)}))
+	require.Equal(t, NewIntMsg(5), chain.Received(addr, OrderedMsg{Msg: NewIntMsg(2)}))
+
+	require.Equal(t, []string{
+		"double sent 3",
+		"inc sent 6",
+		"double recv 2",
+		"inc recv 4",
+	}, calls)
+}
+
+func TestNewChainInterceptor(t *testing.T) {
+	single := NewHistoryInterceptor(1)
+
+	require.Equal(t, ProdInterceptor{}, NewChainInterceptor())
+	require.Same(t, single, NewChainInterceptor(single))
+}
+
+func TestAssertionsInterceptor(t *testing.T) {
+	addr := PortSlotAddr{PortAddr: PortAddr{Path: "n/in", Port: "data"}}
+
+	t.Run("valid", func(t *testing.T) {
+		a := NewAssertionsInterceptor()
+		msg := OrderedMsg{Msg: NewIntMsg(1), index: 1}
+		a.Sent(addr, msg)
+		a.Received(addr, msg)
+		require.NoError(t, a.Err())
+	})
+
+	t.Run("received twice", func(t *testing.T) {
+		a := NewAssertionsInterceptor()
+		msg := OrderedMsg{Msg: NewIntMsg(1), index: 1}
+		a.Sent(addr, msg)
+		a.Received(addr, msg)
+		a.Received(addr, msg)
+		require.ErrorContains(t, a.Err(), "message #1 received but not sent or received twice")
+	})
+
+	t.Run("nil message", func(t *testing.T) {
+		a := NewAssertionsInterceptor()
+		a.Sent(addr, OrderedMsg{index: 1})
+		require.ErrorContains(t, a.Err(), "n/in:data: nil message sent")
+	})
+}
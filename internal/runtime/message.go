@@ -1,6 +1,8 @@
 package runtime
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -18,11 +20,17 @@ func (o OrderedMsg) String() string {
 	return fmt.Sprint(o.Msg)
 }
 
+// Index returns chronological index of the message.
+func (o OrderedMsg) Index() uint64 {
+	return o.index
+}
+
 type Msg interface {
 	Bool() bool
 	Int() int64
 	Float() float64
 	Str() string
+	Bytes() []byte
 	List() []Msg
 	Dict() map[string]Msg
 	Struct() StructMsg
@@ -40,6 +48,7 @@ func (internalMsg) Bool() bool     { panic("unexpected Bool method call on inter
 func (internalMsg) Int() int64     { panic("unexpected Int method call on internal message type") }
 func (internalMsg) Float() float64 { panic("unexpected Float method call on internal message type") }
 func (internalMsg) Str() string    { panic("unexpected Str method call on internal message type") }
+func (internalMsg) Bytes() []byte  { panic("unexpected Bytes method call on internal message type") }
 func (internalMsg) List() []Msg    { panic("unexpected List method call on internal message type") }
 func (internalMsg) Dict() map[string]Msg {
 	panic("unexpected Dict method call on internal message type")
@@ -142,6 +151,30 @@ func NewStringMsg(s string) StringMsg {
 	}
 }
 
+// Bytes
+
+type BytesMsg struct {
+	internalMsg
+	v []byte
+}
+
+func (msg BytesMsg) Bytes() []byte  { return msg.v }
+func (msg BytesMsg) String() string { return hex.EncodeToString(msg.v) }
+func (msg BytesMsg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(msg.v) // base64, same as Go does for []byte
+}
+func (msg BytesMsg) Equal(other Msg) bool {
+	otherBytes, ok := other.(BytesMsg)
+	return ok && bytes.Equal(msg.v, otherBytes.v)
+}
+
+func NewBytesMsg(b []byte) BytesMsg {
+	return BytesMsg{
+		internalMsg: internalMsg{},
+		v:           b,
+	}
+}
+
 // List
 type ListMsg struct {
 	internalMsg
@@ -328,6 +361,34 @@ func (msg UnionMsg) Value() Msg      { return msg.value }
 func (msg UnionMsg) String() string {
 	return fmt.Sprintf("Union(%d, %v)", msg.tag, msg.value)
 }
+func (msg UnionMsg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Tag   uint8 `json:"tag"`
+		Value Msg   `json:"value,omitempty"`
+	}{msg.tag, msg.value})
+}
+
+func (msg UnionMsg) Equal(other Msg) bool {
+	otherUnion, ok := other.(UnionMsg)
+	if !ok || msg.tag != otherUnion.tag {
+		return false
+	}
+	if msg.value == nil || otherUnion.value == nil {
+		return msg.value == nil && otherUnion.value == nil
+	}
+	return msg.value.Equal(otherUnion.value)
+}
+
+// Match reports whether msg matches pattern.
+// Tag-only union pattern matches any union with the same tag regardless of its value,
+// that's how Match and Switch route unions by tag. Other patterns must be equal to msg.
+func Match(msg, pattern Msg) bool {
+	if union, ok := pattern.(UnionMsg); ok && union.value == nil {
+		otherUnion, ok := msg.(UnionMsg)
+		return ok && otherUnion.tag == union.tag
+	}
+	return msg.Equal(pattern)
+}
 
 func NewUnionMsg(tag uint8, value Msg) UnionMsg {
 	return UnionMsg{
@@ -0,0 +1,56 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnionMsgEqual(t *testing.T) {
+	tagOnly := NewUnionMsg(0, nil)
+	one := NewUnionMsg(0, NewIntMsg(1))
+	two := NewUnionMsg(0, NewIntMsg(2))
+
+	require.True(t, one.Equal(NewUnionMsg(0, NewIntMsg(1))))
+	require.True(t, tagOnly.Equal(NewUnionMsg(0, nil)))
+	require.False(t, one.Equal(two))
+	require.False(t, one.Equal(NewUnionMsg(1, NewIntMsg(1))))
+
+	// tag-only union is not equal to unions with values, otherwise equality isn't transitive
+	require.False(t, tagOnly.Equal(one))
+	require.False(t, one.Equal(tagOnly))
+}
+
+func TestMatch(t *testing.T) {
+	tagOnly := NewUnionMsg(0, nil)
+
+	require.True(t, Match(NewUnionMsg(0, NewIntMsg(1)), tagOnly))
+	require.True(t, Match(NewUnionMsg(0, NewIntMsg(2)), tagOnly))
+	require.True(t, Match(tagOnly, tagOnly))
+	require.False(t, Match(NewUnionMsg(1, NewIntMsg(1)), tagOnly))
+	require.False(t, Match(NewIntMsg(0), tagOnly))
+
+	// union with value only matches equal union
+	require.True(t, Match(NewUnionMsg(0, NewIntMsg(1)), NewUnionMsg(0, NewIntMsg(1))))
+	require.False(t, Match(NewUnionMsg(0, NewIntMsg(1)), NewUnionMsg(0, NewIntMsg(2))))
+	require.False(t, Match(tagOnly, NewUnionMsg(0, NewIntMsg(1))))
+
+	require.True(t, Match(NewIntMsg(1), NewIntMsg(1)))
+	require.False(t, Match(NewIntMsg(1), NewIntMsg(2)))
+}
+
+func TestBytesMsg(t *testing.T) {
+	msg := NewBytesMsg([]byte{0x00, 0xff, 'h', 'i'})
+
+	require.Equal(t, []byte{0x00, 0xff, 'h', 'i'}, msg.Bytes())
+	require.Equal(t, "00ff6869", msg.String())
+
+	// same encoding as Go uses for []byte
+	b, err := msg.MarshalJSON()
+	require.NoError(t, err)
+	require.Equal(t, `"AP9oaQ=="`, string(b))
+
+	require.True(t, msg.Equal(NewBytesMsg([]byte{0x00, 0xff, 'h', 'i'})))
+	require.False(t, msg.Equal(NewBytesMsg([]byte{0x00, 0xff})))
+	require.False(t, NewBytesMsg([]byte("hi")).Equal(NewStringMsg("hi")))
+}
@@ -0,0 +1,351 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MetricsInterceptor counts messages per port and measures how long messages wait
+// between being sent and received, per connection.
+// Together with handlers wrapped by Run it also measures how long each function is blocked on its ports.
+type MetricsInterceptor struct {
+	start time.Time
+
+	mu      sync.Mutex
+	pending map[uint64]pendingMsg // sent but not yet received messages by index
+	ports   map[string]*portMetrics
+	conns   map[connMetricsKey]*connMetrics
+
+	funcsMu sync.Mutex
+	funcs   []*funcMetrics
+}
+
+type pendingMsg struct {
+	sender string
+	sentAt time.Time
+}
+
+type portMetrics struct {
+	sent     uint64
+	received uint64
+}
+
+type connMetricsKey struct {
+	sender   string
+	receiver string
+}
+
+type connMetrics struct {
+	count     uint64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// funcMetrics is shared between the handler wrapper and ports of the function.
+type funcMetrics struct {
+	path    string
+	ref     string
+	total   atomic.Int64 // nanoseconds
+	blocked blockedTime
+}
+
+// blockedTime measures wall-clock time during which at least one port of the function is blocked.
+// Ports of the same function can be blocked concurrently (e.g. parallel receives of binary operators),
+// so overlapping intervals are merged instead of being summed.
+// Nil blockedTime measures nothing, so ports of not measured functions don't pay for it.
+type blockedTime struct {
+	mu    sync.Mutex
+	ops   int       // number of currently blocked port operations
+	since time.Time // when ops became non-zero
+	total time.Duration
+}
+
+func (b *blockedTime) start() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.ops == 0 {
+		b.since = time.Now()
+	}
+	b.ops++
+	b.mu.Unlock()
+}
+
+func (b *blockedTime) stop() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.ops--
+	if b.ops == 0 {
+		b.total += time.Since(b.since)
+	}
+	b.mu.Unlock()
+}
+
+// load returns blocked time including the interval that is still in progress.
+func (b *blockedTime) load(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ops > 0 {
+		return b.total + now.Sub(b.since)
+	}
+	return b.total
+}
+
+func (m *MetricsInterceptor) Sent(sender PortSlotAddr, msg OrderedMsg) Msg {
+	addr := sender.String()
+	now := time.Now()
+
+	m.mu.Lock()
+	m.port(addr).sent++
+	m.pending[msg.index] = pendingMsg{sender: addr, sentAt: now}
+	m.mu.Unlock()
+
+	return msg.Msg
+}
+
+func (m *MetricsInterceptor) Received(receiver PortSlotAddr, msg OrderedMsg) Msg {
+	addr := receiver.String()
+	now := time.Now()
+
+	m.mu.Lock()
+	m.port(addr).received++
+	if pending, ok := m.pending[msg.index]; ok {
+		delete(m.pending, msg.index)
+		conn := m.conn(connMetricsKey{sender: pending.sender, receiver: addr})
+		wait := now.Sub(pending.sentAt)
+		conn.count++
+		conn.totalWait += wait
+		if wait > conn.maxWait {
+			conn.maxWait = wait
+		}
+	}
+	m.mu.Unlock()
+
+	return msg.Msg
+}
+
+// port must be called with m.mu locked.
+func (m *MetricsInterceptor) port(addr string) *portMetrics {
+	port, ok := m.ports[addr]
+	if !ok {
+		port = &portMetrics{}
+		m.ports[addr] = port
+	}
+	return port
+}
+
+// conn must be called with m.mu locked.
+func (m *MetricsInterceptor) conn(key connMetricsKey) *connMetrics {
+	conn, ok := m.conns[key]
+	if !ok {
+		conn = &connMetrics{}
+		m.conns[key] = conn
+	}
+	return conn
+}
+
+// measureFunc lets ports of the function measure time spent blocked on sending and receiving.
+// It must be called before the function is created, because functions copy their ports.
+func (m *MetricsInterceptor) measureFunc(call FuncCall) *funcMetrics {
+	stats := &funcMetrics{
+		path: call.IO.path(),
+		ref:  call.Ref,
+	}
+
+	m.funcsMu.Lock()
+	m.funcs = append(m.funcs, stats)
+	m.funcsMu.Unlock()
+
+	call.IO.measureBlocked(&stats.blocked)
+
+	return stats
+}
+
+// wrapHandler makes handler measure its own lifetime.
+func (f *funcMetrics) wrapHandler(handler func(context.Context)) func(context.Context) {
+	return func(ctx context.Context) {
+		start := time.Now()
+		handler(ctx)
+		f.total.Store(int64(time.Since(start)))
+	}
+}
+
+// MetricsSnapshot is a serializable state of collected metrics.
+type MetricsSnapshot struct {
+	Uptime      time.Duration       `json:"uptimeNs"`
+	Ports       []PortMetrics       `json:"ports"`
+	Connections []ConnectionMetrics `json:"connections"`
+	Funcs       []FuncMetrics       `json:"funcs"`
+}
+
+type PortMetrics struct {
+	Addr     string `json:"addr"`
+	Sent     uint64 `json:"sent"`
+	Received uint64 `json:"received"`
+}
+
+type ConnectionMetrics struct {
+	Sender    string        `json:"sender"`
+	Receiver  string        `json:"receiver"`
+	Messages  uint64        `json:"messages"`
+	TotalWait time.Duration `json:"totalWaitNs"` // Time between sending and receiving, summed over all messages.
+	MaxWait   time.Duration `json:"maxWaitNs"`
+}
+
+type FuncMetrics struct {
+	Path    string        `json:"path"`
+	Ref     string        `json:"ref"`
+	Total   time.Duration `json:"totalNs"`   // Lifetime of the function.
+	Blocked time.Duration `json:"blockedNs"` // Time spent waiting on ports.
+	Busy    time.Duration `json:"busyNs"`    // Time spent working, i.e. total minus blocked.
+}
+
+// Snapshot returns metrics collected so far, sorted by addresses.
+func (m *MetricsInterceptor) Snapshot() MetricsSnapshot {
+	snapshot := MetricsSnapshot{Uptime: time.Since(m.start)}
+
+	m.mu.Lock()
+	for addr, port := range m.ports {
+		snapshot.Ports = append(snapshot.Ports, PortMetrics{
+			Addr:     addr,
+			Sent:     port.sent,
+			Received: port.received,
+		})
+	}
+	for key, conn := range m.conns {
+		snapshot.Connections = append(snapshot.Connections, ConnectionMetrics{
+			Sender:    key.sender,
+			Receiver:  key.receiver,
+			Messages:  conn.count,
+			TotalWait: conn.totalWait,
+			MaxWait:   conn.maxWait,
+		})
+	}
+	m.mu.Unlock()
+
+	now := time.Now()
+
+	m.funcsMu.Lock()
+	for _, f := range m.funcs {
+		total := time.Duration(f.total.Load())
+		if total == 0 { // still running
+			total = snapshot.Uptime
+		}
+		blocked := f.blocked.load(now)
+		snapshot.Funcs = append(snapshot.Funcs, FuncMetrics{
+			Path:    f.path,
+			Ref:     f.ref,
+			Total:   total,
+			Blocked: blocked,
+			Busy:    max(total-blocked, 0),
+		})
+	}
+	m.funcsMu.Unlock()
+
+	sort.Slice(snapshot.Ports, func(i, j int) bool {
+		return snapshot.Ports[i].Addr < snapshot.Ports[j].Addr
+	})
+	sort.Slice(snapshot.Connections, func(i, j int) bool {
+		a, b := snapshot.Connections[i], snapshot.Connections[j]
+		if a.Sender != b.Sender {
+			return a.Sender < b.Sender
+		}
+		return a.Receiver < b.Receiver
+	})
+	sort.Slice(snapshot.Funcs, func(i, j int) bool {
+		return snapshot.Funcs[i].Path < snapshot.Funcs[j].Path
+	})
+
+	return snapshot
+}
+
+// Dump writes snapshot of collected metrics as JSON and as Prometheus text format.
+func (m *MetricsInterceptor) Dump(jsonPath, prometheusPath string) error {
+	snapshot := m.Snapshot()
+
+	bb, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(jsonPath, bb, 0644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(prometheusPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return snapshot.WritePrometheus(f)
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes snapshot in Prometheus text exposition format.
+func (s MetricsSnapshot) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+
+	label := func(name, value string) string {
+		return fmt.Sprintf(`%s="%s"`, name, prometheusLabelEscaper.Replace(value))
+	}
+
+	header := func(name, typ, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+
+	header("neva_port_messages_total", "counter", "Number of messages sent or received by the port.")
+	for _, port := range s.Ports {
+		// port is either sender or receiver, so one of the counters is always zero
+		if port.Sent > 0 {
+			fmt.Fprintf(&b, "neva_port_messages_total{%s,%s} %d\n", label("port", port.Addr), label("direction", "sent"), port.Sent)
+		}
+		if port.Received > 0 {
+			fmt.Fprintf(&b, "neva_port_messages_total{%s,%s} %d\n", label("port", port.Addr), label("direction", "recv"), port.Received)
+		}
+	}
+
+	header("neva_connection_messages_total", "counter", "Number of messages delivered through the connection.")
+	for _, conn := range s.Connections {
+		fmt.Fprintf(&b, "neva_connection_messages_total{%s,%s} %d\n", label("sender", conn.Sender), label("receiver", conn.Receiver), conn.Messages)
+	}
+
+	header("neva_connection_wait_seconds_total", "counter", "Time messages spent between being sent and received.")
+	for _, conn := range s.Connections {
+		fmt.Fprintf(&b, "neva_connection_wait_seconds_total{%s,%s} %g\n", label("sender", conn.Sender), label("receiver", conn.Receiver), conn.TotalWait.Seconds())
+	}
+
+	header("neva_connection_wait_seconds_max", "gauge", "Longest time a message spent between being sent and received.")
+	for _, conn := range s.Connections {
+		fmt.Fprintf(&b, "neva_connection_wait_seconds_max{%s,%s} %g\n", label("sender", conn.Sender), label("receiver", conn.Receiver), conn.MaxWait.Seconds())
+	}
+
+	header("neva_func_seconds_total", "counter", "Time function spent blocked on its ports or busy working.")
+	for _, f := range s.Funcs {
+		fmt.Fprintf(&b, "neva_func_seconds_total{%s,%s,%s} %g\n", label("func", f.Path), label("ref", f.Ref), label("state", "blocked"), f.Blocked.Seconds())
+		fmt.Fprintf(&b, "neva_func_seconds_total{%s,%s,%s} %g\n", label("func", f.Path), label("ref", f.Ref), label("state", "busy"), f.Busy.Seconds())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func NewMetricsInterceptor() *MetricsInterceptor {
+	return &MetricsInterceptor{
+		start:   time.Now(),
+		pending: map[uint64]pendingMsg{},
+		ports:   map[string]*portMetrics{},
+		conns:   map[connMetricsKey]*connMetrics{},
+	}
+}
@@ -0,0 +1,113 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockedTime_OverlappingIntervals(t *testing.T) {
+	var b blockedTime
+
+	b.start()
+	b.start() // e.g. send nested in receive callback
+	time.Sleep(50 * time.Millisecond)
+	b.stop()
+	b.stop()
+
+	blocked := b.load(time.Now())
+	require.GreaterOrEqual(t, blocked, 50*time.Millisecond)
+	require.Less(t, blocked, 100*time.Millisecond, "overlapping intervals must not be summed")
+}
+
+func TestBlockedTime_InProgress(t *testing.T) {
+	var b blockedTime
+
+	b.start()
+	time.Sleep(10 * time.Millisecond)
+
+	require.GreaterOrEqual(t, b.load(time.Now()), 10*time.Millisecond)
+}
+
+func TestBlockedTime_Nil(t *testing.T) {
+	var b *blockedTime
+	require.NotPanics(t, func() {
+		b.start()
+		b.stop()
+	})
+}
+
+func TestMetricsInterceptor_ParallelReceives(t *testing.T) {
+	m := NewMetricsInterceptor()
+
+	leftCh, rightCh := make(chan OrderedMsg), make(chan OrderedMsg)
+	call := FuncCall{
+		Ref: "Add",
+		IO: IO{
+			In: NewInports(map[string]Inport{
+				"left":  NewInport(nil, NewSingleInport(leftCh, PortAddr{Path: "add/in", Port: "left"}, m)),
+				"right": NewInport(nil, NewSingleInport(rightCh, PortAddr{Path: "add/in", Port: "right"}, m)),
+			}),
+		},
+	}
+
+	stats := m.measureFunc(call)
+
+	left, err := call.IO.In.Single("left")
+	require.NoError(t, err)
+	right, err := call.IO.In.Single("right")
+	require.NoError(t, err)
+
+	// receives both operands in parallel, like binary operators do
+	handler := stats.wrapHandler(func(ctx context.Context) {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { defer wg.Done(); left.Receive(ctx) }()
+		go func() { defer wg.Done(); right.Receive(ctx) }()
+		wg.Wait()
+	})
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		leftCh <- OrderedMsg{Msg: NewIntMsg(1)}
+		rightCh <- OrderedMsg{Msg: NewIntMsg(2)}
+	}()
+
+	handler(context.Background())
+
+	snapshot := m.Snapshot()
+	require.Len(t, snapshot.Funcs, 1)
+
+	f := snapshot.Funcs[0]
+	require.Equal(t, "add", f.Path)
+	require.Equal(t, "Add", f.Ref)
+	require.GreaterOrEqual(t, f.Blocked, 50*time.Millisecond)
+	require.LessOrEqual(t, f.Blocked, f.Total)
+	require.Equal(t, f.Total-f.Blocked, f.Busy)
+}
+
+func TestMetricsInterceptor_Connections(t *testing.T) {
+	m := NewMetricsInterceptor()
+
+	sender := PortSlotAddr{PortAddr: PortAddr{Path: "a/out", Port: "res"}}
+	receiver := PortSlotAddr{PortAddr: PortAddr{Path: "b/in", Port: "data"}}
+
+	for i := uint64(1); i <= 2; i++ {
+		msg := OrderedMsg{Msg: NewIntMsg(int64(i)), index: i}
+		m.Sent(sender, msg)
+		m.Received(receiver, msg)
+	}
+
+	snapshot := m.Snapshot()
+	require.Equal(t, []PortMetrics{
+		{Addr: "a/out:res", Sent: 2},
+		{Addr: "b/in:data", Received: 2},
+	}, snapshot.Ports)
+	require.Len(t, snapshot.Connections, 1)
+	require.Equal(t, "a/out:res", snapshot.Connections[0].Sender)
+	require.Equal(t, "b/in:data", snapshot.Connections[0].Receiver)
+	require.Equal(t, uint64(2), snapshot.Connections[0].Messages)
+}
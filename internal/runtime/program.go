@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -12,6 +13,9 @@ type Program struct {
 	Start     *SingleOutport // Start must be inport of the first function
 	Stop      *SingleInport  // Stop must be outport of the (one of the) terminator function(s)
 	FuncCalls []FuncCall
+	Metrics   *MetricsInterceptor // Optional, if set function handlers are measured
+	History   *HistoryInterceptor // Optional, if set flowtrace is reported on panic
+	Shutdown  <-chan struct{}     // Optional, closing it prevents start message from being sent
 }
 
 type FuncCall struct {
@@ -25,6 +29,43 @@ type IO struct {
 	Out Outports
 }
 
+// path returns path of the function node that owns the ports.
+func (io IO) path() string {
+	for _, port := range io.In.ports {
+		if port.single != nil {
+			return strings.TrimSuffix(port.single.addr.Path, "/in")
+		}
+		return strings.TrimSuffix(port.array.addr.Path, "/in")
+	}
+	for _, port := range io.Out.ports {
+		if port.single != nil {
+			return strings.TrimSuffix(port.single.addr.Path, "/out")
+		}
+		return strings.TrimSuffix(port.array.addr.Path, "/out")
+	}
+	return ""
+}
+
+// measureBlocked makes all ports of the function report when they are blocked.
+func (io IO) measureBlocked(blocked *blockedTime) {
+	for _, port := range io.In.ports {
+		if port.single != nil {
+			port.single.blocked = blocked
+		}
+		if port.array != nil {
+			port.array.blocked = blocked
+		}
+	}
+	for _, port := range io.Out.ports {
+		if port.single != nil {
+			port.single.blocked = blocked
+		}
+		if port.array != nil {
+			port.array.blocked = blocked
+		}
+	}
+}
+
 type Inports struct {
 	ports map[string]Inport
 }
@@ -79,6 +120,7 @@ type SingleInport struct {
 	ch          <-chan OrderedMsg
 	addr        PortAddr
 	interceptor Interceptor
+	blocked     *blockedTime // nil if function is not measured
 }
 
 func NewSingleInport(
@@ -86,26 +128,31 @@ func NewSingleInport(
 	addr PortAddr,
 	interceptor Interceptor,
 ) *SingleInport {
-	return &SingleInport{ch, addr, interceptor}
+	return &SingleInport{ch: ch, addr: addr, interceptor: interceptor}
+}
+
+func (s SingleInport) Addr() PortAddr {
+	return s.addr
 }
 
 func (s SingleInport) Receive(ctx context.Context) (Msg, bool) {
-	var msg Msg
+	s.blocked.start()
+	defer s.blocked.stop()
+	var v OrderedMsg
 	select {
 	case <-ctx.Done():
 		return nil, false
-	case v := <-s.ch:
-		msg = v.Msg
+	case v = <-s.ch:
 	}
 
-	msg = s.interceptor.Received(
+	msg := s.interceptor.Received(
 		PortSlotAddr{
 			PortAddr: PortAddr{
 				Path: s.addr.Path,
 				Port: s.addr.Port,
 			},
 		},
-		msg,
+		v,
 	)
 
 	return msg, true
@@ -129,6 +176,7 @@ type ArrayInport struct {
 	interceptor Interceptor
 	chans       []<-chan OrderedMsg
 	buf         []SelectedMsg // Select functionality needs buffer to guarantee correct order.
+	blocked     *blockedTime  // nil if function is not measured
 }
 
 func NewArrayInport(
@@ -148,6 +196,8 @@ func NewArrayInport(
 // It returns the received message and a boolean indicating success.
 // It returns false if the context is done or if the channel is closed.
 func (a ArrayInport) Receive(ctx context.Context, idx int) (Msg, bool) {
+	a.blocked.start()
+	defer a.blocked.stop()
 	select {
 	case <-ctx.Done():
 		return nil, false
@@ -161,7 +211,7 @@ func (a ArrayInport) Receive(ctx context.Context, idx int) (Msg, bool) {
 				},
 				Index: &index,
 			},
-			v.Msg,
+			v,
 		)
 		return msg, true
 	}
@@ -182,10 +232,14 @@ func (a ArrayInport) ReceiveAll(ctx context.Context, f func(idx int, msg Msg) bo
 	for idx := range a.chans {
 		go func(idx int) {
 			defer wg.Done()
+			// only waiting is measured, f is the work of the function
+			a.blocked.start()
 			select {
 			case <-ctx.Done():
+				a.blocked.stop()
 				success = false
 			case received := <-a.chans[idx]:
+				a.blocked.stop()
 				index := uint8(idx)
 				msg := a.interceptor.Received(
 					PortSlotAddr{
@@ -195,7 +249,7 @@ func (a ArrayInport) ReceiveAll(ctx context.Context, f func(idx int, msg Msg) bo
 						},
 						Index: &index,
 					},
-					received.Msg,
+					received,
 				)
 				resultChan <- f(idx, msg)
 			}
@@ -256,7 +310,7 @@ func (a ArrayInport) _select(ctx context.Context) ([]SelectedMsg, bool) {
 						},
 						Index: &index,
 					},
-					orderedMsg.Msg,
+					orderedMsg,
 				)
 				buf = append(buf, SelectedMsg{
 					OrderedMsg: OrderedMsg{
@@ -280,6 +334,8 @@ func (a ArrayInport) _select(ctx context.Context) ([]SelectedMsg, bool) {
 
 // Select returns oldest available message across all available array inport slots.
 func (a *ArrayInport) Select(ctx context.Context) (SelectedMsg, bool) {
+	a.blocked.start()
+	defer a.blocked.stop()
 	if len(a.buf) == 0 {
 		batch, ok := a._select(ctx)
 		if !ok {
@@ -348,6 +404,7 @@ type SingleOutport struct {
 	addr        PortAddr // TODO Meta{PortAddr, IntermediateConnections}
 	interceptor Interceptor
 	ch          chan<- OrderedMsg
+	blocked     *blockedTime // nil if function is not measured
 }
 
 func NewSingleOutport(
@@ -363,6 +420,9 @@ func NewSingleOutport(
 }
 
 func (s SingleOutport) Send(ctx context.Context, msg Msg) bool {
+	s.blocked.start()
+	defer s.blocked.stop()
+	index := counter.Add(1)
 	msg = s.interceptor.Sent(
 		PortSlotAddr{
 			PortAddr: PortAddr{
@@ -370,22 +430,24 @@ func (s SingleOutport) Send(ctx context.Context, msg Msg) bool {
 				Port: s.addr.Port,
 			},
 		},
-		msg,
+		OrderedMsg{Msg: msg, index: index},
 	)
 	select {
 	case <-ctx.Done():
 		return false
 	case s.ch <- OrderedMsg{
 		Msg:   msg,
-		index: counter.Add(1),
+		index: index,
 	}:
 		return true
 	}
 }
 
+// Interceptor observes (and can replace) every message that goes through the ports.
+// Ordered message is passed so interceptor can see chronological index of the message.
 type Interceptor interface {
-	Sent(PortSlotAddr, Msg) Msg
-	Received(PortSlotAddr, Msg) Msg
+	Sent(PortSlotAddr, OrderedMsg) Msg
+	Received(PortSlotAddr, OrderedMsg) Msg
 }
 
 type PortSlotAddr struct {
@@ -393,10 +455,18 @@ type PortSlotAddr struct {
 	Index *uint8 // nil means single port
 }
 
+func (p PortSlotAddr) String() string {
+	if p.Index == nil {
+		return fmt.Sprintf("%v:%v", p.Path, p.Port)
+	}
+	return fmt.Sprintf("%v:%v[%v]", p.Path, p.Port, *p.Index)
+}
+
 type ArrayOutport struct {
 	addr        PortAddr
 	interceptor Interceptor
 	slots       []chan<- OrderedMsg
+	blocked     *blockedTime // nil if function is not measured
 }
 
 func NewArrayOutport(addr PortAddr, interceptor Interceptor, slots []chan<- OrderedMsg) *ArrayOutport {
@@ -404,7 +474,10 @@ func NewArrayOutport(addr PortAddr, interceptor Interceptor, slots []chan<- Orde
 }
 
 func (a ArrayOutport) Send(ctx context.Context, idx uint8, msg Msg) bool {
-	a.interceptor.Sent(
+	a.blocked.start()
+	defer a.blocked.stop()
+	index := counter.Add(1)
+	msg = a.interceptor.Sent(
 		PortSlotAddr{
 			PortAddr: PortAddr{
 				Path: a.addr.Path,
@@ -412,12 +485,12 @@ func (a ArrayOutport) Send(ctx context.Context, idx uint8, msg Msg) bool {
 			},
 			Index: &idx,
 		},
-		msg,
+		OrderedMsg{Msg: msg, index: index},
 	)
 	select {
 	case <-ctx.Done():
 		return false
-	case a.slots[idx] <- OrderedMsg{Msg: msg, index: counter.Add(1)}:
+	case a.slots[idx] <- OrderedMsg{Msg: msg, index: index}:
 		return true
 	}
 }
@@ -429,22 +502,27 @@ func (a ArrayOutport) Send(ctx context.Context, idx uint8, msg Msg) bool {
 // Each slot is guaranteed to be handled only once.
 // TODO: figure out why this is the only working version of `SendAll`
 func (a ArrayOutport) SendAll(ctx context.Context, msg Msg) bool {
+	a.blocked.start()
+	defer a.blocked.stop()
 	var wg sync.WaitGroup
 	success := true
 
 	wg.Add(len(a.slots))
 	for idx := range a.slots {
 		go func(idx int) {
+			i := uint8(idx)
+			orderedMsg := OrderedMsg{Msg: msg, index: counter.Add(1)}
+			orderedMsg.Msg = a.interceptor.Sent(
+				PortSlotAddr{
+					PortAddr: a.addr,
+					Index:    &i,
+				},
+				orderedMsg,
+			)
 			select {
 			case <-ctx.Done():
 				success = false
-			case a.slots[idx] <- OrderedMsg{Msg: msg, index: counter.Add(1)}:
-				i := uint8(idx)
-				slotAddr := PortSlotAddr{
-					PortAddr: a.addr,
-					Index:    &i,
-				}
-				a.interceptor.Sent(slotAddr, msg)
+			case a.slots[idx] <- orderedMsg:
 			}
 			wg.Done()
 		}(idx)
@@ -3,6 +3,7 @@ package runtime
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 )
@@ -16,40 +17,130 @@ type FuncCreator interface {
 func Run(ctx context.Context, prog Program, registry map[string]FuncCreator) error {
 	// debugValidation(prog)
 
+	parentCtx := ctx
 	ctx, cancel := context.WithCancel(ctx)
 	go func() {
 		prog.Stop.Receive(ctx)
 		cancel() // normal termination
 	}()
 
-	runFuncs, err := deferFuncCalls(prog.FuncCalls, registry)
+	runFuncs, err := deferFuncCalls(prog.FuncCalls, registry, prog.Metrics)
 	if err != nil {
 		return err
 	}
 
+	var (
+		panicOnce sync.Once
+		panicErr  *PanicError
+	)
+
+	onPanic := func(path string, msg Msg) {
+		panicOnce.Do(func() {
+			panicErr = &PanicError{Path: path, Msg: msg}
+			if prog.History != nil {
+				panicErr.Flowtrace = prog.History.Path(parentPath(path), flowtraceLimit)
+			}
+			cancel() // abnormal termination
+		})
+	}
+
 	funcsFinished := make(chan struct{})
 
 	go func() {
 		// runFuncs blocks until context is cancelled (by the stop port or by panic)
-		runFuncs(context.WithValue(ctx, "cancel", cancel)) //nolint:staticcheck // SA1029
+		runFuncs(context.WithValue(ctx, panicCtxKey{}, onPanic))
 		close(funcsFinished)
 	}()
 
-	prog.Start.Send(
-		ctx,
-		NewStructMsg(nil, nil),
-	)
+	// on shutdown we don't start the program if it's not started yet,
+	// otherwise we let in-flight messages drain until ctx is done
+	startCtx, cancelStart := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-prog.Shutdown:
+			cancelStart()
+		case <-startCtx.Done():
+		}
+	}()
+
+	if !prog.Start.Send(startCtx, NewStructMsg(nil, nil)) {
+		cancel() // nobody is going to send stop message
+	}
+	cancelStart()
 
-	<-funcsFinished
+	select {
+	case <-funcsFinished:
+	case <-parentCtx.Done():
+		// program was terminated from outside (e.g. drain timeout after signal),
+		// funcs blocked outside of ports (e.g. reading stdin) can't be cancelled, so we don't wait for them
+		return context.Cause(parentCtx)
+	}
+
+	if panicErr != nil {
+		return panicErr
+	}
+
+	// parent context is done only if program was terminated from outside
+	if parentCtx.Err() != nil {
+		return context.Cause(parentCtx)
+	}
 
 	return nil
 }
 
+// flowtraceLimit is how many recent messages are reported when program panics.
+const flowtraceLimit = 20
+
+type panicCtxKey struct{}
+
+// Panic terminates the program so that Run returns PanicError.
+// Path is the path of the panicking node. Only the first panic is reported.
+// Outside of Run, e.g. in tests of funcs, Panic does nothing.
+func Panic(ctx context.Context, path string, msg Msg) {
+	if onPanic, ok := ctx.Value(panicCtxKey{}).(func(string, Msg)); ok {
+		onPanic(path, msg)
+	}
+}
+
+// PanicError is returned by Run when program is terminated by Panic.
+type PanicError struct {
+	Path      string         // Path of the panicking node.
+	Msg       Msg            // Message that caused panic.
+	Flowtrace []HistoryEvent // Recent messages inside the component where panic happened, oldest first.
+}
+
+func (p *PanicError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "panic: %v\n", p.Msg)
+
+	if len(p.Flowtrace) > 0 {
+		b.WriteString("\nflowtrace (most recent last):\n")
+		for _, event := range p.Flowtrace {
+			fmt.Fprintf(&b, "  %v | %v | %v\n", event.Dir, event.Addr, event.Msg)
+		}
+	}
+
+	fmt.Fprintf(&b, "\npanicked at: %v", p.Path)
+
+	return b.String()
+}
+
+// parentPath returns path of the component that contains node with given path.
+func parentPath(path string) string {
+	idx := strings.LastIndex(path, "/")
+	if idx == -1 {
+		return ""
+	}
+	return path[:idx]
+}
+
 func deferFuncCalls(
 	funcCalls []FuncCall,
 	registry map[string]FuncCreator,
+	metrics *MetricsInterceptor,
 ) (func(ctx context.Context), error) {
-	handlers, err := createHandlers(funcCalls, registry)
+	handlers, err := createHandlers(funcCalls, registry, metrics)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +159,11 @@ func deferFuncCalls(
 	}, nil
 }
 
-func createHandlers(funcCalls []FuncCall, registry map[string]FuncCreator) ([]func(context.Context), error) {
+func createHandlers(
+	funcCalls []FuncCall,
+	registry map[string]FuncCreator,
+	metrics *MetricsInterceptor,
+) ([]func(context.Context), error) {
 	funcs := make([]func(context.Context), len(funcCalls))
 
 	for i, call := range funcCalls {
@@ -77,11 +172,20 @@ func createHandlers(funcCalls []FuncCall, registry map[string]FuncCreator) ([]fu
 			return nil, fmt.Errorf("func creator not found: %v", call.Ref)
 		}
 
+		var stats *funcMetrics
+		if metrics != nil {
+			stats = metrics.measureFunc(call)
+		}
+
 		handler, err := creator.Create(call.IO, call.Config)
 		if err != nil {
 			return nil, fmt.Errorf("%v: %w", call.Ref, err)
 		}
 
+		if stats != nil {
+			handler = stats.wrapHandler(handler)
+		}
+
 		funcs[i] = handler
 	}
 
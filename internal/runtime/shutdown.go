@@ -0,0 +1,58 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ExitCodeInterrupted is the exit code of a program forcefully stopped by a repeated signal.
+const ExitCodeInterrupted = 130
+
+// ErrDrainTimeout is a cause of context cancellation when program
+// didn't finish in time after it was asked to shut down.
+var ErrDrainTimeout = errors.New("program didn't finish in time after shutdown signal")
+
+// HandleSignals makes program shut down gracefully on SIGINT and SIGTERM.
+// First signal closes returned channel, which must be passed to Program.Shutdown,
+// so program that is not started yet never starts. Started program has drainTimeout
+// to finish in-flight work, after that returned context is cancelled with ErrDrainTimeout
+// and Run returns without waiting for funcs that are still blocked.
+// Second signal terminates the process immediately.
+// Returned function must be called to release resources.
+func HandleSignals(ctx context.Context, drainTimeout time.Duration) (context.Context, <-chan struct{}, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	shutdown := make(chan struct{})
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigs:
+		}
+
+		close(shutdown)
+
+		timer := time.NewTimer(drainTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+			cancel(ErrDrainTimeout)
+		case <-sigs:
+			os.Exit(ExitCodeInterrupted)
+		}
+	}()
+
+	return ctx, shutdown, func() {
+		signal.Stop(sigs)
+		cancel(nil)
+	}
+}
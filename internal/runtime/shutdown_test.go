@@ -0,0 +1,75 @@
+package runtime
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type funcCreator func(IO, Msg) (func(context.Context), error)
+
+func (f funcCreator) Create(io IO, cfg Msg) (func(context.Context), error) { return f(io, cfg) }
+
+func TestRun_DrainTimeoutAfterSignal(t *testing.T) {
+	startCh, stopCh := make(chan OrderedMsg), make(chan OrderedMsg)
+
+	started := make(chan struct{})
+	blockForever := make(chan struct{})
+	defer close(blockForever)
+
+	registry := map[string]FuncCreator{
+		// like reading from stdin, func is blocked on something that ignores context
+		"block": funcCreator(func(io IO, _ Msg) (func(context.Context), error) {
+			sig, err := io.In.Single("sig")
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context) {
+				if _, ok := sig.Receive(ctx); !ok {
+					return
+				}
+				close(started)
+				<-blockForever
+			}, nil
+		}),
+	}
+
+	prog := Program{
+		Start: NewSingleOutport(PortAddr{Path: "in", Port: "start"}, ProdInterceptor{}, startCh),
+		Stop:  NewSingleInport(stopCh, PortAddr{Path: "out", Port: "stop"}, ProdInterceptor{}),
+		FuncCalls: []FuncCall{
+			{
+				Ref: "block",
+				IO: IO{
+					In: NewInports(map[string]Inport{
+						"sig": NewInport(nil, NewSingleInport(startCh, PortAddr{Path: "block/in", Port: "sig"}, ProdInterceptor{})),
+					}),
+				},
+			},
+		},
+	}
+
+	const drainTimeout = 100 * time.Millisecond
+
+	ctx, shutdown, release := HandleSignals(context.Background(), drainTimeout)
+	defer release()
+	prog.Shutdown = shutdown
+
+	result := make(chan error, 1)
+	go func() { result <- Run(ctx, prog, registry) }()
+
+	<-started
+	signaled := time.Now()
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+
+	select {
+	case err := <-result:
+		require.ErrorIs(t, err, ErrDrainTimeout)
+		require.GreaterOrEqual(t, time.Since(signaled), drainTimeout)
+	case <-time.After(5 * time.Second):
+		t.Fatal("program didn't exit after drain timeout")
+	}
+}
@@ -0,0 +1,178 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// TraceDirection tells whether traced message was sent or received.
+type TraceDirection string
+
+const (
+	TraceDirectionSent     TraceDirection = "sent"
+	TraceDirectionReceived TraceDirection = "recv"
+)
+
+// TraceEvent is one line of the structured (JSON Lines) trace.
+type TraceEvent struct {
+	Time  int64          `json:"ts"`   // Nanoseconds since program start, taken from monotonic clock.
+	Index uint64         `json:"idx"`  // Chronological index of the OrderedMsg.
+	Dir   TraceDirection `json:"dir"`  // Either sent or received.
+	Addr  TraceAddr      `json:"addr"` // Port (slot) that sent or received the message.
+	Msg   TraceMsg       `json:"msg"`  // Typed encoding of the message.
+}
+
+// TraceAddr is serializable version of PortSlotAddr.
+type TraceAddr struct {
+	Path  string `json:"path"`
+	Port  string `json:"port"`
+	Index *uint8 `json:"idx,omitempty"` // nil means single port
+}
+
+func (t TraceAddr) String() string {
+	if t.Index == nil {
+		return fmt.Sprintf("%v:%v", t.Path, t.Port)
+	}
+	return fmt.Sprintf("%v:%v[%v]", t.Path, t.Port, *t.Index)
+}
+
+// TraceMsg is a typed encoding of Msg.
+// Only the field that corresponds to the type is set.
+// Scalars are pointers so zero values like false or 0 are not omitted.
+type TraceMsg struct {
+	Type  TraceMsgType        `json:"type"`
+	Bool  *bool               `json:"bool,omitempty"`
+	Int   *int64              `json:"int,omitempty"`
+	Float *TraceFloat         `json:"float,omitempty"`
+	Str   *string             `json:"str,omitempty"`
+	Bytes []byte              `json:"bytes,omitempty"` // Base64 encoded.
+	List  []TraceMsg          `json:"list,omitempty"`
+	Map   map[string]TraceMsg `json:"map,omitempty"` // Dict entries or struct fields.
+	Tag   *uint8              `json:"tag,omitempty"` // Union tag.
+	Value *TraceMsg           `json:"value,omitempty"`
+}
+
+// TraceFloat is a float that can be encoded to JSON even if it's not finite.
+// NaN and infinities are encoded as strings "NaN", "+Inf" and "-Inf".
+type TraceFloat float64
+
+func (f TraceFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Inf"`), nil
+	}
+	return json.Marshal(v)
+}
+
+func (f *TraceFloat) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var v float64
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*f = TraceFloat(v)
+		return nil
+	}
+
+	switch s {
+	case "NaN":
+		*f = TraceFloat(math.NaN())
+	case "+Inf":
+		*f = TraceFloat(math.Inf(1))
+	case "-Inf":
+		*f = TraceFloat(math.Inf(-1))
+	default:
+		return fmt.Errorf("invalid float: %q", s)
+	}
+
+	return nil
+}
+
+// TraceMsgType is an enumeration of traced message types.
+type TraceMsgType string
+
+const (
+	TraceMsgTypeBool   TraceMsgType = "bool"
+	TraceMsgTypeInt    TraceMsgType = "int"
+	TraceMsgTypeFloat  TraceMsgType = "float"
+	TraceMsgTypeString TraceMsgType = "string"
+	TraceMsgTypeBytes  TraceMsgType = "bytes"
+	TraceMsgTypeList   TraceMsgType = "list"
+	TraceMsgTypeDict   TraceMsgType = "dict"
+	TraceMsgTypeStruct TraceMsgType = "struct"
+	TraceMsgTypeUnion  TraceMsgType = "union"
+)
+
+func NewTraceEvent(
+	elapsed time.Duration,
+	dir TraceDirection,
+	addr PortSlotAddr,
+	msg OrderedMsg,
+) TraceEvent {
+	return TraceEvent{
+		Time:  elapsed.Nanoseconds(),
+		Index: msg.index,
+		Dir:   dir,
+		Addr: TraceAddr{
+			Path:  addr.Path,
+			Port:  addr.Port,
+			Index: addr.Index,
+		},
+		Msg: NewTraceMsg(msg.Msg),
+	}
+}
+
+// NewTraceMsg encodes message recursively. It panics on unknown message type.
+func NewTraceMsg(msg Msg) TraceMsg {
+	switch v := msg.(type) {
+	case SelectedMsg: // wrappers are sometimes sent as is (e.g. by fan-in)
+		return NewTraceMsg(v.Msg)
+	case OrderedMsg:
+		return NewTraceMsg(v.Msg)
+	case BoolMsg:
+		return TraceMsg{Type: TraceMsgTypeBool, Bool: &v.v}
+	case IntMsg:
+		return TraceMsg{Type: TraceMsgTypeInt, Int: &v.v}
+	case FloatMsg:
+		f := TraceFloat(v.v)
+		return TraceMsg{Type: TraceMsgTypeFloat, Float: &f}
+	case StringMsg:
+		return TraceMsg{Type: TraceMsgTypeString, Str: &v.v}
+	case BytesMsg:
+		return TraceMsg{Type: TraceMsgTypeBytes, Bytes: v.v}
+	case ListMsg:
+		list := make([]TraceMsg, len(v.v))
+		for i, el := range v.v {
+			list[i] = NewTraceMsg(el)
+		}
+		return TraceMsg{Type: TraceMsgTypeList, List: list}
+	case DictMsg:
+		m := make(map[string]TraceMsg, len(v.v))
+		for k, el := range v.v {
+			m[k] = NewTraceMsg(el)
+		}
+		return TraceMsg{Type: TraceMsgTypeDict, Map: m}
+	case StructMsg:
+		m := make(map[string]TraceMsg, len(v.names))
+		for i, name := range v.names {
+			m[name] = NewTraceMsg(v.fields[i])
+		}
+		return TraceMsg{Type: TraceMsgTypeStruct, Map: m}
+	case UnionMsg:
+		union := TraceMsg{Type: TraceMsgTypeUnion, Tag: &v.tag}
+		if v.value != nil {
+			value := NewTraceMsg(v.value)
+			union.Value = &value
+		}
+		return union
+	}
+	panic(fmt.Sprintf("unknown message type: %T", msg))
+}
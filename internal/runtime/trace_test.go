@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTraceMsg_JSON(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Msg
+		want string
+	}{
+		{"false", NewBoolMsg(false), `{"type":"bool","bool":false}`},
+		{"zero int", NewIntMsg(0), `{"type":"int","int":0}`},
+		{"zero float", NewFloatMsg(0), `{"type":"float","float":0}`},
+		{"empty string", NewStringMsg(""), `{"type":"string","str":""}`},
+		{"nan", NewFloatMsg(math.NaN()), `{"type":"float","float":"NaN"}`},
+		{"+inf", NewFloatMsg(math.Inf(1)), `{"type":"float","float":"+Inf"}`},
+		{"-inf", NewFloatMsg(math.Inf(-1)), `{"type":"float","float":"-Inf"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bb, err := json.Marshal(NewTraceMsg(tt.msg))
+			require.NoError(t, err)
+			require.JSONEq(t, tt.want, string(bb))
+
+			var decoded TraceMsg
+			require.NoError(t, json.Unmarshal(bb, &decoded))
+			again, err := json.Marshal(decoded)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.want, string(again))
+		})
+	}
+}
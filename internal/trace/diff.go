@@ -0,0 +1,85 @@
+package trace
+
+import (
+	"sort"
+)
+
+// Divergence describes the first difference between two message flows of the same port.
+type Divergence struct {
+	Key      Key
+	Position int    // Index of the first differing message in the flow, starting from 0.
+	Left     *Event // nil means left flow ended earlier.
+	Right    *Event // nil means right flow ended earlier.
+}
+
+// Diff compares message flows of two traces port by port.
+// For every port that has different flows it reports the first message where they diverge.
+// Result is sorted by the position of divergence in the flow, so earliest divergences come first.
+// Divergences at the same position are ordered by the first appearance of their ports,
+// ports of the left trace go before ports that only appear in the right one.
+func Diff(left, right []Event) []Divergence {
+	leftFlows, leftKeys := Flows(left)
+	rightFlows, rightKeys := Flows(right)
+
+	keys := leftKeys
+	for _, key := range rightKeys {
+		if _, ok := leftFlows[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	var result []Divergence
+	for _, key := range keys {
+		if divergence, ok := diffFlows(key, leftFlows[key], rightFlows[key]); ok {
+			result = append(result, divergence)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Position < result[j].Position
+	})
+
+	return result
+}
+
+func diffFlows(key Key, left, right []Event) (Divergence, bool) {
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var l, r *Event
+		if i < len(left) {
+			l = &left[i]
+		}
+		if i < len(right) {
+			r = &right[i]
+		}
+		if l != nil && r != nil && l.Msg == r.Msg {
+			continue
+		}
+		return Divergence{
+			Key:      key,
+			Position: i,
+			Left:     l,
+			Right:    r,
+		}, true
+	}
+	return Divergence{}, false
+}
+
+// Flows groups events by direction and port address, preserving their order.
+// Keys are returned in order of their first appearance.
+func Flows(events []Event) (map[Key][]Event, []Key) {
+	flows := map[Key][]Event{}
+	keys := []Key{}
+
+	for _, event := range events {
+		key := Key{
+			Dir:  event.Dir,
+			Addr: event.Addr.String(),
+		}
+		if _, ok := flows[key]; !ok {
+			keys = append(keys, key)
+		}
+		flows[key] = append(flows[key], event)
+	}
+
+	return flows, keys
+}
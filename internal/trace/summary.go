@@ -0,0 +1,80 @@
+package trace
+
+import (
+	"sort"
+
+	"github.com/nevalang/neva/internal/runtime"
+)
+
+// PortSummary is a number of messages that went through the port.
+type PortSummary struct {
+	Addr     Addr
+	Sent     int
+	Received int
+}
+
+// NodeSummary aggregates port summaries of the same node.
+type NodeSummary struct {
+	Node     string
+	Ports    int
+	Sent     int
+	Received int
+}
+
+// Summary contains message counts per port and per node, both sorted by address.
+type Summary struct {
+	Ports []PortSummary
+	Nodes []NodeSummary
+}
+
+// Summarize counts messages per port and per node.
+func Summarize(events []Event) Summary {
+	ports := map[string]*PortSummary{}
+	nodes := map[string]*NodeSummary{}
+
+	for _, event := range events {
+		addr := event.Addr.String()
+
+		node, ok := nodes[event.Addr.Node]
+		if !ok {
+			node = &NodeSummary{Node: event.Addr.Node}
+			nodes[event.Addr.Node] = node
+		}
+
+		port, ok := ports[addr]
+		if !ok {
+			port = &PortSummary{Addr: event.Addr}
+			ports[addr] = port
+			node.Ports++
+		}
+
+		if event.Dir == runtime.TraceDirectionSent {
+			port.Sent++
+			node.Sent++
+		} else {
+			port.Received++
+			node.Received++
+		}
+	}
+
+	summary := Summary{
+		Ports: make([]PortSummary, 0, len(ports)),
+		Nodes: make([]NodeSummary, 0, len(nodes)),
+	}
+
+	for _, port := range ports {
+		summary.Ports = append(summary.Ports, *port)
+	}
+	for _, node := range nodes {
+		summary.Nodes = append(summary.Nodes, *node)
+	}
+
+	sort.Slice(summary.Ports, func(i, j int) bool {
+		return summary.Ports[i].Addr.String() < summary.Ports[j].Addr.String()
+	})
+	sort.Slice(summary.Nodes, func(i, j int) bool {
+		return summary.Nodes[i].Node < summary.Nodes[j].Node
+	})
+
+	return summary
+}
@@ -0,0 +1,205 @@
+// Package trace implements loading and comparison of trace files
+// written by programs built with the `emit-trace` flag.
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nevalang/neva/internal/runtime"
+)
+
+// Event is a single traced message, normalized so that text and JSON traces can be compared.
+type Event struct {
+	Line int                    // Line number in the trace file, starting from 1.
+	Dir  runtime.TraceDirection // Either sent or received.
+	Addr Addr                   // Port (slot) that sent or received the message.
+	Msg  string                 // Canonical representation of the message.
+}
+
+// Addr is a port slot address with `/in` and `/out` suffixes trimmed from the path.
+type Addr struct {
+	Node string // Path of the node that owns the port.
+	Port string
+	Slot *uint8 // nil means single port
+}
+
+func (a Addr) String() string {
+	if a.Slot == nil {
+		return fmt.Sprintf("%v:%v", a.Node, a.Port)
+	}
+	return fmt.Sprintf("%v:%v[%v]", a.Node, a.Port, *a.Slot)
+}
+
+// Key identifies a message flow - sequence of messages that went through the same port in the same direction.
+type Key struct {
+	Dir  runtime.TraceDirection
+	Addr string
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%v %v", k.Dir, k.Addr)
+}
+
+// Load reads trace file in either text or JSON Lines format.
+func Load(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	events, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return events, nil
+}
+
+// Parse parses trace line by line. Format is detected per line.
+func Parse(r io.Reader) ([]Event, error) {
+	var events []Event
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) // messages can be big
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		var (
+			event Event
+			err   error
+		)
+		if strings.HasPrefix(text, "{") {
+			event, err = parseJSONLine(text)
+		} else {
+			event, err = parseTextLine(text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		event.Line = line
+		events = append(events, event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func parseJSONLine(line string) (Event, error) {
+	var traceEvent runtime.TraceEvent
+	if err := json.Unmarshal([]byte(line), &traceEvent); err != nil {
+		return Event{}, err
+	}
+
+	dir, err := parseDirection(string(traceEvent.Dir))
+	if err != nil {
+		return Event{}, err
+	}
+
+	// json marshals map keys in sorted order so the result is canonical
+	msg, err := json.Marshal(traceEvent.Msg)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Dir: dir,
+		Addr: Addr{
+			Node: trimPortDirection(traceEvent.Addr.Path),
+			Port: traceEvent.Addr.Port,
+			Slot: traceEvent.Addr.Index,
+		},
+		Msg: string(msg),
+	}, nil
+}
+
+// parseTextLine parses lines like `sent | node:port[idx] | msg`.
+func parseTextLine(line string) (Event, error) {
+	parts := strings.SplitN(line, " | ", 3)
+	if len(parts) != 3 {
+		return Event{}, fmt.Errorf("unexpected trace line: %q", line)
+	}
+
+	dir, err := parseDirection(parts[0])
+	if err != nil {
+		return Event{}, err
+	}
+
+	addr, err := parseAddr(parts[1])
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Dir:  dir,
+		Addr: addr,
+		Msg:  parts[2],
+	}, nil
+}
+
+func parseDirection(s string) (runtime.TraceDirection, error) {
+	switch dir := runtime.TraceDirection(s); dir {
+	case runtime.TraceDirectionSent, runtime.TraceDirectionReceived:
+		return dir, nil
+	}
+	return "", fmt.Errorf("unknown direction: %q", s)
+}
+
+func parseAddr(s string) (Addr, error) {
+	colonIdx := strings.LastIndex(s, ":")
+	if colonIdx == -1 {
+		return Addr{}, fmt.Errorf("invalid port address: %q", s)
+	}
+
+	addr := Addr{
+		Node: trimPortDirection(s[:colonIdx]),
+		Port: s[colonIdx+1:],
+	}
+
+	bracketIdx := strings.Index(addr.Port, "[")
+	if bracketIdx == -1 {
+		return addr, nil
+	}
+
+	if !strings.HasSuffix(addr.Port, "]") {
+		return Addr{}, fmt.Errorf("invalid port address: %q", s)
+	}
+
+	slot, err := strconv.ParseUint(addr.Port[bracketIdx+1:len(addr.Port)-1], 10, 8)
+	if err != nil {
+		return Addr{}, fmt.Errorf("invalid slot index: %q: %w", s, err)
+	}
+
+	idx := uint8(slot)
+	addr.Slot = &idx
+	addr.Port = addr.Port[:bracketIdx]
+
+	return addr, nil
+}
+
+// trimPortDirection removes `/in` or `/out` suffix that runtime adds to port paths.
+func trimPortDirection(path string) string {
+	if path == "in" || path == "out" {
+		return ""
+	}
+	path = strings.TrimSuffix(path, "/in")
+	path = strings.TrimSuffix(path, "/out")
+	return path
+}
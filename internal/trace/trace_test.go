@@ -0,0 +1,137 @@
+package trace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nevalang/neva/internal/runtime"
+)
+
+func TestParse(t *testing.T) {
+	textTrace := strings.Join([]string{
+		`sent | :start | {}`,
+		`recv | __fan_in__1:data[1] | "a | b"`,
+	}, "\n")
+
+	jsonTrace := strings.Join([]string{
+		`{"ts":1,"idx":1,"dir":"sent","addr":{"path":"in","port":"start"},"msg":{"type":"struct"}}`,
+		`{"ts":2,"idx":2,"dir":"recv","addr":{"path":"__fan_in__1/in","port":"data","idx":1},"msg":{"type":"string","str":"a | b"}}`,
+	}, "\n")
+
+	for _, tt := range []struct {
+		name  string
+		input string
+		msgs  []string
+	}{
+		{name: "text", input: textTrace, msgs: []string{`{}`, `"a | b"`}},
+		{name: "json", input: jsonTrace, msgs: []string{`{"type":"struct"}`, `{"type":"string","str":"a | b"}`}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Parse(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			require.Len(t, events, 2)
+
+			require.Equal(t, runtime.TraceDirectionSent, events[0].Dir)
+			require.Equal(t, ":start", events[0].Addr.String())
+			require.Equal(t, tt.msgs[0], events[0].Msg)
+
+			require.Equal(t, runtime.TraceDirectionReceived, events[1].Dir)
+			require.Equal(t, "__fan_in__1:data[1]", events[1].Addr.String())
+			require.Equal(t, tt.msgs[1], events[1].Msg)
+			require.Equal(t, 2, events[1].Line)
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	left, err := Parse(strings.NewReader(strings.Join([]string{
+		`sent | a:res | 1`,
+		`sent | b:res | 2`,
+		`recv | c:data | 1`,
+		`recv | c:data | 2`,
+	}, "\n")))
+	require.NoError(t, err)
+
+	right, err := Parse(strings.NewReader(strings.Join([]string{
+		`sent | b:res | 2`,
+		`sent | a:res | 1`,
+		`recv | c:data | 2`,
+		`recv | c:data | 1`,
+		`recv | c:data | 3`,
+	}, "\n")))
+	require.NoError(t, err)
+
+	divergences := Diff(left, right)
+	require.Len(t, divergences, 1)
+
+	d := divergences[0]
+	require.Equal(t, Key{Dir: runtime.TraceDirectionReceived, Addr: "c:data"}, d.Key)
+	require.Equal(t, 0, d.Position)
+	require.Equal(t, "1", d.Left.Msg)
+	require.Equal(t, "2", d.Right.Msg)
+
+	require.Empty(t, Diff(left, left))
+}
+
+func TestDiffOrder(t *testing.T) {
+	// left flow of a:res is shorter, c:res only exists in the left trace
+	left, err := Parse(strings.NewReader(strings.Join([]string{
+		`sent | a:res | 1`,
+		`sent | a:res | 2`,
+		`sent | c:res | 1`,
+		`sent | c:res | 2`,
+		`sent | b:res | 1`,
+	}, "\n")))
+	require.NoError(t, err)
+
+	right, err := Parse(strings.NewReader(strings.Join([]string{
+		`sent | a:res | 1`,
+		`sent | a:res | 2`,
+		`sent | a:res | 3`,
+		`sent | b:res | 9`,
+	}, "\n")))
+	require.NoError(t, err)
+
+	divergences := Diff(left, right)
+
+	// sorted by position, then by first appearance of the port
+	type divergence struct {
+		addr     string
+		position int
+	}
+	actual := []divergence{}
+	for _, d := range divergences {
+		actual = append(actual, divergence{d.Key.Addr, d.Position})
+	}
+	require.Equal(t, []divergence{{"c:res", 0}, {"b:res", 0}, {"a:res", 2}}, actual)
+
+	require.Nil(t, divergences[0].Right)
+	require.Nil(t, divergences[2].Left)
+	require.Equal(t, "3", divergences[2].Right.Msg)
+}
+
+func TestSummarize(t *testing.T) {
+	events, err := Parse(strings.NewReader(strings.Join([]string{
+		`sent | a:res | 1`,
+		`recv | b:data | 1`,
+		`sent | b:res | 1`,
+		`sent | a:res | 2`,
+		`recv | b:data | 2`,
+	}, "\n")))
+	require.NoError(t, err)
+
+	summary := Summarize(events)
+
+	require.Equal(t, []PortSummary{
+		{Addr: Addr{Node: "a", Port: "res"}, Sent: 2},
+		{Addr: Addr{Node: "b", Port: "data"}, Received: 2},
+		{Addr: Addr{Node: "b", Port: "res"}, Sent: 1},
+	}, summary.Ports)
+
+	require.Equal(t, []NodeSummary{
+		{Node: "a", Ports: 1, Sent: 2},
+		{Node: "b", Ports: 2, Sent: 1, Received: 2},
+	}, summary.Nodes)
+}